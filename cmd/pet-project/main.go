@@ -1,20 +1,28 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"log"
 	"net/http"
+	"pet-project/config"
 	"pet-project/internal/handler"
+	"pet-project/internal/mailer"
 	"pet-project/internal/middleware"
 	"pet-project/internal/realtime"
 	"pet-project/internal/repository"
 	"pet-project/internal/service"
+	"pet-project/internal/storage"
+	"pet-project/pkg/model"
 
 	"github.com/go-chi/chi"
 	_ "github.com/lib/pq"
 )
 
 func main() {
+	cfg := config.Load()
+
 	connStr := "host=localhost port=5432 user=petuser password=petpassword dbname=petprojectdb sslmode=disable"
 
 	db, err := sql.Open("postgres", connStr)
@@ -28,6 +36,41 @@ func main() {
 	taskRepo := &repository.PostgresTaskRepository{DB: db}
 	comRepo := &repository.PostgresCommentsRepository{DB: db}
 	notRepo := &repository.PostgresNotificationRepository{DB: db}
+	attachmentRepo := &repository.PostgresAttachmentRepository{DB: db}
+	dependencyRepo := &repository.PostgresDependencyRepository{DB: db}
+	labelRepo := &repository.PostgresLabelRepository{DB: db}
+	customFieldRepo := &repository.PostgresCustomFieldRepository{DB: db}
+	memberRepo := &repository.PostgresTaskMemberRepository{DB: db}
+	worklogRepo := &repository.PostgresWorklogRepository{DB: db}
+	recurrenceRepo := &repository.PostgresRecurrenceRepository{DB: db}
+	reminderRepo := &repository.PostgresReminderRepository{DB: db}
+	locker := &repository.PostgresLocker{DB: db}
+	sprintRepo := &repository.PostgresSprintRepository{DB: db}
+	statsRepo := &repository.PostgresStatsRepository{DB: db}
+	searchRepo := &repository.PostgresSearchRepository{DB: db}
+	savedFilterRepo := &repository.PostgresSavedFilterRepository{DB: db}
+	automationRepo := &repository.PostgresAutomationRepository{DB: db}
+	webhookRepo := &repository.PostgresWebhookRepository{DB: db}
+	outboxRepo := &repository.PostgresOutboxRepository{DB: db}
+	emailRepo := &repository.PostgresEmailRepository{DB: db}
+	inboundEmailRepo := &repository.PostgresInboundEmailRepository{DB: db}
+	gitRepo := &repository.PostgresGitRepository{DB: db}
+	chatRepo := &repository.PostgresChatRepository{DB: db}
+	calendarRepo := &repository.PostgresCalendarRepository{DB: db}
+	importRepo := &repository.PostgresImportRepository{DB: db}
+
+	fileStorage, err := newStorage(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	mail, err := newMailer(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	mailTemplates, err := mailer.LoadTemplates()
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	clientManager := realtime.NewClientManager()
 
@@ -38,22 +81,201 @@ func main() {
 	projectService := &service.ProjectService{
 		Repository: projectRepo,
 	}
+	notService := &service.NotificationService{
+		Repository:    notRepo,
+		ClientManager: clientManager,
+	}
+	attachmentService := &service.AttachmentService{
+		Repository:   attachmentRepo,
+		Tasks:        taskRepo,
+		Comments:     comRepo,
+		Storage:      fileStorage,
+		MaxSize:      cfg.AttachmentMaxSize,
+		AllowedTypes: cfg.AttachmentTypes,
+	}
 	taskService := &service.TaskService{
-		Repository: taskRepo,
+		Repository:   taskRepo,
+		Attachments:  attachmentService,
+		Dependencies: dependencyRepo,
+		Labels:       labelRepo,
+		CustomFields: customFieldRepo,
+
+		Members:       memberRepo,
+		Notifications: notService,
+		Recurrences:   recurrenceRepo,
+		Projects:      projectService,
 	}
 	comService := &service.CommentsService{
 		Repository: comRepo,
+		Tasks:      taskService,
 	}
-	notService := &service.NotificationService{
-		Repository:    notRepo,
-		ClientManager: clientManager,
+	dependencyService := &service.DependencyService{
+		Repository: dependencyRepo,
+		Tasks:      taskRepo,
+	}
+	labelService := &service.LabelService{
+		Repository: labelRepo,
+		Tasks:      taskRepo,
+	}
+	customFieldService := &service.CustomFieldService{
+		Repository: customFieldRepo,
+	}
+	worklogService := &service.WorklogService{
+		Repository: worklogRepo,
+		Tasks:      taskRepo,
+	}
+	sprintService := &service.SprintService{
+		Repository: sprintRepo,
+		Tasks:      taskRepo,
+	}
+	statsService := &service.StatsService{Repository: statsRepo}
+	searchService := &service.SearchService{Repository: searchRepo}
+	automationService := &service.AutomationService{
+		Repository: automationRepo,
+		Tasks:      taskService,
+		Comments:   comRepo,
+		Projects:   projectRepo,
+		Client:     &http.Client{Timeout: cfg.AutomationWebhookTimeout},
+		MaxDepth:   cfg.AutomationMaxDepth,
+	}
+	webhookService := &service.WebhookService{
+		Repository:  webhookRepo,
+		Tasks:       taskRepo,
+		Comments:    comRepo,
+		Client:      &http.Client{Timeout: cfg.WebhookTimeout},
+		MaxAttempts: cfg.WebhookMaxAttempts,
+		Backoff:     cfg.WebhookBackoff,
+		MaxBackoff:  cfg.WebhookMaxBackoff,
+	}
+	emailService := &service.EmailService{
+		Repository:  emailRepo,
+		Locker:      locker,
+		Mailer:      mail,
+		Templates:   mailTemplates,
+		From:        cfg.MailFrom,
+		DefaultMode: cfg.EmailDefaultMode,
+		ReplyDomain: cfg.MailReplyDomain,
+		ReplySecret: cfg.MailReplySecret,
+	}
+	gitService := &service.GitIntegrationService{
+		Repository: gitRepo,
+		Tasks:      taskService,
+		Comments:   comRepo,
+	}
+	chatService := &service.ChatService{
+		Repository: chatRepo,
+		Tasks:      taskService,
+		Comments:   comRepo,
+		Client:     &http.Client{Timeout: cfg.ChatTimeout},
+	}
+	calendarService := &service.CalendarService{
+		Repository: calendarRepo,
+		Tasks:      taskService,
+		Projects:   projectRepo,
+		AppURL:     cfg.AppURL,
+	}
+	importService := &service.ImportService{
+		Repository: importRepo,
+		Locker:     locker,
+		Tasks:      taskService,
+		Projects:   projectService,
+		Labels:     labelService,
+		Comments:   comRepo,
+		MaxSize:    cfg.ImportMaxSize,
+	}
+	inboundEmailService := &service.InboundEmailService{
+		Repository:  inboundEmailRepo,
+		Comments:    comService,
+		ReplySecret: cfg.MailReplySecret,
+	}
+	// Изменения пишутся в outbox в своей транзакции, подписчики получают их отсюда
+	outboxDispatcher := &service.OutboxDispatcher{
+		Repository:  outboxRepo,
+		MaxAttempts: cfg.OutboxMaxAttempts,
+		Backoff:     cfg.OutboxBackoff,
+		MaxBackoff:  cfg.OutboxMaxBackoff,
+		Retention:   cfg.OutboxRetention,
+	}
+	outboxDispatcher.Subscribe(model.TopicProjectEvent, "notifications", taskService.NotifyOnEvent)
+	outboxDispatcher.Subscribe(model.TopicProjectEvent, "automation", automationService.HandleEvent)
+	outboxDispatcher.Subscribe(model.TopicProjectEvent, "webhooks", webhookService.HandleEvent)
+	outboxDispatcher.Subscribe(model.TopicProjectEvent, "chat", chatService.HandleEvent)
+	outboxDispatcher.Subscribe(model.TopicNotification, "websocket", notService.PushNotification)
+	outboxDispatcher.Subscribe(model.TopicNotification, "email", emailService.HandleNotification)
+	savedFilterService := &service.SavedFilterService{
+		Repository: savedFilterRepo,
+		Tasks:      taskService,
+	}
+	reminderService := &service.ReminderService{
+		Repository:    reminderRepo,
+		Locker:        locker,
+		Members:       memberRepo,
+		Projects:      projectRepo,
+		Notifications: notService,
+		Windows:       cfg.ReminderWindows,
+		EscalateAfter: cfg.EscalateAfter,
 	}
 
 	authHandler := &handler.AuthHandler{AuthService: authService}
 	projectHandler := &handler.ProjectHandler{ProjectService: projectService}
-	taskHandler := &handler.TaskHandler{TaskService: taskService}
+	taskHandler := &handler.TaskHandler{TaskService: taskService, ProjectService: projectService}
 	commentsHandler := &handler.CommentsHandler{CommentsService: comService}
-	notificationHandler := &handler.NotificationHandler{NotificationService: notService}
+	notificationHandler := &handler.NotificationHandler{
+		NotificationService: notService,
+		EmailService:        emailService,
+	}
+	attachmentHandler := &handler.AttachmentHandler{AttachmentService: attachmentService}
+	dependencyHandler := &handler.DependencyHandler{
+		DependencyService: dependencyService,
+		ProjectService:    projectService,
+	}
+	labelHandler := &handler.LabelHandler{
+		LabelService:   labelService,
+		ProjectService: projectService,
+	}
+	customFieldHandler := &handler.CustomFieldHandler{
+		CustomFieldService: customFieldService,
+		ProjectService:     projectService,
+	}
+	worklogHandler := &handler.WorklogHandler{
+		WorklogService: worklogService,
+		ProjectService: projectService,
+	}
+	sprintHandler := &handler.SprintHandler{
+		SprintService:  sprintService,
+		ProjectService: projectService,
+	}
+	statsHandler := &handler.StatsHandler{
+		StatsService:   statsService,
+		ProjectService: projectService,
+	}
+	searchHandler := &handler.SearchHandler{SearchService: searchService}
+	savedFilterHandler := &handler.SavedFilterHandler{SavedFilterService: savedFilterService}
+	automationHandler := &handler.AutomationHandler{
+		AutomationService: automationService,
+		ProjectService:    projectService,
+	}
+	webhookHandler := &handler.WebhookHandler{
+		WebhookService: webhookService,
+		ProjectService: projectService,
+	}
+	gitHandler := &handler.GitIntegrationHandler{
+		GitService:     gitService,
+		ProjectService: projectService,
+	}
+	chatHandler := &handler.ChatHandler{
+		ChatService:    chatService,
+		ProjectService: projectService,
+	}
+	calendarHandler := &handler.CalendarHandler{CalendarService: calendarService}
+	importHandler := &handler.ImportHandler{
+		ImportService:  importService,
+		ProjectService: projectService,
+	}
+	inboundEmailHandler := &handler.InboundEmailHandler{
+		InboundEmailService: inboundEmailService,
+		Token:               cfg.InboundEmailToken,
+	}
 	notificationWSHandler := &handler.NotificationWSHandler{
 		ClientManager: clientManager,
 		JwtSecret:     []byte("supersecretkey"),
@@ -81,10 +303,31 @@ func main() {
 	r.Route("/projects", func(pr chi.Router) {
 		pr.Use(middleware.AuthMiddleware([]byte("supersecretkey")))
 
-		pr.Post("/", projectHandler.CreateProject)              // POST /projects — создание проекта
-		pr.Get("/{projectID}", projectHandler.GetProjectInfo)   // GET /projects/{id} — получение информации о проекте
-		pr.Put("/{projectID}", projectHandler.UpdateProject)    // PUT /projects/{id} — обновление проекта
-		pr.Delete("/{projectID}", projectHandler.DeleteProject) // DELETE /projects/{id} — удаление проекта
+		pr.Post("/", projectHandler.CreateProject)                                        // POST /projects — создание проекта
+		pr.Get("/{projectID}", projectHandler.GetProjectInfo)                             // GET /projects/{id} — получение информации о проекте
+		pr.Put("/{projectID}", projectHandler.UpdateProject)                              // PUT /projects/{id} — обновление проекта
+		pr.Delete("/{projectID}", projectHandler.DeleteProject)                           // DELETE /projects/{id} — удаление проекта
+		pr.Get("/{projectID}/tasks", taskHandler.ListByProjectTaskRequest)                // GET /projects/{id}/tasks — задачи проекта с фильтрами и сортировкой
+		pr.Get("/{projectID}/labels", labelHandler.ListLabelsRequest)                     // GET /projects/{id}/labels — каталог меток
+		pr.Post("/{projectID}/labels", labelHandler.CreateLabelRequest)                   // POST /projects/{id}/labels — создание метки
+		pr.Get("/{projectID}/labels/usage", labelHandler.LabelUsageRequest)               // GET /projects/{id}/labels/usage — сколько задач с каждой меткой
+		pr.Get("/{projectID}/dependencies", dependencyHandler.GetProjectGraphRequest)     // GET /projects/{id}/dependencies — граф зависимостей задач
+		pr.Get("/{projectID}/fields", customFieldHandler.ListFieldsRequest)               // GET /projects/{id}/fields — пользовательские поля проекта
+		pr.Post("/{projectID}/fields", customFieldHandler.CreateFieldRequest)             // POST /projects/{id}/fields — создание пользовательского поля
+		pr.Get("/{projectID}/worklogs/summary", worklogHandler.ProjectSummaryRequest)     // GET /projects/{id}/worklogs/summary?from=&to= — затраченное время по проекту
+		pr.Get("/{projectID}/board", taskHandler.GetBoardRequest)                         // GET /projects/{id}/board — канбан-доска: колонки по статусам с упорядоченными карточками
+		pr.Get("/{projectID}/sprints", sprintHandler.ListSprintsRequest)                  // GET /projects/{id}/sprints — спринты проекта
+		pr.Post("/{projectID}/sprints", sprintHandler.CreateSprintRequest)                // POST /projects/{id}/sprints — создание спринта
+		pr.Get("/{projectID}/stats", statsHandler.ProjectStatsRequest)                    // GET /projects/{id}/stats?weeks=12 — статистика проекта
+		pr.Get("/{projectID}/automations", automationHandler.ListRulesRequest)            // GET /projects/{id}/automations — правила автоматизации
+		pr.Post("/{projectID}/automations", automationHandler.CreateRuleRequest)          // POST /projects/{id}/automations — создание правила
+		pr.Get("/{projectID}/automations/runs", automationHandler.ListProjectRunsRequest) // GET /projects/{id}/automations/runs — журнал выполнения правил
+		pr.Get("/{projectID}/webhooks", webhookHandler.ListWebhooksRequest)               // GET /projects/{id}/webhooks — подписки проекта
+		pr.Post("/{projectID}/webhooks", webhookHandler.CreateWebhookRequest)             // POST /projects/{id}/webhooks — создание подписки, ответ содержит ключ подписи
+		pr.Get("/{projectID}/git-integrations", gitHandler.ListIntegrationsRequest)       // GET /projects/{id}/git-integrations — подключённые репозитории
+		pr.Post("/{projectID}/git-integrations", gitHandler.CreateIntegrationRequest)     // POST /projects/{id}/git-integrations — подключение репозитория, ответ содержит ключ подписи
+		pr.Get("/{projectID}/chat-integrations", chatHandler.ListIntegrationsRequest)     // GET /projects/{id}/chat-integrations — подключённые каналы Slack и Mattermost
+		pr.Post("/{projectID}/chat-integrations", chatHandler.CreateIntegrationRequest)   // POST /projects/{id}/chat-integrations — подключение канала
 	})
 
 	r.Route("/tasks", func(tr chi.Router) {
@@ -93,6 +336,144 @@ func main() {
 		tr.Put("/{taskID}", taskHandler.UpdateProjectRequest)
 		tr.Get("/{taskID}", taskHandler.GetByIDTaskRequest)
 		tr.Delete("/{taskID}", taskHandler.DeleteTaskRequest)
+		tr.Post("/{taskID}/attachments", attachmentHandler.UploadTaskAttachmentRequest)
+		tr.Get("/{taskID}/attachments", attachmentHandler.ListTaskAttachmentsRequest)
+		tr.Post("/{taskID}/subtasks", taskHandler.CreateSubtaskRequest)
+		tr.Get("/{taskID}/tree", taskHandler.GetTaskTreeRequest)
+		tr.Put("/{taskID}/parent", taskHandler.SetTaskParentRequest)
+		tr.Get("/{taskID}/blockers", dependencyHandler.ListBlockersRequest)
+		tr.Post("/{taskID}/blockers", dependencyHandler.AddBlockerRequest)
+		tr.Delete("/{taskID}/blockers/{blockerID}", dependencyHandler.RemoveBlockerRequest)
+		tr.Post("/{taskID}/labels", labelHandler.AddTaskLabelRequest)
+		tr.Delete("/{taskID}/labels/{labelID}", labelHandler.RemoveTaskLabelRequest)
+		tr.Post("/{taskID}/assignees", taskHandler.AddAssigneeRequest)
+		tr.Delete("/{taskID}/assignees/{userID}", taskHandler.RemoveAssigneeRequest)
+		tr.Post("/{taskID}/watchers", taskHandler.AddWatcherRequest)
+		tr.Delete("/{taskID}/watchers/{userID}", taskHandler.RemoveWatcherRequest)
+		tr.Put("/{taskID}/estimates", taskHandler.SetEstimatesRequest)
+		tr.Post("/{taskID}/move", taskHandler.MoveTaskRequest)
+		tr.Put("/{taskID}/sprint", sprintHandler.SetTaskSprintRequest)
+		tr.Get("/{taskID}/recurrence", taskHandler.GetRecurrenceRequest)
+		tr.Put("/{taskID}/recurrence", taskHandler.SetRecurrenceRequest)
+		tr.Delete("/{taskID}/recurrence", taskHandler.StopRecurrenceRequest)
+		tr.Get("/{taskID}/worklogs", worklogHandler.ListWorklogsRequest)
+		tr.Post("/{taskID}/worklogs", worklogHandler.CreateWorklogRequest)
+		tr.Get("/{taskID}/worklogs/summary", worklogHandler.TaskSummaryRequest)
+		tr.Put("/{taskID}/worklogs/{worklogID}", worklogHandler.UpdateWorklogRequest)
+		tr.Delete("/{taskID}/worklogs/{worklogID}", worklogHandler.DeleteWorklogRequest)
+		tr.Post("/{taskID}/timer/start", worklogHandler.StartTimerRequest)
+		tr.Post("/{taskID}/timer/stop", worklogHandler.StopTimerRequest)
+		tr.Get("/{taskID}/git-links", gitHandler.ListTaskLinksRequest)
+	})
+
+	r.Route("/worklogs", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware([]byte("supersecretkey")))
+		r.Get("/summary", worklogHandler.UserSummaryRequest)
+		r.Get("/timer", worklogHandler.GetTimerRequest)
+	})
+
+	r.Route("/search", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware([]byte("supersecretkey")))
+		r.Get("/", searchHandler.SearchRequest)
+	})
+
+	r.Route("/automations", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware([]byte("supersecretkey")))
+		r.Get("/{ruleID}", automationHandler.GetRuleRequest)
+		r.Put("/{ruleID}", automationHandler.UpdateRuleRequest)
+		r.Delete("/{ruleID}", automationHandler.DeleteRuleRequest)
+		r.Get("/{ruleID}/runs", automationHandler.ListRuleRunsRequest)
+	})
+
+	r.Route("/webhooks", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware([]byte("supersecretkey")))
+		r.Get("/{webhookID}", webhookHandler.GetWebhookRequest)
+		r.Put("/{webhookID}", webhookHandler.UpdateWebhookRequest)
+		r.Delete("/{webhookID}", webhookHandler.DeleteWebhookRequest)
+		r.Post("/{webhookID}/ping", webhookHandler.PingWebhookRequest)
+		r.Get("/{webhookID}/deliveries", webhookHandler.ListDeliveriesRequest)
+		r.Get("/{webhookID}/deliveries/{deliveryID}", webhookHandler.GetDeliveryRequest)
+		r.Post("/{webhookID}/deliveries/{deliveryID}/redeliver", webhookHandler.RedeliverRequest)
+	})
+
+	r.Route("/git-integrations", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware([]byte("supersecretkey")))
+		r.Get("/{integrationID}", gitHandler.GetIntegrationRequest)
+		r.Put("/{integrationID}", gitHandler.UpdateIntegrationRequest)
+		r.Delete("/{integrationID}", gitHandler.DeleteIntegrationRequest)
+	})
+
+	r.Route("/chat-integrations", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware([]byte("supersecretkey")))
+		r.Get("/{integrationID}", chatHandler.GetIntegrationRequest)
+		r.Put("/{integrationID}", chatHandler.UpdateIntegrationRequest)
+		r.Delete("/{integrationID}", chatHandler.DeleteIntegrationRequest)
+		r.Post("/{integrationID}/link-code", chatHandler.LinkCodeRequest)
+	})
+
+	// Webhook провайдера git и slash-команды мессенджеров проверяются подписью интеграции, а не JWT
+	r.Post("/integrations/git/{integrationID}", gitHandler.ReceiveRequest)
+	r.Post("/integrations/chat/{integrationID}/command", chatHandler.CommandRequest)
+
+	// Ответы на письма-уведомления присылает почтовый шлюз, он авторизуется общим токеном, а не JWT
+	if cfg.InboundEmailToken != "" && cfg.MailReplySecret != "" {
+		r.Post("/inbound/email", inboundEmailHandler.ReceiveEmailRequest)
+	}
+
+	r.Route("/filters", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware([]byte("supersecretkey")))
+		r.Post("/", savedFilterHandler.CreateFilterRequest)
+		r.Get("/", savedFilterHandler.ListFiltersRequest)
+		r.Get("/{filterID}", savedFilterHandler.GetFilterRequest)
+		r.Put("/{filterID}", savedFilterHandler.UpdateFilterRequest)
+		r.Delete("/{filterID}", savedFilterHandler.DeleteFilterRequest)
+		r.Get("/{filterID}/tasks", savedFilterHandler.RunFilterRequest)
+	})
+
+	r.Route("/calendar/feeds", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware([]byte("supersecretkey")))
+		r.Post("/", calendarHandler.CreateFeedRequest)
+		r.Get("/", calendarHandler.ListFeedsRequest)
+		r.Delete("/{feedID}", calendarHandler.DeleteFeedRequest)
+	})
+
+	// Календарные клиенты не умеют JWT: лента открывается по секретному токену в адресе
+	r.Get("/calendar/{token}", calendarHandler.FeedRequest)
+
+	r.Route("/imports", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware([]byte("supersecretkey")))
+		r.Post("/", importHandler.CreateImportRequest)
+		r.Get("/", importHandler.ListImportsRequest)
+		r.Get("/{jobID}", importHandler.GetImportRequest)
+		r.Post("/{jobID}/resume", importHandler.ResumeImportRequest)
+	})
+
+	r.Route("/stats", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware([]byte("supersecretkey")))
+		r.Get("/summary", statsHandler.UserSummaryRequest)
+	})
+
+	r.Route("/sprints", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware([]byte("supersecretkey")))
+		r.Get("/{sprintID}", sprintHandler.GetSprintRequest)
+		r.Put("/{sprintID}", sprintHandler.UpdateSprintRequest)
+		r.Delete("/{sprintID}", sprintHandler.DeleteSprintRequest)
+		r.Get("/{sprintID}/tasks", sprintHandler.ListSprintTasksRequest)
+		r.Post("/{sprintID}/start", sprintHandler.StartSprintRequest)
+		r.Post("/{sprintID}/complete", sprintHandler.CompleteSprintRequest)
+		r.Get("/{sprintID}/burndown", sprintHandler.BurndownRequest)
+	})
+
+	r.Route("/fields", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware([]byte("supersecretkey")))
+		r.Put("/{fieldID}", customFieldHandler.UpdateFieldRequest)
+		r.Delete("/{fieldID}", customFieldHandler.DeleteFieldRequest)
+	})
+
+	r.Route("/labels", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware([]byte("supersecretkey")))
+		r.Put("/{labelID}", labelHandler.UpdateLabelRequest)
+		r.Delete("/{labelID}", labelHandler.DeleteLabelRequest)
 	})
 
 	r.Route("/comments", func(r chi.Router) {
@@ -102,6 +483,15 @@ func main() {
 		r.Get("/task/{taskID}", commentsHandler.GetCommentsByTaskRequest)
 		r.Get("/user/{userID}", commentsHandler.GetCommentsByUserRequest)
 		r.Put("/{comID}", commentsHandler.UpdateCommentTextRequest)
+		r.Get("/{comID}/revisions", commentsHandler.GetCommentRevisionsRequest)
+		r.Post("/{comID}/attachments", attachmentHandler.UploadCommentAttachmentRequest)
+		r.Get("/{comID}/attachments", attachmentHandler.ListCommentAttachmentsRequest)
+	})
+
+	r.Route("/attachments", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware([]byte("supersecretkey")))
+		r.Get("/{attachmentID}", attachmentHandler.DownloadAttachmentRequest)
+		r.Delete("/{attachmentID}", attachmentHandler.DeleteAttachmentRequest)
 	})
 
 	r.Route("/notification", func(r chi.Router) {
@@ -110,10 +500,44 @@ func main() {
 		r.Get("/", notificationHandler.GetNotifications)
 		r.Post("/mark-read", notificationHandler.MarkAsRead)
 		r.Get("/unread-count", notificationHandler.CountUnread)
+		r.Get("/preferences", notificationHandler.GetPreferences)
+		r.Put("/preferences", notificationHandler.UpdatePreferences)
 	})
 
 	r.Get("/ws/notifications", notificationWSHandler.WSNotifications)
 
+	go taskService.RunRecurrenceScheduler(context.Background(), cfg.RecurrenceInterval)
+	go reminderService.Run(context.Background(), cfg.ReminderInterval)
+	go webhookService.Run(context.Background(), cfg.WebhookInterval)
+	go outboxDispatcher.Run(context.Background(), cfg.OutboxInterval)
+	go emailService.Run(context.Background(), cfg.DigestInterval)
+	go importService.Run(context.Background(), cfg.ImportInterval)
+
 	log.Println("Server started at :8080")
 	log.Fatal(http.ListenAndServe(":8080", r))
 }
+
+func newStorage(cfg config.Config) (storage.Storage, error) {
+	switch cfg.StorageDriver {
+	case "s3":
+		return storage.NewS3Storage(cfg.S3Endpoint, cfg.S3Bucket, cfg.S3Region, cfg.S3AccessKey, cfg.S3SecretKey)
+	case "", "local":
+		return storage.NewLocalStorage(cfg.StorageDir)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.StorageDriver)
+	}
+}
+
+// newMailer возвращает nil, если почта не настроена: тогда письма не отправляются
+func newMailer(cfg config.Config) (mailer.Mailer, error) {
+	switch cfg.MailDriver {
+	case "smtp":
+		return mailer.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword)
+	case "file":
+		return mailer.NewFileMailer(cfg.MailDir)
+	case "":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.MailDriver)
+	}
+}
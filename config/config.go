@@ -2,6 +2,7 @@ package config
 
 import (
 	"log"
+	"time"
 
 	"github.com/kelseyhightower/envconfig"
 )
@@ -12,6 +13,71 @@ type Config struct {
 	Port           string `envconfig:"PORT" default:""`
 	LogLevel       string `envconfig:""`
 	MigratitionDir string `envconfig:""`
+
+	// Хранилище вложений: "local" (каталог StorageDir) или "s3" (любое S3-совместимое)
+	StorageDriver     string   `envconfig:"STORAGE_DRIVER" default:"local"`
+	StorageDir        string   `envconfig:"STORAGE_DIR" default:"./data/attachments"`
+	S3Endpoint        string   `envconfig:"S3_ENDPOINT" default:""`
+	S3Bucket          string   `envconfig:"S3_BUCKET" default:""`
+	S3Region          string   `envconfig:"S3_REGION" default:"us-east-1"`
+	S3AccessKey       string   `envconfig:"S3_ACCESS_KEY" default:""`
+	S3SecretKey       string   `envconfig:"S3_SECRET_KEY" default:""`
+	AttachmentMaxSize int64    `envconfig:"ATTACHMENT_MAX_SIZE" default:"10485760"`
+	AttachmentTypes   []string `envconfig:"ATTACHMENT_TYPES" default:""`
+
+	// Как часто планировщик создаёт наступившие вхождения повторяющихся задач
+	RecurrenceInterval time.Duration `envconfig:"RECURRENCE_INTERVAL" default:"1m"`
+
+	// Напоминания о сроках: период проверки, окна до срока и задержка эскалации владельцу проекта
+	ReminderInterval time.Duration   `envconfig:"REMINDER_INTERVAL" default:"5m"`
+	ReminderWindows  []time.Duration `envconfig:"REMINDER_WINDOWS" default:"24h,1h"`
+	EscalateAfter    time.Duration   `envconfig:"ESCALATE_AFTER" default:"48h"`
+
+	// Автоматизация: сколько правил подряд может запустить одно событие и таймаут действия webhook
+	AutomationMaxDepth       int           `envconfig:"AUTOMATION_MAX_DEPTH" default:"5"`
+	AutomationWebhookTimeout time.Duration `envconfig:"AUTOMATION_WEBHOOK_TIMEOUT" default:"10s"`
+
+	// Webhooks проектов: период обработки очереди, таймаут запроса, число попыток и границы задержки между ними
+	WebhookInterval    time.Duration `envconfig:"WEBHOOK_INTERVAL" default:"5s"`
+	WebhookTimeout     time.Duration `envconfig:"WEBHOOK_TIMEOUT" default:"10s"`
+	WebhookMaxAttempts int           `envconfig:"WEBHOOK_MAX_ATTEMPTS" default:"8"`
+	WebhookBackoff     time.Duration `envconfig:"WEBHOOK_BACKOFF" default:"30s"`
+	WebhookMaxBackoff  time.Duration `envconfig:"WEBHOOK_MAX_BACKOFF" default:"1h"`
+
+	// Outbox: период разбора, повторы при ошибках подписчиков и срок хранения опубликованных сообщений
+	OutboxInterval    time.Duration `envconfig:"OUTBOX_INTERVAL" default:"1s"`
+	OutboxMaxAttempts int           `envconfig:"OUTBOX_MAX_ATTEMPTS" default:"12"`
+	OutboxBackoff     time.Duration `envconfig:"OUTBOX_BACKOFF" default:"5s"`
+	OutboxMaxBackoff  time.Duration `envconfig:"OUTBOX_MAX_BACKOFF" default:"30m"`
+	OutboxRetention   time.Duration `envconfig:"OUTBOX_RETENTION" default:"168h"`
+
+	// Почта: "smtp", "file" (письма .eml в MailDir) или пусто — не отправлять.
+	// EmailDefaultMode — режим для тех, кто его не выбирал: off, instant, hourly или daily
+	MailDriver       string        `envconfig:"MAIL_DRIVER" default:""`
+	MailFrom         string        `envconfig:"MAIL_FROM" default:"Pet Project <noreply@localhost>"`
+	MailDir          string        `envconfig:"MAIL_DIR" default:"./data/mail"`
+	SMTPHost         string        `envconfig:"SMTP_HOST" default:""`
+	SMTPPort         int           `envconfig:"SMTP_PORT" default:"587"`
+	SMTPUsername     string        `envconfig:"SMTP_USERNAME" default:""`
+	SMTPPassword     string        `envconfig:"SMTP_PASSWORD" default:""`
+	EmailDefaultMode string        `envconfig:"EMAIL_DEFAULT_MODE" default:"daily"`
+	DigestInterval   time.Duration `envconfig:"DIGEST_INTERVAL" default:"5m"`
+
+	// Ответы на письма: Reply-To вида reply+<задача>.<получатель>.<подпись>@MAIL_REPLY_DOMAIN, почтовый шлюз
+	// пересылает их на POST /inbound/email с заголовком Authorization: Bearer INBOUND_EMAIL_TOKEN
+	MailReplyDomain   string `envconfig:"MAIL_REPLY_DOMAIN" default:""`
+	MailReplySecret   string `envconfig:"MAIL_REPLY_SECRET" default:""`
+	InboundEmailToken string `envconfig:"INBOUND_EMAIL_TOKEN" default:""`
+
+	// Таймаут отправки событий во входящие webhook Slack и Mattermost
+	ChatTimeout time.Duration `envconfig:"CHAT_TIMEOUT" default:"10s"`
+
+	// Внешний адрес приложения: из него строятся ссылки на календарные ленты и задачи в них
+	AppURL string `envconfig:"APP_URL" default:"http://localhost:8080"`
+
+	// Импорт из Trello и Jira: период проверки очереди заданий и предельный размер выгрузки
+	ImportInterval time.Duration `envconfig:"IMPORT_INTERVAL" default:"5s"`
+	ImportMaxSize  int64         `envconfig:"IMPORT_MAX_SIZE" default:"20971520"`
 }
 
 func Load() Config {
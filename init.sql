@@ -26,22 +26,44 @@ CREATE TABLE tasks (
     priority VARCHAR(50) NOT NULL,
     assigned_to INT,
     project_id INT NOT NULL,
+    parent_id INT,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     due_date TIMESTAMP,
+    original_estimate INT,
+    remaining_estimate INT,
+    series_id INT,
+    rank VARCHAR(255) COLLATE "C" NOT NULL DEFAULT '',
+    sprint_id INT,
     FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE,
-    FOREIGN KEY (assigned_to) REFERENCES users(id)
+    FOREIGN KEY (assigned_to) REFERENCES users(id),
+    FOREIGN KEY (parent_id) REFERENCES tasks(id) ON DELETE SET NULL
 );
 
+CREATE INDEX idx_tasks_parent_id ON tasks(parent_id);
+
 CREATE TABLE comments (
     id SERIAL PRIMARY KEY,
     task_id INT NOT NULL,
     user_id INT,
-    text VARCHAR(255) NOT NULL,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-)
+    text TEXT NOT NULL,
+    edited BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP,
+    deleted_by INT
+);
+
+CREATE TABLE comment_revisions (
+    id SERIAL PRIMARY KEY,
+    comment_id INT NOT NULL,
+    text TEXT NOT NULL,
+    edited_by INT,
+    edited_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE CASCADE
+);
 
-DROP TABLE notification
+DROP TABLE notification;
 
 CREATE TABLE notification (
     id SERIAL PRIMARY KEY,
@@ -49,5 +71,507 @@ CREATE TABLE notification (
     type VARCHAR(50),
     message VARCHAR(255),
     is_read BOOLEAN DEFAULT FALSE,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-)
\ No newline at end of file
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    dedupe_key VARCHAR(100),
+    emailed_at TIMESTAMP,
+    task_id INT,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX idx_notification_dedupe ON notification(user_id, dedupe_key) WHERE dedupe_key IS NOT NULL;
+
+CREATE TABLE attachments (
+    id SERIAL PRIMARY KEY,
+    task_id INT NOT NULL,
+    comment_id INT,
+    user_id INT NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL,
+    storage_key VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_attachments_task_id ON attachments(task_id);
+CREATE INDEX idx_attachments_comment_id ON attachments(comment_id);
+
+CREATE TABLE task_dependencies (
+    blocker_id INT NOT NULL,
+    blocked_id INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (blocker_id, blocked_id),
+    CHECK (blocker_id <> blocked_id),
+    FOREIGN KEY (blocker_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (blocked_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_task_dependencies_blocked_id ON task_dependencies(blocked_id);
+
+CREATE TABLE labels (
+    id SERIAL PRIMARY KEY,
+    project_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    color VARCHAR(7) NOT NULL DEFAULT '#808080',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX idx_labels_project_name ON labels(project_id, lower(name));
+
+CREATE TABLE task_labels (
+    task_id INT NOT NULL,
+    label_id INT NOT NULL,
+    PRIMARY KEY (task_id, label_id),
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (label_id) REFERENCES labels(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_task_labels_label_id ON task_labels(label_id);
+
+CREATE TABLE custom_fields (
+    id SERIAL PRIMARY KEY,
+    project_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    options TEXT[] NOT NULL DEFAULT '{}',
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX idx_custom_fields_project_name ON custom_fields(project_id, lower(name));
+
+CREATE TABLE task_custom_values (
+    task_id INT NOT NULL,
+    field_id INT NOT NULL,
+    text_value TEXT,
+    number_value DOUBLE PRECISION,
+    date_value TIMESTAMP,
+    user_value INT,
+    options TEXT[],
+    PRIMARY KEY (task_id, field_id),
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (field_id) REFERENCES custom_fields(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_value) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_task_custom_values_field_id ON task_custom_values(field_id);
+
+CREATE TABLE task_assignees (
+    task_id INT NOT NULL,
+    user_id INT NOT NULL,
+    PRIMARY KEY (task_id, user_id),
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE task_watchers (
+    task_id INT NOT NULL,
+    user_id INT NOT NULL,
+    PRIMARY KEY (task_id, user_id),
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_task_assignees_user_id ON task_assignees(user_id);
+CREATE INDEX idx_task_watchers_user_id ON task_watchers(user_id);
+
+CREATE TABLE worklogs (
+    id SERIAL PRIMARY KEY,
+    task_id INT NOT NULL,
+    user_id INT NOT NULL,
+    minutes INT NOT NULL CHECK (minutes > 0),
+    work_date DATE NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_worklogs_task_id ON worklogs(task_id);
+CREATE INDEX idx_worklogs_user_id ON worklogs(user_id, work_date);
+
+CREATE TABLE timers (
+    user_id INT PRIMARY KEY,
+    task_id INT NOT NULL,
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+CREATE TABLE recurrences (
+    id SERIAL PRIMARY KEY,
+    project_id INT NOT NULL,
+    rule TEXT NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    priority VARCHAR(50) NOT NULL,
+    assigned_to INT,
+    start_at TIMESTAMP NOT NULL,
+    next_at TIMESTAMP,
+    generated INT NOT NULL DEFAULT 0,
+    created_by INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE,
+    FOREIGN KEY (assigned_to) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_recurrences_next_at ON recurrences(next_at) WHERE next_at IS NOT NULL;
+
+ALTER TABLE tasks ADD FOREIGN KEY (series_id) REFERENCES recurrences(id) ON DELETE SET NULL;
+CREATE INDEX idx_tasks_series_id ON tasks(series_id);
+
+CREATE TABLE task_reminders (
+    task_id INT NOT NULL,
+    kind VARCHAR(32) NOT NULL,
+    due_date TIMESTAMP NOT NULL,
+    sent_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (task_id, kind, due_date),
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_tasks_due_date ON tasks(due_date) WHERE status <> 'done';
+
+CREATE INDEX idx_tasks_board ON tasks(project_id, status, rank);
+
+CREATE TABLE sprints (
+    id SERIAL PRIMARY KEY,
+    project_id INT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    goal TEXT NOT NULL DEFAULT '',
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'planned',
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE,
+    CHECK (end_date >= start_date)
+);
+
+-- в проекте может быть только один активный спринт
+CREATE UNIQUE INDEX idx_sprints_active ON sprints(project_id) WHERE status = 'active';
+
+ALTER TABLE tasks ADD FOREIGN KEY (sprint_id) REFERENCES sprints(id) ON DELETE SET NULL;
+CREATE INDEX idx_tasks_sprint_id ON tasks(sprint_id);
+
+-- История изменений задач, по ней строится burndown. Пишется триггером, чтобы учитывать
+-- любые пути изменения (обновление, доска, закрытие подзадач, завершение спринта).
+CREATE TABLE task_history (
+    id BIGSERIAL PRIMARY KEY,
+    task_id INT NOT NULL,
+    field VARCHAR(32) NOT NULL,
+    old_value TEXT,
+    new_value TEXT,
+    changed_at TIMESTAMP NOT NULL DEFAULT LOCALTIMESTAMP,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_task_history_task_id ON task_history(task_id, changed_at);
+
+CREATE OR REPLACE FUNCTION log_task_history() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO task_history (task_id, field, old_value, new_value) VALUES
+            (NEW.id, 'status', NULL, NEW.status),
+            (NEW.id, 'sprint_id', NULL, NEW.sprint_id::TEXT),
+            (NEW.id, 'original_estimate', NULL, NEW.original_estimate::TEXT);
+        RETURN NEW;
+    END IF;
+
+    IF NEW.status IS DISTINCT FROM OLD.status THEN
+        INSERT INTO task_history (task_id, field, old_value, new_value) VALUES (NEW.id, 'status', OLD.status, NEW.status);
+    END IF;
+    IF NEW.sprint_id IS DISTINCT FROM OLD.sprint_id THEN
+        INSERT INTO task_history (task_id, field, old_value, new_value)
+            VALUES (NEW.id, 'sprint_id', OLD.sprint_id::TEXT, NEW.sprint_id::TEXT);
+    END IF;
+    IF NEW.original_estimate IS DISTINCT FROM OLD.original_estimate THEN
+        INSERT INTO task_history (task_id, field, old_value, new_value)
+            VALUES (NEW.id, 'original_estimate', OLD.original_estimate::TEXT, NEW.original_estimate::TEXT);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tasks_history AFTER INSERT OR UPDATE ON tasks
+    FOR EACH ROW EXECUTE FUNCTION log_task_history();
+
+-- Полнотекстовый поиск: конфигурация simple без стемминга, т.к. тексты бывают на разных языках
+ALTER TABLE tasks ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', COALESCE(title, '')), 'A') ||
+    setweight(to_tsvector('simple', COALESCE(description, '')), 'B')
+) STORED;
+ALTER TABLE comments ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    to_tsvector('simple', COALESCE(text, ''))
+) STORED;
+ALTER TABLE projects ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', COALESCE(name, '')), 'A') ||
+    setweight(to_tsvector('simple', COALESCE(description, '')), 'B')
+) STORED;
+
+CREATE INDEX idx_tasks_search ON tasks USING GIN (search_vector);
+CREATE INDEX idx_comments_search ON comments USING GIN (search_vector);
+CREATE INDEX idx_projects_search ON projects USING GIN (search_vector);
+
+-- Сохранённые фильтры: без project_id выполняются по всем доступным проектам, общими могут быть только фильтры проекта
+CREATE TABLE saved_filters (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    project_id INT,
+    name VARCHAR(255) NOT NULL,
+    query TEXT NOT NULL,
+    shared BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE,
+    CHECK (NOT shared OR project_id IS NOT NULL)
+);
+
+CREATE UNIQUE INDEX idx_saved_filters_name ON saved_filters(user_id, lower(name));
+CREATE INDEX idx_saved_filters_shared ON saved_filters(project_id) WHERE shared;
+
+-- Автоматизация: правила проектов и журнал их выполнения
+CREATE TABLE automation_rules (
+    id SERIAL PRIMARY KEY,
+    project_id INT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    trigger_event VARCHAR(50) NOT NULL,
+    trigger_field VARCHAR(50) NOT NULL DEFAULT '',
+    trigger_from TEXT,
+    trigger_to TEXT,
+    condition TEXT NOT NULL DEFAULT '',
+    actions JSONB NOT NULL,
+    created_by INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_automation_rules_event ON automation_rules(project_id, trigger_event) WHERE enabled;
+
+CREATE TABLE automation_runs (
+    id SERIAL PRIMARY KEY,
+    rule_id INT NOT NULL,
+    project_id INT NOT NULL,
+    task_id INT NOT NULL,
+    event VARCHAR(50) NOT NULL,
+    event_id BIGINT,
+    status VARCHAR(20) NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    depth INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (rule_id) REFERENCES automation_rules(id) ON DELETE CASCADE,
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_automation_runs_project ON automation_runs(project_id, created_at DESC);
+CREATE INDEX idx_automation_runs_rule ON automation_runs(rule_id, created_at DESC);
+CREATE INDEX idx_automation_runs_event ON automation_runs(rule_id, event_id) WHERE event_id IS NOT NULL;
+
+-- Webhooks проектов: подписки, очередь отправок и журнал попыток
+CREATE TABLE webhooks (
+    id SERIAL PRIMARY KEY,
+    project_id INT NOT NULL,
+    url TEXT NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    events TEXT[] NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhooks_project ON webhooks(project_id) WHERE active;
+
+-- payload хранится текстом, а не JSONB: подпись считается по точным байтам тела
+CREATE TABLE webhook_deliveries (
+    id SERIAL PRIMARY KEY,
+    webhook_id INT NOT NULL,
+    event VARCHAR(50) NOT NULL,
+    event_id BIGINT,
+    payload TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_status_code INT,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP,
+    FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, created_at DESC);
+CREATE UNIQUE INDEX idx_webhook_deliveries_event ON webhook_deliveries(webhook_id, event_id) WHERE event_id IS NOT NULL;
+
+CREATE TABLE webhook_attempts (
+    id SERIAL PRIMARY KEY,
+    delivery_id INT NOT NULL,
+    attempt INT NOT NULL,
+    status_code INT,
+    error TEXT NOT NULL DEFAULT '',
+    response TEXT NOT NULL DEFAULT '',
+    duration_ms INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (delivery_id) REFERENCES webhook_deliveries(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhook_attempts_delivery ON webhook_attempts(delivery_id);
+
+-- Outbox: сообщения пишутся в одной транзакции с изменением, диспетчер раздаёт их подписчикам
+CREATE TABLE outbox (
+    id BIGSERIAL PRIMARY KEY,
+    topic VARCHAR(50) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP
+);
+
+CREATE INDEX idx_outbox_due ON outbox(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_outbox_published ON outbox(published_at) WHERE status = 'published';
+
+-- Подписчики, уже обработавшие сообщение: при повторе сообщения они пропускаются
+CREATE TABLE outbox_consumed (
+    message_id BIGINT NOT NULL,
+    consumer VARCHAR(50) NOT NULL,
+    consumed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (message_id, consumer),
+    FOREIGN KEY (message_id) REFERENCES outbox(id) ON DELETE CASCADE
+);
+
+CREATE TABLE notification_preferences (
+    user_id INT PRIMARY KEY,
+    email_mode VARCHAR(10) CHECK (email_mode IN ('off', 'instant', 'hourly', 'daily')),
+    last_digest_at TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE inbound_emails (
+    message_id VARCHAR(255) PRIMARY KEY,
+    comment_id INT,
+    received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE SET NULL
+);
+
+CREATE TABLE git_integrations (
+    id SERIAL PRIMARY KEY,
+    project_id INT NOT NULL,
+    provider VARCHAR(20) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    close_status VARCHAR(50) NOT NULL DEFAULT 'done',
+    review_status VARCHAR(50) NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE git_links (
+    id SERIAL PRIMARY KEY,
+    task_id INT NOT NULL,
+    integration_id INT,
+    kind VARCHAR(20) NOT NULL,
+    ref VARCHAR(100) NOT NULL,
+    title TEXT NOT NULL DEFAULT '',
+    url TEXT NOT NULL DEFAULT '',
+    author VARCHAR(255) NOT NULL DEFAULT '',
+    state VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (task_id, integration_id, kind, ref),
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (integration_id) REFERENCES git_integrations(id) ON DELETE SET NULL
+);
+
+CREATE TABLE chat_integrations (
+    id SERIAL PRIMARY KEY,
+    project_id INT NOT NULL,
+    provider VARCHAR(20) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    webhook_url TEXT NOT NULL DEFAULT '',
+    events TEXT[] NOT NULL DEFAULT '{}',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE chat_accounts (
+    integration_id INT NOT NULL,
+    chat_user_id VARCHAR(100) NOT NULL,
+    chat_user_name VARCHAR(255) NOT NULL DEFAULT '',
+    user_id INT NOT NULL,
+    linked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (integration_id, chat_user_id),
+    FOREIGN KEY (integration_id) REFERENCES chat_integrations(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE chat_messages (
+    integration_id INT NOT NULL,
+    event_id BIGINT NOT NULL,
+    sent_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (integration_id, event_id),
+    FOREIGN KEY (integration_id) REFERENCES chat_integrations(id) ON DELETE CASCADE
+);
+
+CREATE TABLE calendar_feeds (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    project_id INT,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE
+);
+
+CREATE TABLE import_jobs (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    project_id INT,
+    source VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'done', 'failed')),
+    options JSONB NOT NULL DEFAULT '{}',
+    payload BYTEA,
+    total INT NOT NULL DEFAULT 0,
+    processed INT NOT NULL DEFAULT 0,
+    report JSONB,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE SET NULL
+);
+
+CREATE TABLE import_items (
+    job_id INT NOT NULL,
+    external_id VARCHAR(255) NOT NULL,
+    task_id INT NOT NULL,
+    comments INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (job_id, external_id),
+    FOREIGN KEY (job_id) REFERENCES import_jobs(id) ON DELETE CASCADE,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
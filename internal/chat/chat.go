@@ -0,0 +1,132 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ProviderSlack      = "slack"
+	ProviderMattermost = "mattermost"
+)
+
+var Providers = []string{ProviderSlack, ProviderMattermost}
+
+// MaxClockSkew — насколько X-Slack-Request-Timestamp может отличаться от текущего времени;
+// более старые запросы отклоняются, чтобы перехваченную подпись нельзя было повторить
+const MaxClockSkew = 5 * time.Minute
+
+// Command — slash-команда: Slack и Mattermost присылают одинаковые поля формы
+type Command struct {
+	UserID      string
+	UserName    string
+	ChannelID   string
+	Command     string
+	Text        string
+	ResponseURL string
+}
+
+// Response — ответ на команду. ephemeral видит только автор команды, in_channel — весь канал.
+type Response struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+func Ephemeral(format string, args ...any) *Response {
+	return &Response{ResponseType: "ephemeral", Text: fmt.Sprintf(format, args...)}
+}
+
+func InChannel(format string, args ...any) *Response {
+	return &Response{ResponseType: "in_channel", Text: fmt.Sprintf(format, args...)}
+}
+
+// Verify проверяет запрос команды. Slack подписывает "v0:timestamp:тело" ключом Signing Secret
+// (заголовки X-Slack-Signature и X-Slack-Request-Timestamp), Mattermost передаёт токен команды
+// в заголовке Authorization: Token или в поле token.
+func Verify(provider, secret string, header http.Header, body []byte, now time.Time) bool {
+	if secret == "" {
+		return false
+	}
+	switch provider {
+	case ProviderSlack:
+		timestamp := header.Get("X-Slack-Request-Timestamp")
+		sec, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return false
+		}
+		if skew := now.Sub(time.Unix(sec, 0)); skew > MaxClockSkew || skew < -MaxClockSkew {
+			return false
+		}
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte("v0:" + timestamp + ":"))
+		mac.Write(body)
+		expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(header.Get("X-Slack-Signature")), []byte(expected))
+	case ProviderMattermost:
+		token := strings.TrimPrefix(header.Get("Authorization"), "Token ")
+		if token == "" {
+			form, err := url.ParseQuery(string(body))
+			if err != nil {
+				return false
+			}
+			token = form.Get("token")
+		}
+		return subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+	}
+	return false
+}
+
+// ParseCommand разбирает тело запроса команды (application/x-www-form-urlencoded)
+func ParseCommand(body []byte) (*Command, error) {
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("chat: invalid command form: %w", err)
+	}
+	cmd := &Command{
+		UserID:      form.Get("user_id"),
+		UserName:    form.Get("user_name"),
+		ChannelID:   form.Get("channel_id"),
+		Command:     form.Get("command"),
+		Text:        strings.TrimSpace(form.Get("text")),
+		ResponseURL: form.Get("response_url"),
+	}
+	if cmd.UserID == "" {
+		return nil, fmt.Errorf("chat: command has no user_id")
+	}
+	return cmd, nil
+}
+
+// Post отправляет сообщение во входящий webhook канала; формат {"text": ...} понимают оба мессенджера
+func Post(ctx context.Context, client *http.Client, webhookURL, text string) error {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("chat: webhook responded with %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package chat
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// Пример из документации Slack «Verifying requests from Slack»
+const (
+	slackSecret    = "8f742231b10e8888abcd99yyyzzz85a5"
+	slackTimestamp = "1531420618"
+	slackBody      = "token=xyzz0WbapA4vBCDEFasx0q6G&team_id=T1DC2JH3J&team_domain=testteamnow&channel_id=G8PSS9T3V" +
+		"&channel_name=foobar&user_id=U2CERLKJA&user_name=roadrunner&command=%2Fwebhook-collect&text=" +
+		"&response_url=https%3A%2F%2Fhooks.slack.com%2Fcommands%2FT1DC2JH3J%2F397700885554%2F96rGlfmibIGlgcZRskXaIFfN" +
+		"&trigger_id=398738663015.47445629121.803a0bc887a14d10d2c447fce8b6703c"
+	slackSignature = "v0=a2114d57b48eac39b9ad189dd8316235a7b4a8d21a10bd27519666489c69b503"
+)
+
+func slackHeader(timestamp, signature string) http.Header {
+	return http.Header{"X-Slack-Request-Timestamp": {timestamp}, "X-Slack-Signature": {signature}}
+}
+
+func signSlack(secret, timestamp, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":" + body))
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerify(t *testing.T) {
+	sent := time.Unix(1531420618, 0)
+	const token = "mattermost-token"
+	mmBody := "token=" + token + "&user_id=abc&text=list"
+
+	tests := []struct {
+		name     string
+		provider string
+		secret   string
+		header   http.Header
+		body     string
+		now      time.Time
+		want     bool
+	}{
+		{name: "slack", provider: ProviderSlack, secret: slackSecret,
+			header: slackHeader(slackTimestamp, slackSignature), body: slackBody, now: sent, want: true},
+		{name: "slack within skew", provider: ProviderSlack, secret: slackSecret,
+			header: slackHeader(slackTimestamp, slackSignature), body: slackBody, now: sent.Add(MaxClockSkew), want: true},
+		{name: "slack replayed later", provider: ProviderSlack, secret: slackSecret,
+			header: slackHeader(slackTimestamp, slackSignature), body: slackBody, now: sent.Add(MaxClockSkew + time.Second)},
+		{name: "slack from the future", provider: ProviderSlack, secret: slackSecret,
+			header: slackHeader(slackTimestamp, slackSignature), body: slackBody, now: sent.Add(-MaxClockSkew - time.Second)},
+		{name: "slack wrong secret", provider: ProviderSlack, secret: "other",
+			header: slackHeader(slackTimestamp, slackSignature), body: slackBody, now: sent},
+		{name: "slack modified body", provider: ProviderSlack, secret: slackSecret,
+			header: slackHeader(slackTimestamp, slackSignature), body: slackBody + "&x=1", now: sent},
+		// подпись привязана к timestamp: подставить свежее время в старый запрос нельзя
+		{name: "slack shifted timestamp", provider: ProviderSlack, secret: slackSecret,
+			header: slackHeader("1531420900", slackSignature), body: slackBody, now: sent},
+		{name: "slack bad timestamp", provider: ProviderSlack, secret: slackSecret,
+			header: slackHeader("yesterday", signSlack(slackSecret, "yesterday", slackBody)), body: slackBody, now: sent},
+		{name: "slack missing signature", provider: ProviderSlack, secret: slackSecret,
+			header: slackHeader(slackTimestamp, ""), body: slackBody, now: sent},
+		{name: "mattermost form token", provider: ProviderMattermost, secret: token, header: http.Header{}, body: mmBody, want: true},
+		{name: "mattermost header token", provider: ProviderMattermost, secret: token,
+			header: http.Header{"Authorization": {"Token " + token}}, body: "user_id=abc", want: true},
+		{name: "mattermost wrong token", provider: ProviderMattermost, secret: "other", header: http.Header{}, body: mmBody},
+		{name: "mattermost no token", provider: ProviderMattermost, secret: token, header: http.Header{}, body: "user_id=abc"},
+		{name: "empty secret", provider: ProviderMattermost, secret: "", header: http.Header{}, body: "token=&user_id=abc"},
+		{name: "unknown provider", provider: "teams", secret: token, header: http.Header{}, body: mmBody},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verify(tt.provider, tt.secret, tt.header, []byte(tt.body), tt.now); got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifySlackFreshRequest(t *testing.T) {
+	now := time.Now()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	body := "user_id=U1&text=show+12"
+	header := slackHeader(timestamp, signSlack("secret", timestamp, body))
+	if !Verify(ProviderSlack, "secret", header, []byte(body), now) {
+		t.Error("Verify rejected a freshly signed request")
+	}
+}
+
+func TestLinkCode(t *testing.T) {
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	code := LinkCode("secret", 42, now.Add(10*time.Minute))
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		now    time.Time
+		user   int
+		ok     bool
+	}{
+		{name: "valid", secret: "secret", code: code, now: now, user: 42, ok: true},
+		{name: "surrounding spaces", secret: "secret", code: "  " + code + "\n", now: now, user: 42, ok: true},
+		{name: "expired", secret: "secret", code: code, now: now.Add(11 * time.Minute)},
+		{name: "wrong secret", secret: "other", code: code, now: now},
+		{name: "other user", secret: "secret", code: "43" + code[2:], now: now},
+		{name: "garbage", secret: "secret", code: "hello", now: now},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, ok := ParseLinkCode(tt.secret, tt.code, tt.now)
+			if user != tt.user || ok != tt.ok {
+				t.Errorf("ParseLinkCode(%q) = %d, %v, want %d, %v", tt.code, user, ok, tt.user, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package chat
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LinkCode выпускает код привязки аккаунта мессенджера к пользователю:
+// пользователь получает его в приложении и отправляет командой «/task link КОД». Код подписан
+// ключом интеграции и действует до expires, поэтому хранить его не нужно.
+func LinkCode(secret string, userID int, expires time.Time) string {
+	payload := fmt.Sprintf("%d-%d", userID, expires.Unix())
+	return payload + "-" + linkSignature(secret, payload)
+}
+
+// ParseLinkCode возвращает пользователя из кода; ok == false, если подпись неверна или срок истёк
+func ParseLinkCode(secret, code string, now time.Time) (userID int, ok bool) {
+	parts := strings.Split(strings.TrimSpace(code), "-")
+	if len(parts) != 3 {
+		return 0, false
+	}
+	payload := parts[0] + "-" + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(linkSignature(secret, payload))) {
+		return 0, false
+	}
+	userID, err := strconv.Atoi(parts[0])
+	if err != nil || userID <= 0 {
+		return 0, false
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || now.Unix() > expires {
+		return 0, false
+	}
+	return userID, true
+}
+
+func linkSignature(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("link:" + payload))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
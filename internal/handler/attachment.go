@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"errors"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"pet-project/internal/service"
+	"pet-project/internal/storage"
+	"pet-project/pkg/model"
+	"strconv"
+
+	"github.com/go-chi/chi"
+)
+
+type AttachmentHandler struct {
+	AttachmentService *service.AttachmentService
+}
+
+// readUploadedFile достаёт файл из multipart-поля "file", ограничивая размер тела запроса
+func (h *AttachmentHandler) readUploadedFile(w http.ResponseWriter, r *http.Request) (multipart.File, *multipart.FileHeader, error) {
+	maxSize := h.AttachmentService.MaxSize
+	if maxSize <= 0 {
+		maxSize = service.DefaultMaxAttachmentSize
+	}
+	// Запас на заголовки multipart
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+1<<20)
+
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		return nil, nil, errors.New("Invalid multipart form or file is too large")
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		return nil, nil, errors.New("File is required")
+	}
+	return file, header, nil
+}
+
+func (h *AttachmentHandler) UploadTaskAttachmentRequest(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		writeError(w, errors.New("Unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid task ID"), http.StatusBadRequest)
+		return
+	}
+
+	file, header, err := h.readUploadedFile(w, r)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	att, err := h.AttachmentService.UploadToTask(r.Context(), taskID, userID, header.Filename, header.Size, file)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, att, http.StatusCreated)
+}
+
+func (h *AttachmentHandler) UploadCommentAttachmentRequest(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		writeError(w, errors.New("Unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	comID, err := strconv.Atoi(chi.URLParam(r, "comID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid comment ID"), http.StatusBadRequest)
+		return
+	}
+
+	file, header, err := h.readUploadedFile(w, r)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	att, err := h.AttachmentService.UploadToComment(r.Context(), comID, userID, header.Filename, header.Size, file)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, att, http.StatusCreated)
+}
+
+func (h *AttachmentHandler) ListTaskAttachmentsRequest(w http.ResponseWriter, r *http.Request) {
+	taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid task ID"), http.StatusBadRequest)
+		return
+	}
+
+	attachments, err := h.AttachmentService.ListByTask(r.Context(), taskID, getUserIDFromContext(r))
+	if err != nil {
+		writeError(w, err, http.StatusNotFound)
+		return
+	}
+	writeJSON(w, attachments)
+}
+
+func (h *AttachmentHandler) ListCommentAttachmentsRequest(w http.ResponseWriter, r *http.Request) {
+	comID, err := strconv.Atoi(chi.URLParam(r, "comID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid comment ID"), http.StatusBadRequest)
+		return
+	}
+
+	attachments, err := h.AttachmentService.ListByComment(r.Context(), comID, getUserIDFromContext(r))
+	if err != nil {
+		writeError(w, err, http.StatusNotFound)
+		return
+	}
+	writeJSON(w, attachments)
+}
+
+// DownloadAttachmentRequest отдаёт содержимое через http.ServeContent — он сам обрабатывает Range и If-* заголовки
+func (h *AttachmentHandler) DownloadAttachmentRequest(w http.ResponseWriter, r *http.Request) {
+	attID, err := strconv.Atoi(chi.URLParam(r, "attachmentID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid attachment ID"), http.StatusBadRequest)
+		return
+	}
+
+	att, content, err := h.AttachmentService.Open(r.Context(), attID, getUserIDFromContext(r))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			writeError(w, errors.New("Attachment content is missing"), http.StatusNotFound)
+			return
+		}
+		writeError(w, service.ErrAttachmentNotFound, http.StatusNotFound)
+		return
+	}
+	defer content.Close()
+
+	setAttachmentHeaders(w, att)
+	http.ServeContent(w, r, att.FileName, att.CreatedAt, content)
+}
+
+func setAttachmentHeaders(w http.ResponseWriter, att *model.Attachment) {
+	w.Header().Set("Content-Type", att.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": att.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+}
+
+func (h *AttachmentHandler) DeleteAttachmentRequest(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		writeError(w, errors.New("Unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	attID, err := strconv.Atoi(chi.URLParam(r, "attachmentID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid attachment ID"), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.AttachmentService.Delete(r.Context(), attID, userID); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
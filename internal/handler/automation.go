@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"pet-project/internal/service"
+	"pet-project/pkg/model"
+	"strconv"
+)
+
+type AutomationHandler struct {
+	AutomationService *service.AutomationService
+	ProjectService    *service.ProjectService
+}
+
+type AutomationTriggerRequest struct {
+	Event string  `json:"event"`
+	Field string  `json:"field"`
+	From  *string `json:"from"`
+	To    *string `json:"to"`
+}
+
+type AutomationActionRequest struct {
+	Type   string `json:"type"`
+	Field  string `json:"field"`
+	Value  string `json:"value"`
+	UserID int    `json:"userID"`
+	Text   string `json:"text"`
+	To     string `json:"to"`
+	URL    string `json:"url"`
+}
+
+// AutomationRuleRequest — при обновлении отсутствующие поля не меняются, actions заменяются целиком
+type AutomationRuleRequest struct {
+	Name      *string                   `json:"name"`
+	Enabled   *bool                     `json:"enabled"`
+	Trigger   *AutomationTriggerRequest `json:"trigger"`
+	Condition *string                   `json:"condition"`
+	Actions   []AutomationActionRequest `json:"actions"`
+}
+
+func (req *AutomationRuleRequest) apply(rule *model.AutomationRule) {
+	if req.Name != nil {
+		rule.Name = *req.Name
+	}
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	if req.Trigger != nil {
+		rule.Trigger = model.AutomationTrigger{
+			Event: req.Trigger.Event,
+			Field: req.Trigger.Field,
+			From:  req.Trigger.From,
+			To:    req.Trigger.To,
+		}
+	}
+	if req.Condition != nil {
+		rule.Condition = *req.Condition
+	}
+	if req.Actions != nil {
+		rule.Actions = make([]model.AutomationAction, 0, len(req.Actions))
+		for _, action := range req.Actions {
+			rule.Actions = append(rule.Actions, model.AutomationAction{
+				Type:   action.Type,
+				Field:  action.Field,
+				Value:  action.Value,
+				UserID: action.UserID,
+				Text:   action.Text,
+				To:     action.To,
+				URL:    action.URL,
+			})
+		}
+	}
+}
+
+// ruleFromURL загружает правило из URL и проверяет доступ к его проекту
+func (h *AutomationHandler) ruleFromURL(w http.ResponseWriter, r *http.Request) (*model.AutomationRule, bool) {
+	ruleID, ok := idFromURL(w, r, "ruleID", "rule")
+	if !ok {
+		return nil, false
+	}
+	rule, err := h.AutomationService.GetRule(ruleID)
+	if err != nil {
+		writeError(w, errors.New("Rule not found"), http.StatusNotFound)
+		return nil, false
+	}
+	if !canAccessProject(w, r, h.ProjectService, rule.ProjectID) {
+		return nil, false
+	}
+	return rule, true
+}
+
+func (h *AutomationHandler) CreateRuleRequest(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := projectFromURL(w, r, h.ProjectService)
+	if !ok {
+		return
+	}
+
+	var req AutomationRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+
+	rule := &model.AutomationRule{ProjectID: projectID, Enabled: true, CreatedBy: getUserIDFromContext(r)}
+	req.apply(rule)
+	if err := h.AutomationService.CreateRule(rule); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, rule, http.StatusCreated)
+}
+
+func (h *AutomationHandler) ListRulesRequest(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := projectFromURL(w, r, h.ProjectService)
+	if !ok {
+		return
+	}
+
+	rules, err := h.AutomationService.ListRules(projectID)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, rules)
+}
+
+func (h *AutomationHandler) GetRuleRequest(w http.ResponseWriter, r *http.Request) {
+	rule, ok := h.ruleFromURL(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, rule)
+}
+
+func (h *AutomationHandler) UpdateRuleRequest(w http.ResponseWriter, r *http.Request) {
+	rule, ok := h.ruleFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var req AutomationRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+	req.apply(rule)
+
+	if err := h.AutomationService.UpdateRule(rule); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, rule)
+}
+
+func (h *AutomationHandler) DeleteRuleRequest(w http.ResponseWriter, r *http.Request) {
+	rule, ok := h.ruleFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.AutomationService.DeleteRule(rule.ID); err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListProjectRunsRequest обрабатывает GET /projects/{projectID}/automations/runs?limit=50
+func (h *AutomationHandler) ListProjectRunsRequest(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := projectFromURL(w, r, h.ProjectService)
+	if !ok {
+		return
+	}
+	h.writeRuns(w, r, projectID, nil)
+}
+
+// ListRuleRunsRequest обрабатывает GET /automations/{ruleID}/runs?limit=50
+func (h *AutomationHandler) ListRuleRunsRequest(w http.ResponseWriter, r *http.Request) {
+	rule, ok := h.ruleFromURL(w, r)
+	if !ok {
+		return
+	}
+	h.writeRuns(w, r, rule.ProjectID, &rule.ID)
+}
+
+func (h *AutomationHandler) writeRuns(w http.ResponseWriter, r *http.Request, projectID int, ruleID *int) {
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			writeError(w, errors.New("Invalid limit"), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	runs, err := h.AutomationService.ListRuns(projectID, ruleID, limit)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, runs)
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"pet-project/internal/ical"
+	"pet-project/internal/service"
+	"pet-project/pkg/model"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi"
+)
+
+type CalendarHandler struct {
+	CalendarService *service.CalendarService
+}
+
+// CalendarFeedRequest — без projectID лента содержит задачи, назначенные пользователю
+type CalendarFeedRequest struct {
+	ProjectID *int `json:"projectID"`
+}
+
+func (h *CalendarHandler) CreateFeedRequest(w http.ResponseWriter, r *http.Request) {
+	var req CalendarFeedRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+
+	feed := &model.CalendarFeed{UserID: getUserIDFromContext(r), ProjectID: req.ProjectID}
+	if err := h.CalendarService.CreateFeed(feed); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, feed, http.StatusCreated)
+}
+
+func (h *CalendarHandler) ListFeedsRequest(w http.ResponseWriter, r *http.Request) {
+	feeds, err := h.CalendarService.ListFeeds(getUserIDFromContext(r))
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, feeds)
+}
+
+func (h *CalendarHandler) DeleteFeedRequest(w http.ResponseWriter, r *http.Request) {
+	feedID, err := strconv.Atoi(chi.URLParam(r, "feedID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid feed ID"), http.StatusBadRequest)
+		return
+	}
+	if err := h.CalendarService.DeleteFeed(feedID, getUserIDFromContext(r)); err != nil {
+		writeError(w, err, http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// FeedRequest обрабатывает GET /calendar/{token}.ics?kind=todo. JWT здесь нет: календарные клиенты
+// его не передают, доступ даёт секретный токен в адресе.
+func (h *CalendarHandler) FeedRequest(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimSuffix(chi.URLParam(r, "token"), ".ics")
+	kind := ical.KindEvent
+	if r.URL.Query().Get("kind") == "todo" {
+		kind = ical.KindTodo
+	}
+
+	var body strings.Builder
+	err := h.CalendarService.WriteFeed(&body, token, kind)
+	if errors.Is(err, service.ErrCalendarFeedNotFound) {
+		writeError(w, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="tasks.ics"`)
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.Write([]byte(body.String()))
+}
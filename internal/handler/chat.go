@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"pet-project/internal/service"
+	"pet-project/pkg/model"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+)
+
+// maxChatCommandSize — тело slash-команды: несколько коротких полей формы
+const maxChatCommandSize = 64 << 10
+
+type ChatHandler struct {
+	ChatService    *service.ChatService
+	ProjectService *service.ProjectService
+}
+
+// ChatIntegrationRequest — при обновлении отсутствующие поля не меняются
+type ChatIntegrationRequest struct {
+	Provider   *string  `json:"provider"`
+	Secret     *string  `json:"secret"`
+	WebhookURL *string  `json:"webhookURL"`
+	Events     []string `json:"events"`
+	Active     *bool    `json:"active"`
+}
+
+func (req *ChatIntegrationRequest) apply(integration *model.ChatIntegration) {
+	if req.Provider != nil {
+		integration.Provider = *req.Provider
+	}
+	if req.Secret != nil {
+		integration.Secret = *req.Secret
+	}
+	if req.WebhookURL != nil {
+		integration.WebhookURL = *req.WebhookURL
+	}
+	if req.Events != nil {
+		integration.Events = req.Events
+	}
+	if req.Active != nil {
+		integration.Active = *req.Active
+	}
+}
+
+type ChatLinkCodeResponse struct {
+	Code      string    `json:"code"`
+	Command   string    `json:"command"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (h *ChatHandler) integrationFromURL(w http.ResponseWriter, r *http.Request) (*model.ChatIntegration, bool) {
+	return integrationFromURL(w, r, h.ProjectService, h.ChatService.GetIntegration,
+		func(integration *model.ChatIntegration) int { return integration.ProjectID }, service.ErrChatIntegrationNotFound)
+}
+
+func (h *ChatHandler) CreateIntegrationRequest(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := projectFromURL(w, r, h.ProjectService)
+	if !ok {
+		return
+	}
+
+	var req ChatIntegrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+
+	integration := &model.ChatIntegration{ProjectID: projectID, Active: true, CreatedBy: getUserIDFromContext(r)}
+	req.apply(integration)
+	if err := h.ChatService.CreateIntegration(integration); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, integration, http.StatusCreated)
+}
+
+func (h *ChatHandler) ListIntegrationsRequest(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := projectFromURL(w, r, h.ProjectService)
+	if !ok {
+		return
+	}
+
+	integrations, err := h.ChatService.ListIntegrations(projectID)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, integrations)
+}
+
+func (h *ChatHandler) GetIntegrationRequest(w http.ResponseWriter, r *http.Request) {
+	integration, ok := h.integrationFromURL(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, integration)
+}
+
+func (h *ChatHandler) UpdateIntegrationRequest(w http.ResponseWriter, r *http.Request) {
+	integration, ok := h.integrationFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var req ChatIntegrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+	req.apply(integration)
+
+	if err := h.ChatService.UpdateIntegration(integration); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, integration)
+}
+
+func (h *ChatHandler) DeleteIntegrationRequest(w http.ResponseWriter, r *http.Request) {
+	integration, ok := h.integrationFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.ChatService.DeleteIntegration(integration.ID); err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LinkCodeRequest обрабатывает POST /chat-integrations/{integrationID}/link-code: код для команды /task link
+func (h *ChatHandler) LinkCodeRequest(w http.ResponseWriter, r *http.Request) {
+	integration, ok := h.integrationFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	code, expires := h.ChatService.LinkCode(integration, getUserIDFromContext(r))
+	writeJSON(w, ChatLinkCodeResponse{Code: code, Command: "/task link " + code, ExpiresAt: expires})
+}
+
+// CommandRequest обрабатывает POST /integrations/chat/{integrationID}/command — slash-команду
+// Slack или Mattermost. JWT здесь нет: запрос проверяется подписью или токеном команды.
+func (h *ChatHandler) CommandRequest(w http.ResponseWriter, r *http.Request) {
+	integrationID, err := strconv.Atoi(chi.URLParam(r, "integrationID"))
+	if err != nil {
+		writeError(w, service.ErrChatIntegrationNotFound, http.StatusNotFound)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxChatCommandSize))
+	if err != nil {
+		writeError(w, errors.New("Command is too large"), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	resp, err := h.ChatService.HandleCommand(r.Context(), integrationID, r.Header, body)
+	switch {
+	case errors.Is(err, service.ErrChatIntegrationNotFound):
+		writeError(w, err, http.StatusNotFound)
+	case errors.Is(err, service.ErrChatSignature):
+		writeError(w, err, http.StatusUnauthorized)
+	case err != nil:
+		writeError(w, err, http.StatusInternalServerError)
+	default:
+		writeJSON(w, resp)
+	}
+}
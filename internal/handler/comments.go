@@ -47,6 +47,11 @@ func (h *CommentsHandler) AddCommentRequest(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	// Автор комментария — аутентифицированный пользователь
+	if userID := getUserIDFromContext(r); userID != 0 {
+		req.UserID = userID
+	}
+
 	if req.TaskID <= 0 {
 		writeError(w, errors.New("Invalid Task ID"), http.StatusBadRequest)
 		return
@@ -81,7 +86,13 @@ func (h *CommentsHandler) DeleteCommentRequest(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	err = h.CommentsService.DeleteComment(intComID)
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		writeError(w, errors.New("Unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	err = h.CommentsService.DeleteComment(intComID, userID)
 	if err != nil {
 		writeError(w, err, http.StatusNotFound)
 		return
@@ -143,11 +154,34 @@ func (h *CommentsHandler) UpdateCommentTextRequest(w http.ResponseWriter, r *htt
 		return
 	}
 
-	err = h.CommentsService.UpdateCommentText(intComID, req.Text)
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		writeError(w, errors.New("Unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	com, err := h.CommentsService.UpdateCommentText(intComID, req.Text, userID)
 	if err != nil {
 		writeError(w, err, http.StatusNotFound)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	writeJSON(w, com)
+}
+
+func (h *CommentsHandler) GetCommentRevisionsRequest(w http.ResponseWriter, r *http.Request) {
+	comID := chi.URLParam(r, "comID")
+
+	intComID, err := strconv.Atoi(comID)
+	if err != nil {
+		writeError(w, errors.New("Invalid comment ID"), http.StatusBadRequest)
+		return
+	}
+
+	revisions, err := h.CommentsService.GetCommentRevisions(intComID)
+	if err != nil {
+		writeError(w, err, http.StatusNotFound)
+		return
+	}
+	writeJSON(w, revisions)
 }
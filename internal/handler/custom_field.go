@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"pet-project/internal/service"
+	"pet-project/pkg/model"
+	"strconv"
+
+	"github.com/go-chi/chi"
+)
+
+type CustomFieldHandler struct {
+	CustomFieldService *service.CustomFieldService
+	ProjectService     *service.ProjectService
+}
+
+type CreateCustomFieldRequest struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Options  []string `json:"options"`
+	Required bool     `json:"required"`
+}
+
+type UpdateCustomFieldRequest struct {
+	Name     *string   `json:"name"`
+	Options  *[]string `json:"options"`
+	Required *bool     `json:"required"`
+}
+
+func (h *CustomFieldHandler) fieldFromURL(w http.ResponseWriter, r *http.Request) (*model.CustomField, bool) {
+	fieldID, err := strconv.Atoi(chi.URLParam(r, "fieldID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid field ID"), http.StatusBadRequest)
+		return nil, false
+	}
+	field, err := h.CustomFieldService.GetField(fieldID)
+	if err != nil {
+		writeError(w, errors.New("Field not found"), http.StatusNotFound)
+		return nil, false
+	}
+	if _, err := h.ProjectService.GetByIDProject(field.ProjectID, getUserIDFromContext(r)); err != nil {
+		writeError(w, err, http.StatusNotFound)
+		return nil, false
+	}
+	return field, true
+}
+
+func (h *CustomFieldHandler) CreateFieldRequest(w http.ResponseWriter, r *http.Request) {
+	projectID, err := strconv.Atoi(chi.URLParam(r, "projectID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid project ID"), http.StatusBadRequest)
+		return
+	}
+	if _, err := h.ProjectService.GetByIDProject(projectID, getUserIDFromContext(r)); err != nil {
+		writeError(w, err, http.StatusNotFound)
+		return
+	}
+
+	var req CreateCustomFieldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+
+	field := &model.CustomField{
+		ProjectID: projectID,
+		Name:      req.Name,
+		Type:      req.Type,
+		Options:   req.Options,
+		Required:  req.Required,
+	}
+	if err := h.CustomFieldService.CreateField(field); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, field, http.StatusCreated)
+}
+
+func (h *CustomFieldHandler) ListFieldsRequest(w http.ResponseWriter, r *http.Request) {
+	projectID, err := strconv.Atoi(chi.URLParam(r, "projectID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid project ID"), http.StatusBadRequest)
+		return
+	}
+	if _, err := h.ProjectService.GetByIDProject(projectID, getUserIDFromContext(r)); err != nil {
+		writeError(w, err, http.StatusNotFound)
+		return
+	}
+
+	fields, err := h.CustomFieldService.ListByProject(projectID)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, fields)
+}
+
+func (h *CustomFieldHandler) UpdateFieldRequest(w http.ResponseWriter, r *http.Request) {
+	field, ok := h.fieldFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateCustomFieldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+
+	if req.Name != nil {
+		field.Name = *req.Name
+	}
+	if req.Options != nil {
+		field.Options = *req.Options
+	}
+	if req.Required != nil {
+		field.Required = *req.Required
+	}
+
+	if err := h.CustomFieldService.UpdateField(field); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, field)
+}
+
+func (h *CustomFieldHandler) DeleteFieldRequest(w http.ResponseWriter, r *http.Request) {
+	field, ok := h.fieldFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.CustomFieldService.DeleteField(field.ID); err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"pet-project/internal/service"
+	"pet-project/pkg/model"
+	"strconv"
+
+	"github.com/go-chi/chi"
+)
+
+type DependencyHandler struct {
+	DependencyService *service.DependencyService
+	ProjectService    *service.ProjectService
+}
+
+type AddBlockerRequest struct {
+	BlockerID int `json:"blockerID"`
+}
+
+// taskWithAccess загружает задачу и проверяет, что текущий пользователь имеет доступ к её проекту
+func (h *DependencyHandler) taskWithAccess(w http.ResponseWriter, r *http.Request, taskID int) (*model.Task, bool) {
+	task, err := h.DependencyService.GetTask(taskID)
+	if err != nil {
+		writeError(w, errors.New("Task not found"), http.StatusNotFound)
+		return nil, false
+	}
+	if _, err := h.ProjectService.GetByIDProject(task.ProjectID, getUserIDFromContext(r)); err != nil {
+		writeError(w, err, http.StatusNotFound)
+		return nil, false
+	}
+	return task, true
+}
+
+// linkedTasks проверяет доступ к обеим задачам связи; связывать можно только задачи одного проекта
+func (h *DependencyHandler) linkedTasks(w http.ResponseWriter, r *http.Request, blockerID, blockedID int) bool {
+	blocked, ok := h.taskWithAccess(w, r, blockedID)
+	if !ok {
+		return false
+	}
+	blocker, ok := h.taskWithAccess(w, r, blockerID)
+	if !ok {
+		return false
+	}
+	if blocker.ProjectID != blocked.ProjectID {
+		writeError(w, errors.New("Dependent tasks must belong to the same project"), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func (h *DependencyHandler) AddBlockerRequest(w http.ResponseWriter, r *http.Request) {
+	taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid task ID"), http.StatusBadRequest)
+		return
+	}
+
+	var req AddBlockerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+
+	if req.BlockerID <= 0 {
+		writeError(w, errors.New("Invalid blocker ID"), http.StatusBadRequest)
+		return
+	}
+	if !h.linkedTasks(w, r, req.BlockerID, taskID) {
+		return
+	}
+
+	dep, err := h.DependencyService.AddDependency(req.BlockerID, taskID)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, dep, http.StatusCreated)
+}
+
+func (h *DependencyHandler) ListBlockersRequest(w http.ResponseWriter, r *http.Request) {
+	taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid task ID"), http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := h.taskWithAccess(w, r, taskID); !ok {
+		return
+	}
+
+	blockers, err := h.DependencyService.ListBlockers(taskID)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, blockers)
+}
+
+func (h *DependencyHandler) RemoveBlockerRequest(w http.ResponseWriter, r *http.Request) {
+	taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid task ID"), http.StatusBadRequest)
+		return
+	}
+
+	blockerID, err := strconv.Atoi(chi.URLParam(r, "blockerID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid blocker ID"), http.StatusBadRequest)
+		return
+	}
+	if !h.linkedTasks(w, r, blockerID, taskID) {
+		return
+	}
+
+	if err := h.DependencyService.RemoveDependency(blockerID, taskID); err != nil {
+		writeError(w, errors.New("Dependency not found"), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *DependencyHandler) GetProjectGraphRequest(w http.ResponseWriter, r *http.Request) {
+	projectID, err := strconv.Atoi(chi.URLParam(r, "projectID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid project ID"), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.ProjectService.GetByIDProject(projectID, getUserIDFromContext(r)); err != nil {
+		writeError(w, err, http.StatusNotFound)
+		return
+	}
+
+	graph, err := h.DependencyService.GetProjectGraph(projectID)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, graph)
+}
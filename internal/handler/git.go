@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"pet-project/internal/service"
+	"pet-project/pkg/model"
+	"strconv"
+
+	"github.com/go-chi/chi"
+)
+
+// maxGitPayloadSize — GitHub ограничивает тело webhook 25 МБ
+const maxGitPayloadSize = 25 << 20
+
+type GitIntegrationHandler struct {
+	GitService     *service.GitIntegrationService
+	ProjectService *service.ProjectService
+}
+
+// GitIntegrationRequest — при обновлении отсутствующие поля не меняются; пустой secret выпускает новый ключ
+type GitIntegrationRequest struct {
+	Provider     *string `json:"provider"`
+	Secret       *string `json:"secret"`
+	CloseStatus  *string `json:"closeStatus"`
+	ReviewStatus *string `json:"reviewStatus"`
+	Active       *bool   `json:"active"`
+}
+
+func (req *GitIntegrationRequest) apply(integration *model.GitIntegration) {
+	if req.Provider != nil {
+		integration.Provider = *req.Provider
+	}
+	if req.Secret != nil {
+		integration.Secret = *req.Secret
+	}
+	if req.CloseStatus != nil {
+		integration.CloseStatus = *req.CloseStatus
+	}
+	if req.ReviewStatus != nil {
+		integration.ReviewStatus = *req.ReviewStatus
+	}
+	if req.Active != nil {
+		integration.Active = *req.Active
+	}
+}
+
+// GitIntegrationSecretResponse — интеграция с ключом; ключ отдаётся только при создании и смене
+type GitIntegrationSecretResponse struct {
+	*model.GitIntegration
+	Secret string `json:"secret"`
+}
+
+func (h *GitIntegrationHandler) integrationFromURL(w http.ResponseWriter, r *http.Request) (*model.GitIntegration, bool) {
+	return integrationFromURL(w, r, h.ProjectService, h.GitService.GetIntegration,
+		func(integration *model.GitIntegration) int { return integration.ProjectID }, service.ErrGitIntegrationNotFound)
+}
+
+func (h *GitIntegrationHandler) CreateIntegrationRequest(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := projectFromURL(w, r, h.ProjectService)
+	if !ok {
+		return
+	}
+
+	var req GitIntegrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+
+	integration := &model.GitIntegration{ProjectID: projectID, Active: true, CreatedBy: getUserIDFromContext(r)}
+	req.apply(integration)
+	if err := h.GitService.CreateIntegration(integration); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, GitIntegrationSecretResponse{GitIntegration: integration, Secret: integration.Secret}, http.StatusCreated)
+}
+
+func (h *GitIntegrationHandler) ListIntegrationsRequest(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := projectFromURL(w, r, h.ProjectService)
+	if !ok {
+		return
+	}
+
+	integrations, err := h.GitService.ListIntegrations(projectID)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, integrations)
+}
+
+func (h *GitIntegrationHandler) GetIntegrationRequest(w http.ResponseWriter, r *http.Request) {
+	integration, ok := h.integrationFromURL(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, integration)
+}
+
+func (h *GitIntegrationHandler) UpdateIntegrationRequest(w http.ResponseWriter, r *http.Request) {
+	integration, ok := h.integrationFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var req GitIntegrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+	req.apply(integration)
+
+	if err := h.GitService.UpdateIntegration(integration); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	if req.Secret != nil {
+		writeJSON(w, GitIntegrationSecretResponse{GitIntegration: integration, Secret: integration.Secret})
+		return
+	}
+	writeJSON(w, integration)
+}
+
+func (h *GitIntegrationHandler) DeleteIntegrationRequest(w http.ResponseWriter, r *http.Request) {
+	integration, ok := h.integrationFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.GitService.DeleteIntegration(integration.ID); err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListTaskLinksRequest обрабатывает GET /tasks/{taskID}/git-links
+func (h *GitIntegrationHandler) ListTaskLinksRequest(w http.ResponseWriter, r *http.Request) {
+	taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid task ID"), http.StatusBadRequest)
+		return
+	}
+	projectID, err := h.GitService.TaskProjectID(taskID)
+	if err != nil {
+		writeError(w, err, http.StatusNotFound)
+		return
+	}
+	if _, err := h.ProjectService.GetByIDProject(projectID, getUserIDFromContext(r)); err != nil {
+		writeError(w, err, http.StatusNotFound)
+		return
+	}
+
+	links, err := h.GitService.ListLinks(taskID)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, links)
+}
+
+// ReceiveRequest обрабатывает POST /integrations/git/{integrationID} — webhook GitHub, GitLab или Gitea.
+// JWT здесь нет: запрос проверяется подписью с ключом интеграции.
+func (h *GitIntegrationHandler) ReceiveRequest(w http.ResponseWriter, r *http.Request) {
+	integrationID, err := strconv.Atoi(chi.URLParam(r, "integrationID"))
+	if err != nil {
+		writeError(w, service.ErrGitIntegrationNotFound, http.StatusNotFound)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxGitPayloadSize))
+	if err != nil {
+		writeError(w, errors.New("Payload is too large"), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	result, err := h.GitService.Receive(r.Context(), integrationID, r.Header, body)
+	switch {
+	case errors.Is(err, service.ErrGitIntegrationNotFound):
+		writeError(w, err, http.StatusNotFound)
+	case errors.Is(err, service.ErrGitSignature):
+		writeError(w, err, http.StatusUnauthorized)
+	case errors.Is(err, service.ErrGitPayload):
+		writeError(w, err, http.StatusBadRequest)
+	case err != nil:
+		writeError(w, err, http.StatusInternalServerError)
+	default:
+		writeJSON(w, result)
+	}
+}
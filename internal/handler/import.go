@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"pet-project/internal/service"
+	"pet-project/pkg/model"
+	"strconv"
+
+	"github.com/go-chi/chi"
+)
+
+type ImportHandler struct {
+	ImportService  *service.ImportService
+	ProjectService *service.ProjectService
+}
+
+// jobFromURL загружает задание импорта текущего пользователя
+func (h *ImportHandler) jobFromURL(w http.ResponseWriter, r *http.Request) (*model.ImportJob, bool) {
+	jobID, err := strconv.Atoi(chi.URLParam(r, "jobID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid import job ID"), http.StatusBadRequest)
+		return nil, false
+	}
+	job, err := h.ImportService.GetJob(jobID, getUserIDFromContext(r))
+	if err != nil {
+		writeError(w, err, http.StatusNotFound)
+		return nil, false
+	}
+	return job, true
+}
+
+// CreateImportRequest обрабатывает POST /imports — multipart-форму с полями:
+// file — выгрузка, source — trello или jira, projectID — проект (без него создаётся новый),
+// options — JSON {"StatusMap": {...}, "Members": {...}}, dryRun=true — только отчёт, без импорта.
+func (h *ImportHandler) CreateImportRequest(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	maxSize := h.ImportService.MaxSize
+	if maxSize <= 0 {
+		maxSize = service.DefaultMaxImportSize
+	}
+	// Запас на заголовки multipart и остальные поля
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+1<<20)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		writeError(w, errors.New("Invalid multipart form or file is too large"), http.StatusBadRequest)
+		return
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, errors.New("File is required"), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		writeError(w, errors.New("Failed to read file"), http.StatusBadRequest)
+		return
+	}
+
+	var options model.ImportOptions
+	if value := r.FormValue("options"); value != "" {
+		if err := json.Unmarshal([]byte(value), &options); err != nil {
+			writeError(w, errors.New("Invalid import options"), http.StatusBadRequest)
+			return
+		}
+	}
+	var projectID *int
+	if value := r.FormValue("projectID"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			writeError(w, errors.New("Invalid project ID"), http.StatusBadRequest)
+			return
+		}
+		if _, err := h.ProjectService.GetByIDProject(id, userID); err != nil {
+			writeError(w, err, http.StatusNotFound)
+			return
+		}
+		projectID = &id
+	}
+	source := r.FormValue("source")
+
+	if r.FormValue("dryRun") == "true" {
+		report, err := h.ImportService.Preview(r.Context(), source, data, options, projectID)
+		if err != nil {
+			writeError(w, err, http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, report)
+		return
+	}
+
+	job := &model.ImportJob{UserID: userID, ProjectID: projectID, Source: source, Options: options, Payload: data}
+	if err := h.ImportService.CreateJob(r.Context(), job); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, job, http.StatusAccepted)
+}
+
+func (h *ImportHandler) ListImportsRequest(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.ImportService.ListJobs(getUserIDFromContext(r))
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, jobs)
+}
+
+func (h *ImportHandler) GetImportRequest(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.jobFromURL(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, job)
+}
+
+// ResumeImportRequest обрабатывает POST /imports/{jobID}/resume — повтор задания после ошибки
+func (h *ImportHandler) ResumeImportRequest(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.jobFromURL(w, r)
+	if !ok {
+		return
+	}
+	if err := h.ImportService.ResumeJob(r.Context(), job); err != nil {
+		writeError(w, err, http.StatusConflict)
+		return
+	}
+	writeJSON(w, job, http.StatusAccepted)
+}
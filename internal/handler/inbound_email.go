@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"pet-project/internal/service"
+	"strings"
+)
+
+// maxInboundEmailSize — предел размера входящего письма вместе с вложениями
+const maxInboundEmailSize = 25 << 20
+
+type InboundEmailHandler struct {
+	InboundEmailService *service.InboundEmailService
+	// Token — общий секрет с почтовым шлюзом, передаётся в заголовке Authorization: Bearer
+	Token string
+}
+
+// ReceiveEmailRequest обрабатывает POST /inbound/email. Тело — письмо RFC 5322 целиком
+// (message/rfc822) либо форма, в которой письмо лежит в поле "email" (SendGrid) или "body-mime" (Mailgun).
+func (h *InboundEmailHandler) ReceiveEmailRequest(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if h.Token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.Token)) != 1 {
+		writeError(w, errors.New("Unauthorized"), http.StatusUnauthorized)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxInboundEmailSize)
+
+	var raw io.Reader = r.Body
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" || mediaType == "application/x-www-form-urlencoded" {
+		if err := r.ParseMultipartForm(1 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+			writeError(w, errors.New("Invalid form or email is too large"), http.StatusBadRequest)
+			return
+		}
+		message := r.FormValue("email")
+		if message == "" {
+			message = r.FormValue("body-mime")
+		}
+		if message == "" {
+			writeError(w, errors.New("Email is required"), http.StatusBadRequest)
+			return
+		}
+		raw = strings.NewReader(message)
+	}
+
+	com, err := h.InboundEmailService.Process(r.Context(), raw)
+	// Отклонённое письмо подтверждается шлюзу кодом 4xx без повторов; 5xx — просьба доставить ещё раз
+	if errors.Is(err, service.ErrEmailRejected) {
+		log.Println("Inbound email rejected:", err)
+		writeError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	if com == nil {
+		writeJSON(w, map[string]string{"status": "duplicate"})
+		return
+	}
+	writeJSON(w, com, http.StatusCreated)
+}
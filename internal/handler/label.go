@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"pet-project/internal/service"
+	"pet-project/pkg/model"
+	"strconv"
+
+	"github.com/go-chi/chi"
+)
+
+type LabelHandler struct {
+	LabelService   *service.LabelService
+	ProjectService *service.ProjectService
+}
+
+type CreateLabelRequest struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+type UpdateLabelRequest struct {
+	Name  *string `json:"name"`
+	Color *string `json:"color"`
+}
+
+type AddTaskLabelRequest struct {
+	LabelID int `json:"labelID"`
+}
+
+// labelFromURL загружает метку из URL и проверяет доступ к её проекту
+func (h *LabelHandler) labelFromURL(w http.ResponseWriter, r *http.Request) (*model.Label, bool) {
+	labelID, ok := idFromURL(w, r, "labelID", "label")
+	if !ok {
+		return nil, false
+	}
+	label, err := h.LabelService.GetLabel(labelID)
+	if err != nil {
+		writeError(w, errors.New("Label not found"), http.StatusNotFound)
+		return nil, false
+	}
+	if !canAccessProject(w, r, h.ProjectService, label.ProjectID) {
+		return nil, false
+	}
+	return label, true
+}
+
+func (h *LabelHandler) CreateLabelRequest(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := projectFromURL(w, r, h.ProjectService)
+	if !ok {
+		return
+	}
+
+	var req CreateLabelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+
+	label, err := h.LabelService.CreateLabel(projectID, req.Name, req.Color)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, label, http.StatusCreated)
+}
+
+func (h *LabelHandler) ListLabelsRequest(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := projectFromURL(w, r, h.ProjectService)
+	if !ok {
+		return
+	}
+
+	labels, err := h.LabelService.ListByProject(projectID)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, labels)
+}
+
+func (h *LabelHandler) LabelUsageRequest(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := projectFromURL(w, r, h.ProjectService)
+	if !ok {
+		return
+	}
+
+	usage, err := h.LabelService.UsageByProject(projectID)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, usage)
+}
+
+func (h *LabelHandler) UpdateLabelRequest(w http.ResponseWriter, r *http.Request) {
+	label, ok := h.labelFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateLabelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+
+	if req.Name != nil {
+		label.Name = *req.Name
+	}
+	if req.Color != nil {
+		label.Color = *req.Color
+	}
+
+	if err := h.LabelService.UpdateLabel(label); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, label)
+}
+
+func (h *LabelHandler) DeleteLabelRequest(w http.ResponseWriter, r *http.Request) {
+	label, ok := h.labelFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.LabelService.DeleteLabel(label.ID); err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *LabelHandler) AddTaskLabelRequest(w http.ResponseWriter, r *http.Request) {
+	taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid task ID"), http.StatusBadRequest)
+		return
+	}
+
+	var req AddTaskLabelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.LabelService.AddToTask(taskID, req.LabelID); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *LabelHandler) RemoveTaskLabelRequest(w http.ResponseWriter, r *http.Request) {
+	taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid task ID"), http.StatusBadRequest)
+		return
+	}
+
+	labelID, err := strconv.Atoi(chi.URLParam(r, "labelID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid label ID"), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.LabelService.RemoveFromTask(taskID, labelID); err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
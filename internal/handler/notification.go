@@ -11,6 +11,7 @@ import (
 
 type NotificationHandler struct {
 	NotificationService *service.NotificationService
+	EmailService        *service.EmailService
 }
 
 type CreateNotificationRequest struct {
@@ -22,6 +23,10 @@ type MarkAsReadRequest struct {
 	NotificationIDs []int `json:"notification_ids"`
 }
 
+type NotificationPreferenceRequest struct {
+	EmailMode string `json:"email_mode"`
+}
+
 func (h *NotificationHandler) CreateNotification(w http.ResponseWriter, r *http.Request) {
 	userID := getUserIDFromContext(r)
 	if userID == 0 {
@@ -137,3 +142,40 @@ func (h *NotificationHandler) CountUnread(w http.ResponseWriter, r *http.Request
 		"unread_count": count,
 	})
 }
+
+func (h *NotificationHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		writeError(w, errors.New("Unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	pref, err := h.EmailService.GetPreference(r.Context(), userID)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, pref)
+}
+
+// UpdatePreferences обрабатывает PUT /notification/preferences: email_mode — off, instant, hourly или daily
+func (h *NotificationHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		writeError(w, errors.New("Unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	var req NotificationPreferenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+
+	pref, err := h.EmailService.UpdatePreference(r.Context(), userID, req.EmailMode)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, pref)
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"pet-project/internal/service"
+	"pet-project/pkg/model"
+	"strconv"
+
+	"github.com/go-chi/chi"
+)
+
+type SavedFilterHandler struct {
+	SavedFilterService *service.SavedFilterService
+}
+
+// SavedFilterRequest — query в языке запросов задач; shared доступен только фильтрам проекта
+type SavedFilterRequest struct {
+	Name      *string `json:"name"`
+	Query     *string `json:"query"`
+	ProjectID *int    `json:"projectID"`
+	Shared    *bool   `json:"shared"`
+}
+
+func (req *SavedFilterRequest) apply(filter *model.SavedFilter) {
+	if req.Name != nil {
+		filter.Name = *req.Name
+	}
+	if req.Query != nil {
+		filter.Query = *req.Query
+	}
+	if req.Shared != nil {
+		filter.Shared = *req.Shared
+	}
+}
+
+// filterFromURL загружает фильтр из URL, если он виден текущему пользователю
+func (h *SavedFilterHandler) filterFromURL(w http.ResponseWriter, r *http.Request) (*model.SavedFilter, bool) {
+	filterID, err := strconv.Atoi(chi.URLParam(r, "filterID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid filter ID"), http.StatusBadRequest)
+		return nil, false
+	}
+	filter, err := h.SavedFilterService.GetFilter(filterID, getUserIDFromContext(r))
+	if err != nil {
+		writeError(w, err, http.StatusNotFound)
+		return nil, false
+	}
+	return filter, true
+}
+
+func (h *SavedFilterHandler) CreateFilterRequest(w http.ResponseWriter, r *http.Request) {
+	var req SavedFilterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+
+	filter := &model.SavedFilter{UserID: getUserIDFromContext(r), ProjectID: req.ProjectID}
+	req.apply(filter)
+	if err := h.SavedFilterService.CreateFilter(filter); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, filter, http.StatusCreated)
+}
+
+// ListFiltersRequest обрабатывает GET /filters?projectID=1
+func (h *SavedFilterHandler) ListFiltersRequest(w http.ResponseWriter, r *http.Request) {
+	var projectID *int
+	if value := r.URL.Query().Get("projectID"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			writeError(w, errors.New("Invalid project ID"), http.StatusBadRequest)
+			return
+		}
+		projectID = &id
+	}
+
+	filters, err := h.SavedFilterService.ListFilters(getUserIDFromContext(r), projectID)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, filters)
+}
+
+func (h *SavedFilterHandler) GetFilterRequest(w http.ResponseWriter, r *http.Request) {
+	filter, ok := h.filterFromURL(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, filter)
+}
+
+func (h *SavedFilterHandler) UpdateFilterRequest(w http.ResponseWriter, r *http.Request) {
+	filter, ok := h.filterFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var req SavedFilterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+	if req.ProjectID != nil {
+		writeError(w, errors.New("Filter can't be moved to another project"), http.StatusBadRequest)
+		return
+	}
+	req.apply(filter)
+
+	if err := h.SavedFilterService.UpdateFilter(filter, getUserIDFromContext(r)); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, filter)
+}
+
+func (h *SavedFilterHandler) DeleteFilterRequest(w http.ResponseWriter, r *http.Request) {
+	filter, ok := h.filterFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.SavedFilterService.DeleteFilter(filter, getUserIDFromContext(r)); err != nil {
+		writeError(w, err, http.StatusForbidden)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RunFilterRequest выполняет фильтр; параметры списка задач (?q=, ?labels=, ?sort=) дополнительно сужают выборку
+func (h *SavedFilterHandler) RunFilterRequest(w http.ResponseWriter, r *http.Request) {
+	filter, ok := h.filterFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	extra, err := parseTaskFilter(r)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	tasks, err := h.SavedFilterService.RunFilter(filter, getUserIDFromContext(r), extra)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, tasks)
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"pet-project/internal/service"
+	"pet-project/pkg/model"
+	"strconv"
+	"strings"
+)
+
+type SearchHandler struct {
+	SearchService *service.SearchService
+}
+
+// SearchRequest обрабатывает GET /search?q=...&type=task,comment&limit=20&offset=0
+func (h *SearchHandler) SearchRequest(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	query := model.SearchQuery{
+		Query:  params.Get("q"),
+		UserID: getUserIDFromContext(r),
+	}
+
+	for _, value := range params["type"] {
+		for _, kind := range strings.Split(value, ",") {
+			if kind = strings.TrimSpace(kind); kind != "" {
+				query.Types = append(query.Types, kind)
+			}
+		}
+	}
+
+	for name, target := range map[string]*int{"limit": &query.Limit, "offset": &query.Offset} {
+		value := params.Get(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			writeError(w, errors.New("Invalid "+name), http.StatusBadRequest)
+			return
+		}
+		*target = n
+	}
+
+	results, err := h.SearchService.Search(query)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, results)
+}
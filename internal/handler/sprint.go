@@ -0,0 +1,253 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"pet-project/internal/service"
+	"pet-project/pkg/model"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+)
+
+type SprintHandler struct {
+	SprintService  *service.SprintService
+	ProjectService *service.ProjectService
+}
+
+// SprintRequest — даты в формате YYYY-MM-DD, обе включительно
+type SprintRequest struct {
+	Name      *string `json:"name"`
+	Goal      *string `json:"goal"`
+	StartDate *string `json:"startDate"`
+	EndDate   *string `json:"endDate"`
+}
+
+type CompleteSprintRequest struct {
+	CarryOverTo *int `json:"carryOverTo"`
+}
+
+type SetTaskSprintRequest struct {
+	SprintID *int `json:"sprintID"`
+}
+
+func (req *SprintRequest) apply(sprint *model.Sprint) error {
+	if req.Name != nil {
+		sprint.Name = *req.Name
+	}
+	if req.Goal != nil {
+		sprint.Goal = *req.Goal
+	}
+	for _, field := range []struct {
+		value  *string
+		target *time.Time
+		name   string
+	}{{req.StartDate, &sprint.StartDate, "startDate"}, {req.EndDate, &sprint.EndDate, "endDate"}} {
+		if field.value == nil {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", *field.value)
+		if err != nil {
+			return errors.New("Invalid " + field.name + " format, use YYYY-MM-DD")
+		}
+		*field.target = date
+	}
+	return nil
+}
+
+// sprintFromURL загружает спринт из URL и проверяет доступ к его проекту
+func (h *SprintHandler) sprintFromURL(w http.ResponseWriter, r *http.Request) (*model.Sprint, bool) {
+	sprintID, ok := idFromURL(w, r, "sprintID", "sprint")
+	if !ok {
+		return nil, false
+	}
+	sprint, err := h.SprintService.GetSprint(sprintID)
+	if err != nil {
+		writeError(w, errors.New("Sprint not found"), http.StatusNotFound)
+		return nil, false
+	}
+	if !canAccessProject(w, r, h.ProjectService, sprint.ProjectID) {
+		return nil, false
+	}
+	return sprint, true
+}
+
+func (h *SprintHandler) CreateSprintRequest(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := projectFromURL(w, r, h.ProjectService)
+	if !ok {
+		return
+	}
+
+	var req SprintRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+
+	sprint := &model.Sprint{ProjectID: projectID}
+	if err := req.apply(sprint); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	if err := h.SprintService.CreateSprint(sprint); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, sprint, http.StatusCreated)
+}
+
+func (h *SprintHandler) ListSprintsRequest(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := projectFromURL(w, r, h.ProjectService)
+	if !ok {
+		return
+	}
+
+	sprints, err := h.SprintService.ListByProject(projectID)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, sprints)
+}
+
+func (h *SprintHandler) GetSprintRequest(w http.ResponseWriter, r *http.Request) {
+	sprint, ok := h.sprintFromURL(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, sprint)
+}
+
+func (h *SprintHandler) UpdateSprintRequest(w http.ResponseWriter, r *http.Request) {
+	sprint, ok := h.sprintFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var req SprintRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+	if err := req.apply(sprint); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.SprintService.UpdateSprint(sprint); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, sprint)
+}
+
+func (h *SprintHandler) DeleteSprintRequest(w http.ResponseWriter, r *http.Request) {
+	sprint, ok := h.sprintFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.SprintService.DeleteSprint(sprint); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *SprintHandler) ListSprintTasksRequest(w http.ResponseWriter, r *http.Request) {
+	sprint, ok := h.sprintFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	tasks, err := h.SprintService.ListTasks(sprint.ID)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, tasks)
+}
+
+func (h *SprintHandler) StartSprintRequest(w http.ResponseWriter, r *http.Request) {
+	sprint, ok := h.sprintFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.SprintService.StartSprint(sprint); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	sprint, err := h.SprintService.GetSprint(sprint.ID)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, sprint)
+}
+
+func (h *SprintHandler) CompleteSprintRequest(w http.ResponseWriter, r *http.Request) {
+	sprint, ok := h.sprintFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var req CompleteSprintRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+			return
+		}
+	}
+
+	carried, err := h.SprintService.CompleteSprint(sprint, req.CarryOverTo)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"sprintID": sprint.ID, "carriedOver": carried, "carryOverTo": req.CarryOverTo})
+}
+
+func (h *SprintHandler) BurndownRequest(w http.ResponseWriter, r *http.Request) {
+	sprint, ok := h.sprintFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	burndown, err := h.SprintService.Burndown(sprint)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, burndown)
+}
+
+func (h *SprintHandler) SetTaskSprintRequest(w http.ResponseWriter, r *http.Request) {
+	taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid task ID"), http.StatusBadRequest)
+		return
+	}
+	task, err := h.SprintService.GetTask(taskID)
+	if err != nil {
+		writeError(w, errors.New("Task not found"), http.StatusNotFound)
+		return
+	}
+	if !canAccessProject(w, r, h.ProjectService, task.ProjectID) {
+		return
+	}
+
+	var req SetTaskSprintRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.SprintService.SetTaskSprint(taskID, req.SprintID); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
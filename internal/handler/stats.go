@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"pet-project/internal/service"
+	"strconv"
+
+	"github.com/go-chi/chi"
+)
+
+type StatsHandler struct {
+	StatsService   *service.StatsService
+	ProjectService *service.ProjectService
+}
+
+func (h *StatsHandler) ProjectStatsRequest(w http.ResponseWriter, r *http.Request) {
+	projectID, err := strconv.Atoi(chi.URLParam(r, "projectID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid project ID"), http.StatusBadRequest)
+		return
+	}
+	if _, err := h.ProjectService.GetByIDProject(projectID, getUserIDFromContext(r)); err != nil {
+		writeError(w, err, http.StatusNotFound)
+		return
+	}
+
+	weeks := 0
+	if value := r.URL.Query().Get("weeks"); value != "" {
+		weeks, err = strconv.Atoi(value)
+		if err != nil {
+			writeError(w, errors.New("Invalid weeks"), http.StatusBadRequest)
+			return
+		}
+	}
+
+	stats, err := h.StatsService.ProjectStats(projectID, weeks)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, stats)
+}
+
+// UserSummaryRequest — сводка по всем проектам текущего пользователя
+func (h *StatsHandler) UserSummaryRequest(w http.ResponseWriter, r *http.Request) {
+	summary, err := h.StatsService.UserSummary(getUserIDFromContext(r))
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, summary)
+}
@@ -6,15 +6,18 @@ import (
 	"log"
 	"net/http"
 	"pet-project/internal/service"
+	"pet-project/internal/taskquery"
 	"pet-project/pkg/model"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi"
 )
 
 type TaskHandler struct {
-	TaskService *service.TaskService
+	TaskService    *service.TaskService
+	ProjectService *service.ProjectService
 }
 
 type CreateTaskRequest struct {
@@ -24,7 +27,10 @@ type CreateTaskRequest struct {
 	Priority    string `json:"priority"`
 	AssignedTo  int    `json:"assignedTo"`
 	ProjectID   int    `json:"projectID"`
+	ParentID    *int   `json:"parentID"`
 	DueDate     string `json:"dueDate"`
+
+	CustomFields map[string]interface{} `json:"customFields"`
 }
 
 type UpdateTaskRequest struct {
@@ -34,6 +40,8 @@ type UpdateTaskRequest struct {
 	Status      *string `json:"status"`
 	Priority    *string `json:"priority"`
 	Description *string `json:"description"`
+
+	CustomFields map[string]interface{} `json:"customFields"`
 }
 
 type GetByIDTaskRequest struct {
@@ -49,6 +57,32 @@ type DeleteTaskRequest struct {
 	AssignedTo int `json:"assignedTo"`
 }
 
+type SetTaskParentRequest struct {
+	ParentID *int `json:"parentID"`
+}
+
+type TaskMemberRequest struct {
+	UserID int `json:"userID"`
+}
+
+// MoveTaskRequest — afterID: карточка над задачей, beforeID: под ней; без обоих задача уходит в конец колонки
+type MoveTaskRequest struct {
+	Status   string `json:"status"`
+	AfterID  *int   `json:"afterID"`
+	BeforeID *int   `json:"beforeID"`
+}
+
+// SetRecurrenceRequest — правило в формате RRULE, например "FREQ=WEEKLY;BYDAY=MO;COUNT=10"
+type SetRecurrenceRequest struct {
+	Rule string `json:"rule"`
+}
+
+// SetEstimatesRequest — оценки в минутах, null очищает оценку
+type SetEstimatesRequest struct {
+	OriginalEstimate  *int `json:"originalEstimate"`
+	RemainingEstimate *int `json:"remainingEstimate"`
+}
+
 func (h *TaskHandler) CreateTaskRequest(w http.ResponseWriter, r *http.Request) {
 	var req CreateTaskRequest
 
@@ -77,14 +111,10 @@ func (h *TaskHandler) CreateTaskRequest(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	var dueDate *time.Time
-	if req.DueDate != "" {
-		t, err := time.Parse(time.RFC3339, req.DueDate)
-		if err != nil {
-			writeError(w, errors.New("Invalid dueDate format, use RFC3339"), http.StatusBadRequest)
-			return
-		}
-		dueDate = &t
+	dueDate, err := parseDueDate(req.DueDate)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
 	}
 
 	task := &model.Task{
@@ -94,9 +124,12 @@ func (h *TaskHandler) CreateTaskRequest(w http.ResponseWriter, r *http.Request)
 		Priority:    req.Priority,
 		AssignedTo:  req.AssignedTo,
 		ProjectID:   req.ProjectID,
+		ParentID:    req.ParentID,
 		DueDate:     dueDate,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
+
+		CustomFields: req.CustomFields,
 	}
 
 	if err := h.TaskService.CreateTask(task); err != nil {
@@ -140,6 +173,8 @@ func (h *TaskHandler) UpdateProjectRequest(w http.ResponseWriter, r *http.Reques
 		Status:      *req.Status,
 		Priority:    *req.Priority,
 		Description: *req.Description,
+
+		CustomFields: req.CustomFields,
 	}
 	task.UpdatedAt = time.Now()
 	if req.Description == nil {
@@ -151,8 +186,18 @@ func (h *TaskHandler) UpdateProjectRequest(w http.ResponseWriter, r *http.Reques
 		task.Description = existingTask.Description
 	}
 
-	if err := h.TaskService.UpdateTask(task, req.AssignedTo); err != nil {
-		writeError(w, errors.New("Failed to update task"), http.StatusBadRequest)
+	// ?scope=series применяет изменения ко всей серии повторяющихся задач
+	var err error
+	switch r.URL.Query().Get("scope") {
+	case "", "single":
+		err = h.TaskService.UpdateTask(task, getUserIDFromContext(r))
+	case "series":
+		err = h.TaskService.UpdateTaskSeries(task, getUserIDFromContext(r))
+	default:
+		err = errors.New("Invalid scope, use single or series")
+	}
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
 		return
 	}
 }
@@ -176,13 +221,18 @@ func (h *TaskHandler) GetByIDTaskRequest(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *TaskHandler) ListByProjectTaskRequest(w http.ResponseWriter, r *http.Request) {
-	projectID := chi.URLParam(r, "projectID")
-	intProjectID, err := strconv.Atoi(projectID)
+	intProjectID, ok := projectFromURL(w, r, h.ProjectService)
+	if !ok {
+		return
+	}
+
+	filter, err := parseTaskFilter(r)
 	if err != nil {
-		writeError(w, errors.New("Invalid project ID"), http.StatusBadRequest)
+		writeError(w, err, http.StatusBadRequest)
+		return
 	}
 
-	task, err := h.TaskService.ListByProjectTask(intProjectID)
+	task, err := h.TaskService.ListByProjectTask(intProjectID, filter)
 	if err != nil {
 		writeError(w, err, http.StatusNotFound)
 		return
@@ -207,3 +257,386 @@ func (h *TaskHandler) DeleteTaskRequest(w http.ResponseWriter, r *http.Request)
 	}
 	writeJSON(w, task)
 }
+
+// taskWithAccess загружает задачу и проверяет доступ к её проекту
+func (h *TaskHandler) taskWithAccess(w http.ResponseWriter, r *http.Request, taskID int) (*model.Task, bool) {
+	task, err := h.TaskService.GetTask(taskID)
+	if err != nil {
+		writeError(w, errors.New("Task not found"), http.StatusNotFound)
+		return nil, false
+	}
+	if !canAccessProject(w, r, h.ProjectService, task.ProjectID) {
+		return nil, false
+	}
+	return task, true
+}
+
+func (h *TaskHandler) CreateSubtaskRequest(w http.ResponseWriter, r *http.Request) {
+	parentID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid task ID"), http.StatusBadRequest)
+		return
+	}
+	if _, ok := h.taskWithAccess(w, r, parentID); !ok {
+		return
+	}
+
+	var req CreateTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+
+	if req.Title == "" {
+		writeError(w, errors.New("Task required a name"), http.StatusBadRequest)
+		return
+	}
+
+	dueDate, err := parseDueDate(req.DueDate)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	task := &model.Task{
+		Title:       req.Title,
+		Description: req.Description,
+		Status:      req.Status,
+		Priority:    req.Priority,
+		AssignedTo:  req.AssignedTo,
+		ProjectID:   req.ProjectID,
+		DueDate:     dueDate,
+
+		CustomFields: req.CustomFields,
+	}
+
+	if err := h.TaskService.CreateSubtask(parentID, task); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, task, http.StatusCreated)
+}
+
+func (h *TaskHandler) GetTaskTreeRequest(w http.ResponseWriter, r *http.Request) {
+	taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid task ID"), http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := h.taskWithAccess(w, r, taskID); !ok {
+		return
+	}
+
+	tree, err := h.TaskService.GetTaskTree(taskID)
+	if err != nil {
+		writeError(w, err, http.StatusNotFound)
+		return
+	}
+	writeJSON(w, tree)
+}
+
+func (h *TaskHandler) SetTaskParentRequest(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+
+	taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid task ID"), http.StatusBadRequest)
+		return
+	}
+
+	var req SetTaskParentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.TaskService.SetParent(taskID, req.ParentID, userID)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, task)
+}
+
+var taskSortFields = []string{"id", "title", "status", "priority", "created_at", "updated_at", "due_date", "rank"}
+
+var customFieldFilterOps = []string{"ne", "gt", "gte", "lt", "lte", "contains"}
+
+// parseTaskFilter разбирает параметры выборки:
+//
+//	?labels=bug,frontend&match=any|all
+//	?cf.Environment=prod&cf.Story points.gte=3   — условия на пользовательские поля
+//	?sort=-due_date или ?sort=cf.Story points     — сортировка, "-" означает по убыванию
+//	?q=status != done AND label = bug             — выражение языка запросов
+func parseTaskFilter(r *http.Request) (model.TaskFilter, error) {
+	var filter model.TaskFilter
+	query := r.URL.Query()
+
+	if labels := query.Get("labels"); labels != "" {
+		names := strings.Split(labels, ",")
+		switch query.Get("match") {
+		case "", "any":
+			filter.LabelsAny = names
+		case "all":
+			filter.LabelsAll = names
+		default:
+			return filter, errors.New("Invalid match, use any or all")
+		}
+	}
+
+	for key, values := range query {
+		if !strings.HasPrefix(key, "cf.") {
+			continue
+		}
+		name, op := strings.TrimPrefix(key, "cf."), "eq"
+		if idx := strings.LastIndex(name, "."); idx > 0 {
+			for _, candidate := range customFieldFilterOps {
+				if name[idx+1:] == candidate {
+					name, op = name[:idx], candidate
+					break
+				}
+			}
+		}
+		for _, value := range values {
+			filter.CustomFields = append(filter.CustomFields, model.CustomFieldCondition{Field: name, Op: op, Raw: value})
+		}
+	}
+
+	if q := strings.TrimSpace(query.Get("q")); q != "" {
+		if _, err := taskquery.Parse(q); err != nil {
+			return filter, err
+		}
+		filter.Query = q
+		filter.Viewer = getUserIDFromContext(r)
+	}
+
+	if sortParam := query.Get("sort"); sortParam != "" {
+		sort := &model.TaskSort{Field: strings.TrimPrefix(sortParam, "-"), Desc: strings.HasPrefix(sortParam, "-")}
+		if strings.HasPrefix(sort.Field, "cf.") {
+			sort.Field = strings.TrimPrefix(sort.Field, "cf.")
+			sort.Custom = true
+		} else if !containsField(taskSortFields, sort.Field) {
+			return filter, errors.New("Invalid sort field")
+		}
+		filter.Sort = sort
+	}
+	return filter, nil
+}
+
+func containsField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+func parseDueDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, errors.New("Invalid dueDate format, use RFC3339")
+	}
+	return &t, nil
+}
+
+func (h *TaskHandler) AddAssigneeRequest(w http.ResponseWriter, r *http.Request) {
+	taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid task ID"), http.StatusBadRequest)
+		return
+	}
+
+	var req TaskMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.TaskService.AddAssignee(taskID, req.UserID, getUserIDFromContext(r)); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TaskHandler) RemoveAssigneeRequest(w http.ResponseWriter, r *http.Request) {
+	taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid task ID"), http.StatusBadRequest)
+		return
+	}
+
+	userID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid user ID"), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.TaskService.RemoveAssignee(taskID, userID, getUserIDFromContext(r)); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddWatcherRequest без userID в теле подписывает на задачу текущего пользователя
+func (h *TaskHandler) AddWatcherRequest(w http.ResponseWriter, r *http.Request) {
+	actorID := getUserIDFromContext(r)
+
+	taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid task ID"), http.StatusBadRequest)
+		return
+	}
+
+	req := TaskMemberRequest{UserID: actorID}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := h.TaskService.AddWatcher(taskID, req.UserID, actorID); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TaskHandler) RemoveWatcherRequest(w http.ResponseWriter, r *http.Request) {
+	taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid task ID"), http.StatusBadRequest)
+		return
+	}
+
+	userID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid user ID"), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.TaskService.RemoveWatcher(taskID, userID, getUserIDFromContext(r)); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TaskHandler) SetEstimatesRequest(w http.ResponseWriter, r *http.Request) {
+	taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid task ID"), http.StatusBadRequest)
+		return
+	}
+
+	var req SetEstimatesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.TaskService.SetEstimates(taskID, req.OriginalEstimate, req.RemainingEstimate, getUserIDFromContext(r))
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, task)
+}
+
+func (h *TaskHandler) SetRecurrenceRequest(w http.ResponseWriter, r *http.Request) {
+	taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid task ID"), http.StatusBadRequest)
+		return
+	}
+
+	var req SetRecurrenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+
+	rec, err := h.TaskService.SetRecurrence(taskID, req.Rule, getUserIDFromContext(r))
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, rec)
+}
+
+func (h *TaskHandler) GetRecurrenceRequest(w http.ResponseWriter, r *http.Request) {
+	taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid task ID"), http.StatusBadRequest)
+		return
+	}
+
+	rec, err := h.TaskService.GetRecurrence(taskID)
+	if err != nil {
+		writeError(w, err, http.StatusNotFound)
+		return
+	}
+	writeJSON(w, rec)
+}
+
+func (h *TaskHandler) StopRecurrenceRequest(w http.ResponseWriter, r *http.Request) {
+	taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid task ID"), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.TaskService.StopRecurrence(taskID, getUserIDFromContext(r)); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TaskHandler) MoveTaskRequest(w http.ResponseWriter, r *http.Request) {
+	taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid task ID"), http.StatusBadRequest)
+		return
+	}
+
+	var req MoveTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.TaskService.MoveTask(taskID, req.Status, req.AfterID, req.BeforeID, getUserIDFromContext(r))
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, task)
+}
+
+func (h *TaskHandler) GetBoardRequest(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := projectFromURL(w, r, h.ProjectService)
+	if !ok {
+		return
+	}
+
+	filter, err := parseTaskFilter(r)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	board, err := h.TaskService.GetBoard(projectID, filter)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, board)
+}
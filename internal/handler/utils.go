@@ -2,7 +2,12 @@ package handler
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"pet-project/internal/service"
+	"strconv"
+
+	"github.com/go-chi/chi"
 )
 
 func writeError(w http.ResponseWriter, err error, statusCode int) {
@@ -18,3 +23,50 @@ func writeJSON(w http.ResponseWriter, data interface{}, statusCode ...int) {
 	w.WriteHeader(statusCode[0])
 	json.NewEncoder(w).Encode(data)
 }
+
+// idFromURL разбирает числовой параметр пути key; name попадает в текст ошибки
+func idFromURL(w http.ResponseWriter, r *http.Request, key, name string) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, key))
+	if err != nil {
+		writeError(w, fmt.Errorf("Invalid %s ID", name), http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// canAccessProject проверяет, что текущий пользователь имеет доступ к проекту, иначе отвечает 404
+func canAccessProject(w http.ResponseWriter, r *http.Request, projects *service.ProjectService, projectID int) bool {
+	if _, err := projects.GetByIDProject(projectID, getUserIDFromContext(r)); err != nil {
+		writeError(w, err, http.StatusNotFound)
+		return false
+	}
+	return true
+}
+
+// projectFromURL проверяет, что текущий пользователь имеет доступ к проекту из URL
+func projectFromURL(w http.ResponseWriter, r *http.Request, projects *service.ProjectService) (int, bool) {
+	projectID, ok := idFromURL(w, r, "projectID", "project")
+	if !ok || !canAccessProject(w, r, projects, projectID) {
+		return 0, false
+	}
+	return projectID, true
+}
+
+// integrationFromURL загружает интеграцию (git или чат) по параметру integrationID и проверяет доступ к её проекту
+func integrationFromURL[T any](w http.ResponseWriter, r *http.Request, projects *service.ProjectService,
+	get func(id int) (T, error), projectOf func(T) int, notFound error) (T, bool) {
+	var zero T
+	integrationID, ok := idFromURL(w, r, "integrationID", "integration")
+	if !ok {
+		return zero, false
+	}
+	integration, err := get(integrationID)
+	if err != nil {
+		writeError(w, notFound, http.StatusNotFound)
+		return zero, false
+	}
+	if !canAccessProject(w, r, projects, projectOf(integration)) {
+		return zero, false
+	}
+	return integration, true
+}
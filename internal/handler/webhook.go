@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"pet-project/internal/service"
+	"pet-project/pkg/model"
+	"strconv"
+
+	"github.com/go-chi/chi"
+)
+
+type WebhookHandler struct {
+	WebhookService *service.WebhookService
+	ProjectService *service.ProjectService
+}
+
+// WebhookRequest — при обновлении отсутствующие поля не меняются; пустой secret выпускает новый ключ
+type WebhookRequest struct {
+	URL    *string  `json:"url"`
+	Secret *string  `json:"secret"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"`
+}
+
+func (req *WebhookRequest) apply(webhook *model.Webhook) {
+	if req.URL != nil {
+		webhook.URL = *req.URL
+	}
+	if req.Secret != nil {
+		webhook.Secret = *req.Secret
+	}
+	if req.Events != nil {
+		webhook.Events = req.Events
+	}
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+}
+
+// WebhookSecretResponse — подписка с ключом; ключ отдаётся только при создании и смене
+type WebhookSecretResponse struct {
+	*model.Webhook
+	Secret string `json:"secret"`
+}
+
+// webhookFromURL загружает подписку из URL и проверяет доступ к её проекту
+func (h *WebhookHandler) webhookFromURL(w http.ResponseWriter, r *http.Request) (*model.Webhook, bool) {
+	webhookID, ok := idFromURL(w, r, "webhookID", "webhook")
+	if !ok {
+		return nil, false
+	}
+	webhook, err := h.WebhookService.GetWebhook(webhookID)
+	if err != nil {
+		writeError(w, errors.New("Webhook not found"), http.StatusNotFound)
+		return nil, false
+	}
+	if !canAccessProject(w, r, h.ProjectService, webhook.ProjectID) {
+		return nil, false
+	}
+	return webhook, true
+}
+
+func deliveryIDFromURL(w http.ResponseWriter, r *http.Request) (int, bool) {
+	deliveryID, err := strconv.Atoi(chi.URLParam(r, "deliveryID"))
+	if err != nil {
+		writeError(w, errors.New("Invalid delivery ID"), http.StatusBadRequest)
+		return 0, false
+	}
+	return deliveryID, true
+}
+
+func (h *WebhookHandler) CreateWebhookRequest(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := projectFromURL(w, r, h.ProjectService)
+	if !ok {
+		return
+	}
+
+	var req WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+
+	webhook := &model.Webhook{ProjectID: projectID, Active: true, CreatedBy: getUserIDFromContext(r)}
+	req.apply(webhook)
+	if err := h.WebhookService.CreateWebhook(webhook); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, WebhookSecretResponse{Webhook: webhook, Secret: webhook.Secret}, http.StatusCreated)
+}
+
+func (h *WebhookHandler) ListWebhooksRequest(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := projectFromURL(w, r, h.ProjectService)
+	if !ok {
+		return
+	}
+
+	webhooks, err := h.WebhookService.ListWebhooks(projectID)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, webhooks)
+}
+
+func (h *WebhookHandler) GetWebhookRequest(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.webhookFromURL(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, webhook)
+}
+
+func (h *WebhookHandler) UpdateWebhookRequest(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.webhookFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var req WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+	req.apply(webhook)
+
+	if err := h.WebhookService.UpdateWebhook(webhook); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	if req.Secret != nil {
+		writeJSON(w, WebhookSecretResponse{Webhook: webhook, Secret: webhook.Secret})
+		return
+	}
+	writeJSON(w, webhook)
+}
+
+func (h *WebhookHandler) DeleteWebhookRequest(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.webhookFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.WebhookService.DeleteWebhook(webhook.ID); err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PingWebhookRequest обрабатывает POST /webhooks/{webhookID}/ping
+func (h *WebhookHandler) PingWebhookRequest(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.webhookFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.WebhookService.Ping(webhook)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, delivery, http.StatusAccepted)
+}
+
+// ListDeliveriesRequest обрабатывает GET /webhooks/{webhookID}/deliveries?status=failed&limit=50
+func (h *WebhookHandler) ListDeliveriesRequest(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.webhookFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			writeError(w, errors.New("Invalid limit"), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	deliveries, err := h.WebhookService.ListDeliveries(webhook.ID, r.URL.Query().Get("status"), limit)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, deliveries)
+}
+
+// GetDeliveryRequest обрабатывает GET /webhooks/{webhookID}/deliveries/{deliveryID} — отправка с журналом попыток
+func (h *WebhookHandler) GetDeliveryRequest(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.webhookFromURL(w, r)
+	if !ok {
+		return
+	}
+	deliveryID, ok := deliveryIDFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.WebhookService.GetDelivery(webhook.ID, deliveryID)
+	if err != nil {
+		writeError(w, errors.New("Delivery not found"), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, delivery)
+}
+
+// RedeliverRequest обрабатывает POST /webhooks/{webhookID}/deliveries/{deliveryID}/redeliver
+func (h *WebhookHandler) RedeliverRequest(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.webhookFromURL(w, r)
+	if !ok {
+		return
+	}
+	deliveryID, ok := deliveryIDFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.WebhookService.Redeliver(webhook.ID, deliveryID)
+	if err != nil {
+		writeError(w, errors.New("Delivery not found"), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, delivery, http.StatusAccepted)
+}
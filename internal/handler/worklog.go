@@ -0,0 +1,258 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"pet-project/internal/service"
+	"pet-project/pkg/model"
+	"time"
+)
+
+type WorklogHandler struct {
+	WorklogService *service.WorklogService
+	ProjectService *service.ProjectService
+}
+
+// WorklogRequest — длительность задаётся минутами или строкой вида "1h30m"
+type WorklogRequest struct {
+	Minutes  *int    `json:"minutes"`
+	Duration *string `json:"duration"`
+	WorkDate *string `json:"workDate"`
+	Note     *string `json:"note"`
+}
+
+type StopTimerRequest struct {
+	Note string `json:"note"`
+}
+
+func (req *WorklogRequest) apply(wl *model.Worklog) error {
+	if req.Duration != nil {
+		d, err := time.ParseDuration(*req.Duration)
+		if err != nil {
+			return errors.New("Invalid duration, use format like 1h30m")
+		}
+		wl.Minutes = int(d.Minutes())
+	}
+	if req.Minutes != nil {
+		wl.Minutes = *req.Minutes
+	}
+	if req.WorkDate != nil {
+		date, err := time.Parse("2006-01-02", *req.WorkDate)
+		if err != nil {
+			return errors.New("Invalid workDate format, use YYYY-MM-DD")
+		}
+		wl.WorkDate = date
+	}
+	if req.Note != nil {
+		wl.Note = *req.Note
+	}
+	return nil
+}
+
+// taskFromURL проверяет, что задача из URL существует и текущий пользователь имеет доступ к её проекту
+func (h *WorklogHandler) taskFromURL(w http.ResponseWriter, r *http.Request) (int, bool) {
+	taskID, ok := idFromURL(w, r, "taskID", "task")
+	if !ok {
+		return 0, false
+	}
+	task, err := h.WorklogService.GetTask(taskID)
+	if err != nil {
+		writeError(w, errors.New("Task not found"), http.StatusNotFound)
+		return 0, false
+	}
+	if !canAccessProject(w, r, h.ProjectService, task.ProjectID) {
+		return 0, false
+	}
+	return taskID, true
+}
+
+// worklogFromURL загружает запись времени задачи из URL; запись другой задачи считается ненайденной
+func (h *WorklogHandler) worklogFromURL(w http.ResponseWriter, r *http.Request) (*model.Worklog, bool) {
+	taskID, ok := h.taskFromURL(w, r)
+	if !ok {
+		return nil, false
+	}
+	worklogID, ok := idFromURL(w, r, "worklogID", "worklog")
+	if !ok {
+		return nil, false
+	}
+	wl, err := h.WorklogService.GetWorklog(worklogID)
+	if err != nil || wl.TaskID != taskID {
+		writeError(w, errors.New("Worklog not found"), http.StatusNotFound)
+		return nil, false
+	}
+	return wl, true
+}
+
+func (h *WorklogHandler) CreateWorklogRequest(w http.ResponseWriter, r *http.Request) {
+	taskID, ok := h.taskFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var req WorklogRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+
+	wl := &model.Worklog{TaskID: taskID, UserID: getUserIDFromContext(r)}
+	if err := req.apply(wl); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.WorklogService.LogWork(wl); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, wl, http.StatusCreated)
+}
+
+func (h *WorklogHandler) ListWorklogsRequest(w http.ResponseWriter, r *http.Request) {
+	taskID, ok := h.taskFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	worklogs, err := h.WorklogService.ListByTask(taskID)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, worklogs)
+}
+
+func (h *WorklogHandler) UpdateWorklogRequest(w http.ResponseWriter, r *http.Request) {
+	wl, ok := h.worklogFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var req WorklogRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+		return
+	}
+	if err := req.apply(wl); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.WorklogService.UpdateWorklog(wl, getUserIDFromContext(r)); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, wl)
+}
+
+func (h *WorklogHandler) DeleteWorklogRequest(w http.ResponseWriter, r *http.Request) {
+	wl, ok := h.worklogFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.WorklogService.DeleteWorklog(wl.ID, getUserIDFromContext(r)); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WorklogHandler) StartTimerRequest(w http.ResponseWriter, r *http.Request) {
+	taskID, ok := h.taskFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	timer, err := h.WorklogService.StartTimer(taskID, getUserIDFromContext(r))
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, timer, http.StatusCreated)
+}
+
+func (h *WorklogHandler) StopTimerRequest(w http.ResponseWriter, r *http.Request) {
+	taskID, ok := h.taskFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var req StopTimerRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, errors.New("Invalid Request Body"), http.StatusBadRequest)
+			return
+		}
+	}
+
+	wl, err := h.WorklogService.StopTimer(taskID, getUserIDFromContext(r), req.Note)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, wl, http.StatusCreated)
+}
+
+func (h *WorklogHandler) GetTimerRequest(w http.ResponseWriter, r *http.Request) {
+	timer, err := h.WorklogService.GetTimer(getUserIDFromContext(r))
+	if err != nil {
+		writeError(w, err, http.StatusNotFound)
+		return
+	}
+	writeJSON(w, timer)
+}
+
+func (h *WorklogHandler) TaskSummaryRequest(w http.ResponseWriter, r *http.Request) {
+	taskID, ok := h.taskFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	h.writeSummary(w, r, model.WorklogScope{TaskID: taskID})
+}
+
+func (h *WorklogHandler) ProjectSummaryRequest(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := projectFromURL(w, r, h.ProjectService)
+	if !ok {
+		return
+	}
+
+	h.writeSummary(w, r, model.WorklogScope{ProjectID: projectID})
+}
+
+// UserSummaryRequest — время, затраченное текущим пользователем
+func (h *WorklogHandler) UserSummaryRequest(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		writeError(w, errors.New("Unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	h.writeSummary(w, r, model.WorklogScope{UserID: userID})
+}
+
+// writeSummary дополняет рамки выборки параметрами ?from=YYYY-MM-DD&to=YYYY-MM-DD и отдаёт агрегаты
+func (h *WorklogHandler) writeSummary(w http.ResponseWriter, r *http.Request, scope model.WorklogScope) {
+	for param, target := range map[string]**time.Time{"from": &scope.From, "to": &scope.To} {
+		value := r.URL.Query().Get(param)
+		if value == "" {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			writeError(w, errors.New("Invalid "+param+" format, use YYYY-MM-DD"), http.StatusBadRequest)
+			return
+		}
+		*target = &date
+	}
+
+	summary, err := h.WorklogService.Summary(scope)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, summary)
+}
@@ -0,0 +1,167 @@
+// Package ical пишет календари iCalendar (RFC 5545) с событиями VEVENT и задачами VTODO.
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	KindEvent = "VEVENT"
+	KindTodo  = "VTODO"
+)
+
+// maxLineOctets — строки длиннее переносятся с пробелом в начале продолжения
+const maxLineOctets = 75
+
+// Calendar — календарь; ProdID обязателен по стандарту, Name показывается клиентами как название
+type Calendar struct {
+	ProdID string
+	Name   string
+	// RefreshInterval подсказывает клиенту, как часто обновлять подписку
+	RefreshInterval time.Duration
+	Items           []Item
+}
+
+// Item — одна запись календаря. Клиент узнаёт запись по UID и заменяет её, если Sequence вырос.
+type Item struct {
+	Kind        string
+	UID         string
+	Sequence    int
+	Stamp       time.Time
+	Summary     string
+	Description string
+	URL         string
+	Categories  []string
+	// Priority — 1 (высший) … 9 (низший), 0 — не задан
+	Priority int
+	// Status — для VEVENT: CONFIRMED, TENTATIVE, CANCELLED; для VTODO: NEEDS-ACTION, IN-PROCESS, COMPLETED, CANCELLED
+	Status string
+	// Due — срок: у VEVENT это начало события, у VTODO — DUE. AllDay пишет только дату.
+	Due       time.Time
+	AllDay    bool
+	Completed *time.Time
+	Modified  time.Time
+	Created   time.Time
+}
+
+// Write пишет календарь со строками CRLF
+func Write(w io.Writer, cal *Calendar) error {
+	bw := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeFolded(bw, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", Escape(cal.ProdID))
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if cal.Name != "" {
+		line("X-WR-CALNAME", Escape(cal.Name))
+	}
+	if cal.RefreshInterval > 0 {
+		line("REFRESH-INTERVAL;VALUE=DURATION", duration(cal.RefreshInterval))
+		line("X-PUBLISHED-TTL", duration(cal.RefreshInterval))
+	}
+
+	for _, item := range cal.Items {
+		kind := item.Kind
+		if kind == "" {
+			kind = KindEvent
+		}
+		line("BEGIN", kind)
+		line("UID", Escape(item.UID))
+		line("DTSTAMP", utc(item.Stamp))
+		line("SEQUENCE", strconv.Itoa(item.Sequence))
+		if !item.Created.IsZero() {
+			line("CREATED", utc(item.Created))
+		}
+		if !item.Modified.IsZero() {
+			line("LAST-MODIFIED", utc(item.Modified))
+		}
+		line("SUMMARY", Escape(item.Summary))
+		if item.Description != "" {
+			line("DESCRIPTION", Escape(item.Description))
+		}
+		if item.URL != "" {
+			line("URL;VALUE=URI", item.URL)
+		}
+		if len(item.Categories) > 0 {
+			escaped := make([]string, len(item.Categories))
+			for i, category := range item.Categories {
+				escaped[i] = Escape(category)
+			}
+			line("CATEGORIES", strings.Join(escaped, ","))
+		}
+		if item.Priority > 0 {
+			line("PRIORITY", strconv.Itoa(item.Priority))
+		}
+		if item.Status != "" {
+			line("STATUS", item.Status)
+		}
+
+		dateName := "DUE"
+		if kind == KindEvent {
+			dateName = "DTSTART"
+		}
+		if item.AllDay {
+			line(dateName+";VALUE=DATE", item.Due.Format("20060102"))
+			if kind == KindEvent {
+				line("DTEND;VALUE=DATE", item.Due.AddDate(0, 0, 1).Format("20060102"))
+			}
+		} else {
+			line(dateName, utc(item.Due))
+		}
+		if kind == KindTodo && item.Completed != nil {
+			line("COMPLETED", utc(*item.Completed))
+			line("PERCENT-COMPLETE", "100")
+		}
+		line("END", kind)
+	}
+
+	line("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+// Escape экранирует текстовое значение: обратную косую черту, запятые, точки с запятой и переводы строк
+func Escape(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`, "\r", `\n`).Replace(s)
+}
+
+// writeFolded пишет строку, перенося её по 75 октетов и не разрывая символы UTF-8
+func writeFolded(w *bufio.Writer, s string) {
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.WriteString(s[:cut])
+		w.WriteString("\r\n ")
+		s = s[cut:]
+		// продолжение начинается с пробела, он входит в длину строки
+		limit = maxLineOctets - 1
+	}
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+func utc(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+func duration(d time.Duration) string {
+	if d%(time.Hour) == 0 {
+		return "PT" + strconv.Itoa(int(d/time.Hour)) + "H"
+	}
+	if d%time.Minute == 0 {
+		return "PT" + strconv.Itoa(int(d/time.Minute)) + "M"
+	}
+	return "PT" + strconv.Itoa(int(d/time.Second)) + "S"
+}
@@ -0,0 +1,212 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{in: "plain", want: "plain"},
+		{in: `a\b`, want: `a\\b`},
+		{in: "a;b,c", want: `a\;b\,c`},
+		{in: "line1\nline2", want: `line1\nline2`},
+		{in: "line1\r\nline2", want: `line1\nline2`},
+		{in: "line1\rline2", want: `line1\nline2`},
+		{in: `\n`, want: `\\n`},
+		{in: "Задача; срок", want: `Задача\; срок`},
+	}
+	for _, tt := range tests {
+		if got := Escape(tt.in); got != tt.want {
+			t.Errorf("Escape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// unfold склеивает перенесённые строки обратно, проверяя длину каждой физической строки
+func unfold(t *testing.T, out string) []string {
+	t.Helper()
+	if !strings.HasSuffix(out, "\r\n") {
+		t.Fatalf("output doesn't end with CRLF: %q", out)
+	}
+	var lines []string
+	for _, physical := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		if len(physical) > maxLineOctets {
+			t.Errorf("line is %d octets: %q", len(physical), physical)
+		}
+		if !utf8.ValidString(physical) {
+			t.Errorf("line splits a UTF-8 character: %q", physical)
+		}
+		if strings.HasPrefix(physical, " ") {
+			if len(lines) == 0 {
+				t.Fatalf("output starts with a continuation line")
+			}
+			lines[len(lines)-1] += physical[1:]
+			continue
+		}
+		lines = append(lines, physical)
+	}
+	return lines
+}
+
+func write(t *testing.T, cal *Calendar) []string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Write(&buf, cal); err != nil {
+		t.Fatal(err)
+	}
+	return unfold(t, buf.String())
+}
+
+func contains(lines []string, want string) bool {
+	for _, line := range lines {
+		if line == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestWriteFolding(t *testing.T) {
+	tests := []string{
+		strings.Repeat("a", 200),
+		// Двухбайтовые символы: граница 75 октетов попадает в середину символа
+		strings.Repeat("ж", 100),
+		// Четырёхбайтовые символы со смещением на один октет
+		"x" + strings.Repeat("😀", 60),
+	}
+	for _, summary := range tests {
+		lines := write(t, &Calendar{ProdID: "-//test//EN", Items: []Item{{UID: "1", Summary: summary}}})
+		if !contains(lines, "SUMMARY:"+summary) {
+			t.Errorf("summary %q didn't survive folding: %q", summary, lines)
+		}
+	}
+}
+
+func TestWriteFoldingExactLimit(t *testing.T) {
+	var buf bytes.Buffer
+	summary := strings.Repeat("a", 75-len("SUMMARY:"))
+	if err := Write(&buf, &Calendar{ProdID: "p", Items: []Item{{UID: "1", Summary: summary}}}); err != nil {
+		t.Fatal(err)
+	}
+	// Строка ровно в 75 октетов не переносится
+	if !strings.Contains(buf.String(), "\r\nSUMMARY:"+summary+"\r\n") {
+		t.Errorf("75-octet line was folded: %q", buf.String())
+	}
+}
+
+func TestWriteAllDay(t *testing.T) {
+	due := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	stamp := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	lines := write(t, &Calendar{ProdID: "p", Items: []Item{
+		{Kind: KindEvent, UID: "event", Stamp: stamp, Summary: "Event", Due: due, AllDay: true},
+		{Kind: KindTodo, UID: "todo", Stamp: stamp, Summary: "Todo", Due: due, AllDay: true},
+	}})
+
+	event := itemLines(t, lines, "event")
+	for _, want := range []string{"DTSTART;VALUE=DATE:20241231", "DTEND;VALUE=DATE:20250101"} {
+		if !contains(event, want) {
+			t.Errorf("event has no %q: %q", want, event)
+		}
+	}
+	todo := itemLines(t, lines, "todo")
+	if !contains(todo, "DUE;VALUE=DATE:20241231") {
+		t.Errorf("todo has no all-day DUE: %q", todo)
+	}
+	for _, line := range todo {
+		if strings.HasPrefix(line, "DTEND") || strings.HasPrefix(line, "DTSTART") {
+			t.Errorf("todo has %q", line)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	due := time.Date(2024, 7, 3, 13, 15, 0, 0, moscow)
+	completed := time.Date(2024, 7, 3, 12, 0, 0, 0, time.UTC)
+	lines := write(t, &Calendar{
+		ProdID:          "-//pet-project//tasks//EN",
+		Name:            "Tasks, mine",
+		RefreshInterval: time.Hour,
+		Items: []Item{{
+			Kind:        KindTodo,
+			UID:         "task-1@example.com",
+			Sequence:    2,
+			Stamp:       completed,
+			Summary:     "Fix; ship",
+			Description: "Line 1\nLine 2",
+			URL:         "https://example.com/tasks/1",
+			Categories:  []string{"bug", "a,b"},
+			Priority:    1,
+			Status:      "COMPLETED",
+			Due:         due,
+			Completed:   &completed,
+		}},
+	})
+
+	want := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//pet-project//tasks//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		`X-WR-CALNAME:Tasks\, mine`,
+		"REFRESH-INTERVAL;VALUE=DURATION:PT1H",
+		"X-PUBLISHED-TTL:PT1H",
+		"BEGIN:VTODO",
+		"UID:task-1@example.com",
+		"DTSTAMP:20240703T120000Z",
+		"SEQUENCE:2",
+		`SUMMARY:Fix\; ship`,
+		`DESCRIPTION:Line 1\nLine 2`,
+		"URL;VALUE=URI:https://example.com/tasks/1",
+		`CATEGORIES:bug,a\,b`,
+		"PRIORITY:1",
+		"STATUS:COMPLETED",
+		"DUE:20240703T101500Z",
+		"COMPLETED:20240703T120000Z",
+		"PERCENT-COMPLETE:100",
+		"END:VTODO",
+		"END:VCALENDAR",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Write =\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// itemLines возвращает строки записи с заданным UID
+func itemLines(t *testing.T, lines []string, uid string) []string {
+	t.Helper()
+	start := -1
+	for i, line := range lines {
+		if line == "UID:"+uid {
+			start = i
+		}
+		if start >= 0 && strings.HasPrefix(line, "END:V") {
+			return lines[start:i]
+		}
+	}
+	t.Fatalf("item %q not found in %q", uid, lines)
+	return nil
+}
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{in: 2 * time.Hour, want: "PT2H"},
+		{in: 90 * time.Minute, want: "PT90M"},
+		{in: 45 * time.Second, want: "PT45S"},
+	}
+	for _, tt := range tests {
+		if got := duration(tt.in); got != tt.want {
+			t.Errorf("duration(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Package importer разбирает выгрузки Trello (JSON доски) и Jira (CSV задач) в общий вид:
+// доска со списком задач, их статусами, участниками, метками, сроками и комментариями.
+// Сопоставление с пользователями и статусами приложения делает сервис импорта.
+package importer
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	SourceTrello = "trello"
+	SourceJira   = "jira"
+)
+
+var (
+	ErrUnsupportedSource = errors.New("unsupported import source")
+	ErrInvalidExport     = errors.New("invalid export file")
+)
+
+// Board — разобранная выгрузка. Skipped — архивные карточки и другие записи, которые не импортируются.
+type Board struct {
+	Name    string
+	Tasks   []*Task
+	Skipped int
+}
+
+// Task — задача из выгрузки. Status — название колонки Trello или статуса Jira как есть;
+// Done — признак закрытия, известный источнику независимо от названия статуса.
+type Task struct {
+	ExternalID  string
+	Key         string
+	URL         string
+	Title       string
+	Description string
+	Status      string
+	Done        bool
+	Priority    string
+	Assignees   []Person
+	Labels      []string
+	Due         *time.Time
+	Comments    []Comment
+}
+
+// Person — участник во внешней системе. Email в выгрузках бывает не всегда: тогда пользователь
+// находится по сопоставлению ID, Username или Name с адресом, заданному при импорте.
+type Person struct {
+	ID       string
+	Username string
+	Name     string
+	Email    string
+}
+
+// Keys возвращает непустые идентификаторы участника в порядке надёжности
+func (p Person) Keys() []string {
+	var keys []string
+	for _, key := range []string{p.Email, p.ID, p.Username, p.Name} {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// String — имя участника для отчёта и подписи комментариев
+func (p Person) String() string {
+	for _, name := range []string{p.Name, p.Username, p.Email, p.ID} {
+		if name != "" {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+type Comment struct {
+	Author    Person
+	Text      string
+	CreatedAt time.Time
+}
+
+// Parse разбирает выгрузку источника source
+func Parse(source string, data []byte) (*Board, error) {
+	switch source {
+	case SourceTrello:
+		return ParseTrello(data)
+	case SourceJira:
+		return ParseJira(data)
+	}
+	return nil, ErrUnsupportedSource
+}
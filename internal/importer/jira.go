@@ -0,0 +1,171 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// jiraTimeLayouts — форматы дат в CSV-выгрузке: по умолчанию Jira пишет 03/Jul/24 10:15 AM,
+// но формат зависит от настроек экземпляра. Часовой пояс в выгрузке не указывается, считаем UTC.
+var jiraTimeLayouts = []string{
+	"02/Jan/06 3:04 PM",
+	"02/Jan/06 15:04",
+	"02/Jan/06",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02T15:04:05.000-0700",
+	"2006-01-02",
+}
+
+// ParseJira разбирает CSV-выгрузку задач Jira (Filters → Export → CSV, все поля). Метки и комментарии
+// выгружаются повторяющимися колонками Labels и Comment; комментарий имеет вид «дата;автор;текст».
+func ParseJira(data []byte) (*Board, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+	}
+	columns := make(map[string][]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		columns[name] = append(columns[name], i)
+	}
+	if columns["summary"] == nil {
+		return nil, fmt.Errorf("%w: Summary column is missing", ErrInvalidExport)
+	}
+
+	board := &Board{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+		}
+		row := jiraRow{columns: columns, record: record}
+
+		if board.Name == "" {
+			board.Name = row.get("project name")
+		}
+		title := row.get("summary")
+		if title == "" {
+			board.Skipped++
+			continue
+		}
+
+		task := &Task{
+			ExternalID:  row.get("issue id"),
+			Key:         row.get("issue key"),
+			Title:       title,
+			Description: row.get("description"),
+			Status:      row.get("status"),
+			Priority:    row.get("priority"),
+			Labels:      row.all("labels"),
+			Done:        strings.EqualFold(row.get("status category"), "done") || row.get("resolution") != "",
+		}
+		if task.ExternalID == "" {
+			task.ExternalID = task.Key
+		}
+		if task.ExternalID == "" {
+			task.ExternalID = fmt.Sprintf("line-%d", line)
+		}
+
+		if assignee := row.get("assignee"); assignee != "" {
+			person := Person{ID: row.get("assignee id"), Name: assignee}
+			if strings.Contains(assignee, "@") {
+				person.Email = assignee
+			}
+			task.Assignees = []Person{person}
+		}
+
+		due := row.get("due date")
+		if due == "" {
+			due = row.get("due")
+		}
+		if due != "" {
+			t, err := parseJiraTime(due)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: due date %q", ErrInvalidExport, line, due)
+			}
+			task.Due = &t
+		}
+
+		for _, value := range row.all("comment") {
+			comment, err := parseJiraComment(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidExport, line, err)
+			}
+			task.Comments = append(task.Comments, comment)
+		}
+		board.Tasks = append(board.Tasks, task)
+	}
+	return board, nil
+}
+
+type jiraRow struct {
+	columns map[string][]int
+	record  []string
+}
+
+// get возвращает первое непустое значение колонки
+func (r jiraRow) get(name string) string {
+	for _, i := range r.columns[name] {
+		if i < len(r.record) {
+			if value := strings.TrimSpace(r.record[i]); value != "" {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// all возвращает непустые значения всех одноимённых колонок
+func (r jiraRow) all(name string) []string {
+	var values []string
+	for _, i := range r.columns[name] {
+		if i < len(r.record) {
+			if value := strings.TrimSpace(r.record[i]); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+func parseJiraComment(value string) (Comment, error) {
+	parts := strings.SplitN(value, ";", 3)
+	if len(parts) != 3 {
+		// Комментарий без даты и автора — так бывает при выгрузке без метаданных
+		return Comment{Text: value}, nil
+	}
+	created, err := parseJiraTime(parts[0])
+	if err != nil {
+		return Comment{}, fmt.Errorf("comment date %q", parts[0])
+	}
+	author := strings.TrimSpace(parts[1])
+	person := Person{ID: author}
+	if strings.Contains(author, "@") {
+		person.Email = author
+	}
+	return Comment{Author: person, Text: parts[2], CreatedAt: created}, nil
+}
+
+func parseJiraTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range jiraTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("unknown date format")
+}
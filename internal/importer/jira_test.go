@@ -0,0 +1,114 @@
+package importer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseJira(t *testing.T) {
+	board, err := Parse(SourceJira, readFixture(t, "jira.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if board.Name != "Website" {
+		t.Errorf("Name = %q, want Website", board.Name)
+	}
+	// Строка без Summary
+	if board.Skipped != 1 {
+		t.Errorf("Skipped = %d, want 1", board.Skipped)
+	}
+	if len(board.Tasks) != 3 {
+		t.Fatalf("got %d tasks, want 3", len(board.Tasks))
+	}
+
+	tests := []struct {
+		key      string
+		status   string
+		done     bool
+		priority string
+	}{
+		{key: "WEB-1", status: "To Do", priority: "High"},
+		{key: "WEB-2", status: "In Review", priority: "Medium"},
+		// Закрыта и по категории статуса, и по резолюции
+		{key: "WEB-3", status: "Closed", done: true, priority: "Low"},
+	}
+	for i, tt := range tests {
+		task := board.Tasks[i]
+		if task.Key != tt.key || task.Status != tt.status || task.Done != tt.done || task.Priority != tt.priority {
+			t.Errorf("task %d = %q/%q/%v/%q, want %q/%q/%v/%q", i, task.Key, task.Status, task.Done, task.Priority,
+				tt.key, tt.status, tt.done, tt.priority)
+		}
+	}
+
+	login := board.Tasks[0]
+	if login.ExternalID != "10001" || login.Title != "Login page" {
+		t.Errorf("login = %q %q", login.ExternalID, login.Title)
+	}
+	if login.Description != `Form with "remember me"` {
+		t.Errorf("login Description = %q", login.Description)
+	}
+	if want := []string{"frontend", "auth"}; !reflect.DeepEqual(login.Labels, want) {
+		t.Errorf("login Labels = %v, want %v", login.Labels, want)
+	}
+	wantDue := time.Date(2024, 7, 3, 10, 15, 0, 0, time.UTC)
+	if login.Due == nil || !login.Due.Equal(wantDue) {
+		t.Errorf("login Due = %v, want %v", login.Due, wantDue)
+	}
+
+	// Исполнитель-адрес становится Email, имя — только Name
+	if want := []Person{{ID: "acc-1", Name: "anna@example.com", Email: "anna@example.com"}}; !reflect.DeepEqual(login.Assignees, want) {
+		t.Errorf("login Assignees = %+v, want %+v", login.Assignees, want)
+	}
+	if want := []Person{{ID: "acc-2", Name: "Ivan Sidorov"}}; !reflect.DeepEqual(board.Tasks[1].Assignees, want) {
+		t.Errorf("reset Assignees = %+v, want %+v", board.Tasks[1].Assignees, want)
+	}
+	if board.Tasks[2].Assignees != nil {
+		t.Errorf("old bug Assignees = %+v, want none", board.Tasks[2].Assignees)
+	}
+
+	wantComments := []Comment{
+		{
+			Author:    Person{ID: "ivan@example.com", Email: "ivan@example.com"},
+			Text:      "Please add OAuth",
+			CreatedAt: time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			Author:    Person{ID: "acc-1"},
+			Text:      "Will do",
+			CreatedAt: time.Date(2024, 7, 2, 14, 30, 0, 0, time.UTC),
+		},
+	}
+	if !reflect.DeepEqual(login.Comments, wantComments) {
+		t.Errorf("login Comments = %+v, want %+v", login.Comments, wantComments)
+	}
+	// Комментарий без даты и автора сохраняется как текст
+	if want := []Comment{{Text: "Fixed in release"}}; !reflect.DeepEqual(board.Tasks[2].Comments, want) {
+		t.Errorf("old bug Comments = %+v, want %+v", board.Tasks[2].Comments, want)
+	}
+}
+
+func TestParseJiraInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"Key,Status\nWEB-1,To Do\n",
+		"Summary,Due Date\nTask,someday\n",
+		"Summary,Comment\nTask,yesterday;anna;text\n",
+	}
+	for _, data := range tests {
+		if _, err := ParseJira([]byte(data)); !errors.Is(err, ErrInvalidExport) {
+			t.Errorf("ParseJira(%q) error = %v, want ErrInvalidExport", data, err)
+		}
+	}
+}
+
+func TestParseJiraTime(t *testing.T) {
+	want := time.Date(2024, 7, 3, 15, 4, 0, 0, time.UTC)
+	for _, value := range []string{"03/Jul/24 3:04 PM", "03/Jul/24 15:04", "2024-07-03 15:04", "2024-07-03T15:04:00Z"} {
+		got, err := parseJiraTime(value)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("parseJiraTime(%q) = %v, %v, want %v", value, got, err, want)
+		}
+	}
+}
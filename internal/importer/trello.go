@@ -0,0 +1,135 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// trelloBoard — поля JSON-выгрузки доски Trello (Menu → Print, export and share → Export as JSON)
+type trelloBoard struct {
+	Name  string `json:"name"`
+	Lists []struct {
+		ID     string `json:"id"`
+		Name   string `json:"name"`
+		Closed bool   `json:"closed"`
+	} `json:"lists"`
+	Cards []struct {
+		ID          string     `json:"id"`
+		IDShort     int        `json:"idShort"`
+		Name        string     `json:"name"`
+		Desc        string     `json:"desc"`
+		IDList      string     `json:"idList"`
+		IDMembers   []string   `json:"idMembers"`
+		Due         *time.Time `json:"due"`
+		DueComplete bool       `json:"dueComplete"`
+		Closed      bool       `json:"closed"`
+		ShortURL    string     `json:"shortUrl"`
+		Labels      []struct {
+			Name  string `json:"name"`
+			Color string `json:"color"`
+		} `json:"labels"`
+	} `json:"cards"`
+	Members []trelloMember `json:"members"`
+	Actions []struct {
+		Type          string       `json:"type"`
+		Date          time.Time    `json:"date"`
+		MemberCreator trelloMember `json:"memberCreator"`
+		Data          struct {
+			Text string `json:"text"`
+			Card struct {
+				ID string `json:"id"`
+			} `json:"card"`
+		} `json:"data"`
+	} `json:"actions"`
+}
+
+type trelloMember struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	FullName string `json:"fullName"`
+}
+
+func (m trelloMember) person() Person {
+	return Person{ID: m.ID, Username: m.Username, Name: m.FullName}
+}
+
+// ParseTrello разбирает JSON-выгрузку доски. Статус задачи — название её колонки; архивные
+// карточки и карточки архивных колонок пропускаются. Выгрузка Trello не содержит адресов участников.
+func ParseTrello(data []byte) (*Board, error) {
+	var export trelloBoard
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+	}
+	if export.Name == "" && len(export.Cards) == 0 {
+		return nil, fmt.Errorf("%w: not a Trello board export", ErrInvalidExport)
+	}
+
+	lists := make(map[string]string, len(export.Lists))
+	closedLists := make(map[string]bool)
+	for _, list := range export.Lists {
+		lists[list.ID] = list.Name
+		closedLists[list.ID] = list.Closed
+	}
+	members := make(map[string]Person, len(export.Members))
+	for _, member := range export.Members {
+		members[member.ID] = member.person()
+	}
+
+	comments := make(map[string][]Comment)
+	for _, action := range export.Actions {
+		if action.Type != "commentCard" || strings.TrimSpace(action.Data.Text) == "" {
+			continue
+		}
+		comments[action.Data.Card.ID] = append(comments[action.Data.Card.ID], Comment{
+			Author:    action.MemberCreator.person(),
+			Text:      action.Data.Text,
+			CreatedAt: action.Date,
+		})
+	}
+
+	board := &Board{Name: export.Name}
+	for _, card := range export.Cards {
+		if card.Closed || closedLists[card.IDList] {
+			board.Skipped++
+			continue
+		}
+		task := &Task{
+			ExternalID:  card.ID,
+			Key:         fmt.Sprintf("#%d", card.IDShort),
+			URL:         card.ShortURL,
+			Title:       strings.TrimSpace(card.Name),
+			Description: card.Desc,
+			Status:      lists[card.IDList],
+			// Отметка о выполнении срока в Trello и есть закрытие карточки
+			Done: card.DueComplete,
+			Due:  card.Due,
+		}
+		for _, id := range card.IDMembers {
+			if member, ok := members[id]; ok {
+				task.Assignees = append(task.Assignees, member)
+			} else {
+				task.Assignees = append(task.Assignees, Person{ID: id})
+			}
+		}
+		for _, label := range card.Labels {
+			// У меток Trello может не быть названия, только цвет
+			name := strings.TrimSpace(label.Name)
+			if name == "" {
+				name = label.Color
+			}
+			if name != "" {
+				task.Labels = append(task.Labels, name)
+			}
+		}
+		// Действия в выгрузке идут от новых к старым
+		task.Comments = comments[card.ID]
+		sort.SliceStable(task.Comments, func(i, j int) bool {
+			return task.Comments[i].CreatedAt.Before(task.Comments[j].CreatedAt)
+		})
+		board.Tasks = append(board.Tasks, task)
+	}
+	return board, nil
+}
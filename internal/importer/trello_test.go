@@ -0,0 +1,102 @@
+package importer
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseTrello(t *testing.T) {
+	board, err := Parse(SourceTrello, readFixture(t, "trello.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if board.Name != "Roadmap" {
+		t.Errorf("Name = %q, want Roadmap", board.Name)
+	}
+	// Архивная карточка и карточка архивной колонки
+	if board.Skipped != 2 {
+		t.Errorf("Skipped = %d, want 2", board.Skipped)
+	}
+	if len(board.Tasks) != 3 {
+		t.Fatalf("got %d tasks, want 3", len(board.Tasks))
+	}
+
+	tests := []struct {
+		title  string
+		status string
+		done   bool
+	}{
+		{title: "Write spec", status: "To Do"},
+		{title: "Build API", status: "Doing"},
+		{title: "Release", status: "Shipped", done: true},
+	}
+	for i, tt := range tests {
+		task := board.Tasks[i]
+		if task.Title != tt.title || task.Status != tt.status || task.Done != tt.done {
+			t.Errorf("task %d = %q/%q/%v, want %q/%q/%v", i, task.Title, task.Status, task.Done, tt.title, tt.status, tt.done)
+		}
+	}
+
+	spec := board.Tasks[0]
+	if spec.ExternalID != "c1" || spec.Key != "#1" || spec.URL != "https://trello.com/c/abc1" {
+		t.Errorf("spec ids = %q %q %q", spec.ExternalID, spec.Key, spec.URL)
+	}
+	wantDue := time.Date(2024, 7, 3, 10, 15, 0, 0, time.UTC)
+	if spec.Due == nil || !spec.Due.Equal(wantDue) {
+		t.Errorf("spec Due = %v, want %v", spec.Due, wantDue)
+	}
+	// Метка без названия импортируется по цвету
+	if want := []string{"docs", "red"}; !reflect.DeepEqual(spec.Labels, want) {
+		t.Errorf("spec Labels = %v, want %v", spec.Labels, want)
+	}
+
+	// Выгрузка Trello без адресов: участника находят по ID, логину или имени
+	wantAssignees := []Person{
+		{ID: "m1", Username: "anna", Name: "Anna Petrova"},
+		{ID: "m9"},
+	}
+	if !reflect.DeepEqual(spec.Assignees, wantAssignees) {
+		t.Errorf("spec Assignees = %+v, want %+v", spec.Assignees, wantAssignees)
+	}
+
+	// Только commentCard, от старых к новым
+	if len(spec.Comments) != 2 {
+		t.Fatalf("spec has %d comments, want 2", len(spec.Comments))
+	}
+	if spec.Comments[0].Text != "Started" || spec.Comments[0].Author.Username != "anna" {
+		t.Errorf("first comment = %+v", spec.Comments[0])
+	}
+	if spec.Comments[1].Text != "Looks good" || spec.Comments[1].Author.Username != "ivan" {
+		t.Errorf("second comment = %+v", spec.Comments[1])
+	}
+	// Пустой комментарий пропускается
+	if len(board.Tasks[1].Comments) != 0 {
+		t.Errorf("Build API comments = %+v, want none", board.Tasks[1].Comments)
+	}
+}
+
+func TestParseTrelloInvalid(t *testing.T) {
+	for _, data := range []string{`not json`, `{}`, `[]`} {
+		if _, err := ParseTrello([]byte(data)); !errors.Is(err, ErrInvalidExport) {
+			t.Errorf("ParseTrello(%q) error = %v, want ErrInvalidExport", data, err)
+		}
+	}
+}
+
+func TestParseUnsupportedSource(t *testing.T) {
+	if _, err := Parse("asana", []byte("{}")); !errors.Is(err, ErrUnsupportedSource) {
+		t.Errorf("Parse error = %v, want ErrUnsupportedSource", err)
+	}
+}
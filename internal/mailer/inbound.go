@@ -0,0 +1,204 @@
+package mailer
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// maxInboundParts ограничивает вложенность и число частей, чтобы письмо не разбиралось бесконечно
+const maxInboundParts = 50
+
+// Inbound — входящее письмо: отправитель, все адресаты и текст без цитат
+type Inbound struct {
+	MessageID string
+	From      string
+	To        []string
+	Subject   string
+	Text      string
+}
+
+// ParseInbound разбирает письмо RFC 5322. Текст берётся из text/plain, а если его нет — из text/html;
+// вложения пропускаются. Кодировки тела кроме UTF-8, US-ASCII и ISO-8859-1 не поддерживаются.
+func ParseInbound(r io.Reader) (*Inbound, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message: %w", err)
+	}
+
+	from, err := msg.Header.AddressList("From")
+	if err != nil || len(from) == 0 {
+		return nil, errors.New("message has no sender")
+	}
+	in := &Inbound{
+		MessageID: strings.Trim(strings.TrimSpace(msg.Header.Get("Message-Id")), "<>"),
+		From:      strings.ToLower(from[0].Address),
+	}
+	if subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject")); err == nil {
+		in.Subject = subject
+	}
+	// ответ мог прийти в копии или переадресацией, поэтому смотрим все заголовки с адресатами
+	for _, name := range []string{"To", "Cc", "Delivered-To", "X-Original-To"} {
+		list, err := msg.Header.AddressList(name)
+		if err != nil {
+			continue
+		}
+		for _, addr := range list {
+			in.To = append(in.To, addr.Address)
+		}
+	}
+
+	p := &bodyParser{}
+	if err := p.walk(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body, 0); err != nil {
+		return nil, err
+	}
+	switch {
+	case p.text != "":
+		in.Text = StripQuoted(p.text)
+	case p.html != "":
+		in.Text = StripQuoted(htmlToText(p.html))
+	}
+	return in, nil
+}
+
+type bodyParser struct {
+	text, html string
+	parts      int
+}
+
+func (p *bodyParser) walk(contentType, encoding string, body io.Reader, depth int) error {
+	p.parts++
+	if p.parts > maxInboundParts || depth > 5 {
+		return errors.New("message has too many parts")
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("invalid multipart body: %w", err)
+			}
+			// вложения не нужны: комментарий собирается только из текста
+			if disposition, _, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition")); disposition == "attachment" {
+				continue
+			}
+			// multipart.Reader сам снимает quoted-printable и убирает заголовок
+			err = p.walk(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part, depth+1)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		return nil
+	}
+	if (mediaType == "text/plain" && p.text != "") || (mediaType == "text/html" && p.html != "") {
+		return nil
+	}
+	data, err := io.ReadAll(decodeTransfer(encoding, body))
+	if err != nil {
+		return fmt.Errorf("invalid body encoding: %w", err)
+	}
+	text, err := decodeCharset(params["charset"], data)
+	if err != nil {
+		return err
+	}
+	if mediaType == "text/plain" {
+		p.text = text
+	} else {
+		p.html = text
+	}
+	return nil
+}
+
+func decodeTransfer(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	}
+	return body
+}
+
+func decodeCharset(charset string, data []byte) (string, error) {
+	switch strings.ToLower(charset) {
+	case "", "utf-8", "utf8", "us-ascii":
+		return strings.ToValidUTF8(string(data), "�"), nil
+	case "iso-8859-1", "latin1":
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return string(runes), nil
+	}
+	if utf8.Valid(data) {
+		return string(data), nil
+	}
+	return "", fmt.Errorf("unsupported charset %q", charset)
+}
+
+var (
+	htmlQuote = regexp.MustCompile(`(?is)<blockquote.*</blockquote>|<div[^>]*class="[^"]*(gmail_quote|moz-cite-prefix)[^"]*".*`)
+	htmlBreak = regexp.MustCompile(`(?i)<br\s*/?>|</p>|</div>|</li>|</tr>`)
+	blankRuns = regexp.MustCompile(`\n{3,}`)
+)
+
+// htmlToText оставляет от HTML-письма только текст; цитаты почтовых клиентов отрезаются целиком
+func htmlToText(s string) string {
+	s = htmlQuote.ReplaceAllString(s, "")
+	s = htmlBreak.ReplaceAllString(s, "\n")
+	s = html.UnescapeString(bluemonday.StrictPolicy().Sanitize(s))
+	return blankRuns.ReplaceAllString(s, "\n\n")
+}
+
+var (
+	// строка-заголовок цитаты: «On Mon, 1 Jul 2024, Ivan <ivan@example.com> wrote:», «… пишет:»
+	quoteHeader = regexp.MustCompile(`(?i)^(on\s.+|.*\d{4}.*)(wrote|пишет|написала?|написал\(а\))\s*:$`)
+	quoteSplit  = regexp.MustCompile(`(?i)^-{2,}\s*(original message|forwarded message|исходное сообщение|пересылаемое сообщение)\s*-{2,}$|^_{10,}$`)
+)
+
+// StripQuoted отрезает от ответа цитату исходного письма и подпись: всё после строки вида
+// «On … wrote:», разделителя «-----Original Message-----» или «-- », а также строки с «>»
+func StripQuoted(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+
+	kept := make([]string, 0, len(lines))
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if line == "-- " || quoteSplit.MatchString(trimmed) || quoteHeader.MatchString(trimmed) {
+			break
+		}
+		// Gmail переносит длинный заголовок цитаты: «On Mon, … Ivan» и «<ivan@example.com> wrote:»
+		if i+1 < len(lines) && trimmed != "" && quoteHeader.MatchString(trimmed+" "+strings.TrimSpace(lines[i+1])) &&
+			strings.HasPrefix(strings.ToLower(trimmed), "on ") {
+			break
+		}
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+		kept = append(kept, strings.TrimRight(line, " \t"))
+	}
+	return strings.TrimSpace(blankRuns.ReplaceAllString(strings.Join(kept, "\n"), "\n\n"))
+}
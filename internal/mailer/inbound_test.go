@@ -0,0 +1,112 @@
+package mailer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStripQuoted(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "plain reply",
+			in:   "Готово, проверьте.\r\n",
+			want: "Готово, проверьте.",
+		},
+		{
+			name: "gmail quote header",
+			in:   "Sounds good.\n\nOn Mon, 1 Jul 2024 at 10:00, Ivan <ivan@example.com> wrote:\n> Can you take a look?\n",
+			want: "Sounds good.",
+		},
+		{
+			name: "wrapped gmail quote header",
+			in:   "Sounds good.\n\nOn Mon, 1 Jul 2024 at 10:00, Ivan Petrov\n<ivan@example.com> wrote:\n> Can you take a look?\n",
+			want: "Sounds good.",
+		},
+		{
+			name: "russian quote header",
+			in:   "Сделаю завтра.\n\n1 июля 2024 г., в 10:00, Иван <ivan@example.com> написал:\n> Посмотришь?",
+			want: "Сделаю завтра.",
+		},
+		{
+			name: "outlook separator",
+			in:   "Approved.\n\n-----Original Message-----\nFrom: Ivan\nSubject: Review",
+			want: "Approved.",
+		},
+		{
+			name: "signature",
+			in:   "Fixed in master.\n\n-- \nIvan Petrov\nTeam lead",
+			want: "Fixed in master.",
+		},
+		{
+			name: "interleaved quotes",
+			in:   "> first question\nFirst answer.\n> second question\nSecond answer.   \n",
+			want: "First answer.\nSecond answer.",
+		},
+		{
+			name: "blank lines collapsed",
+			in:   "Line one.\n\n\n\nLine two.",
+			want: "Line one.\n\nLine two.",
+		},
+		{
+			name: "dashes inside text kept",
+			in:   "Range is 10--20, see the doc.",
+			want: "Range is 10--20, see the doc.",
+		},
+		{
+			name: "only quote",
+			in:   "> just the quote",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StripQuoted(tt.in); got != tt.want {
+				t.Errorf("StripQuoted() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplyAddress(t *testing.T) {
+	const secret = "reply-secret"
+	addr := ReplyAddress(secret, "mail.example.com", 42, 7)
+	if !strings.HasPrefix(addr, "reply+42.7.") || !strings.HasSuffix(addr, "@mail.example.com") {
+		t.Fatalf("ReplyAddress = %q", addr)
+	}
+	local, _, _ := strings.Cut(addr, "@")
+	sig := local[strings.LastIndex(local, ".")+1:]
+
+	tests := []struct {
+		name   string
+		secret string
+		addr   string
+		task   int
+		user   int
+		ok     bool
+	}{
+		{name: "valid", secret: secret, addr: addr, task: 42, user: 7, ok: true},
+		{name: "upper case", secret: secret, addr: strings.ToUpper(addr), task: 42, user: 7, ok: true},
+		{name: "other domain", secret: secret, addr: local + "@elsewhere.org", task: 42, user: 7, ok: true},
+		{name: "wrong secret", secret: "other", addr: addr},
+		{name: "other task", secret: secret, addr: "reply+43.7." + sig + "@mail.example.com"},
+		{name: "other user", secret: secret, addr: "reply+42.8." + sig + "@mail.example.com"},
+		{name: "truncated signature", secret: secret, addr: "reply+42.7." + sig[:8] + "@mail.example.com"},
+		{name: "missing user", secret: secret, addr: "reply+42." + sig + "@mail.example.com"},
+		{name: "negative task", secret: secret, addr: "reply+-42.7." + sig + "@mail.example.com"},
+		{name: "not a reply address", secret: secret, addr: "support@mail.example.com"},
+		{name: "no domain", secret: secret, addr: local},
+		{name: "empty", secret: secret, addr: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task, user, ok := ParseReplyAddress(tt.secret, tt.addr)
+			if ok != tt.ok || task != tt.task || user != tt.user {
+				t.Errorf("ParseReplyAddress(%q) = %d, %d, %v, want %d, %d, %v", tt.addr, task, user, ok, tt.task, tt.user, tt.ok)
+			}
+		})
+	}
+}
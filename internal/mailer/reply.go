@@ -0,0 +1,51 @@
+package mailer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// replyPrefix — локальная часть адреса для ответов: reply+<задача>.<получатель>.<подпись>@домен
+const replyPrefix = "reply+"
+
+// ReplyAddress возвращает адрес, ответ на который станет комментарием к задаче от имени userID.
+// Адрес у каждого получателя свой: подпись не даёт ни подобрать чужую задачу, ни ответить
+// с чужого адреса, имея на руках своё письмо.
+func ReplyAddress(secret, domain string, taskID, userID int) string {
+	return fmt.Sprintf("%s%d.%d.%s@%s", replyPrefix, taskID, userID, replySignature(secret, taskID, userID), domain)
+}
+
+// ParseReplyAddress находит задачу и получателя письма по адресу для ответов;
+// ok == false, если адрес не наш или подпись неверна
+func ParseReplyAddress(secret, address string) (taskID, userID int, ok bool) {
+	local, _, found := strings.Cut(address, "@")
+	if !found || len(local) < len(replyPrefix) || !strings.EqualFold(local[:len(replyPrefix)], replyPrefix) {
+		return 0, 0, false
+	}
+	parts := strings.Split(local[len(replyPrefix):], ".")
+	if len(parts) != 3 {
+		return 0, 0, false
+	}
+	taskID, err := strconv.Atoi(parts[0])
+	if err != nil || taskID <= 0 {
+		return 0, 0, false
+	}
+	userID, err = strconv.Atoi(parts[1])
+	if err != nil || userID <= 0 {
+		return 0, 0, false
+	}
+	if !hmac.Equal([]byte(strings.ToLower(parts[2])), []byte(replySignature(secret, taskID, userID))) {
+		return 0, 0, false
+	}
+	return taskID, userID, true
+}
+
+func replySignature(secret string, taskID, userID int) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "task:%d:user:%d", taskID, userID)
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
@@ -0,0 +1,71 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPMailer отправляет письма через SMTP-сервер. STARTTLS включается, если сервер его
+// поддерживает; логин и пароль передаются только по защищённому соединению или на localhost.
+type SMTPMailer struct {
+	Addr     string
+	Host     string
+	Username string
+	Password string
+}
+
+func NewSMTPMailer(host string, port int, username, password string) (*SMTPMailer, error) {
+	if host == "" {
+		return nil, errors.New("smtp host is required")
+	}
+	if port == 0 {
+		port = 587
+	}
+	return &SMTPMailer{
+		Addr:     net.JoinHostPort(host, strconv.Itoa(port)),
+		Host:     host,
+		Username: username,
+		Password: password,
+	}, nil
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg *Message) error {
+	body, err := Build(msg, time.Now())
+	if err != nil {
+		return err
+	}
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return err
+	}
+	to := make([]string, 0, len(msg.To))
+	for _, rcpt := range msg.To {
+		addr, err := mail.ParseAddress(rcpt)
+		if err != nil {
+			return err
+		}
+		to = append(to, addr.Address)
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	// smtp.SendMail не принимает контекст, поэтому отменяем ожидание, а не сам запрос
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(m.Addr, auth, from.Address, to, body)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
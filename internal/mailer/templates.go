@@ -0,0 +1,78 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// DefaultTemplate используется для типов уведомлений, у которых нет своего шаблона
+const DefaultTemplate = "default"
+
+type templateSet struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// Templates — шаблоны писем по типам уведомлений. Для типа name нужны templates/name.txt
+// с блоками subject и text и templates/name.html с блоком content, который вставляется в layout.html.
+type Templates struct {
+	sets map[string]templateSet
+}
+
+func LoadTemplates() (*Templates, error) {
+	entries, err := fs.Glob(templateFS, "templates/*.txt")
+	if err != nil {
+		return nil, err
+	}
+	t := &Templates{sets: map[string]templateSet{}}
+	for _, entry := range entries {
+		name := strings.TrimSuffix(strings.TrimPrefix(entry, "templates/"), ".txt")
+		text, err := texttemplate.ParseFS(templateFS, entry)
+		if err != nil {
+			return nil, err
+		}
+		html, err := htmltemplate.ParseFS(templateFS, "templates/layout.html", "templates/"+name+".html")
+		if err != nil {
+			return nil, err
+		}
+		t.sets[name] = templateSet{text: text, html: html}
+	}
+	if _, ok := t.sets[DefaultTemplate]; !ok {
+		return nil, fmt.Errorf("template %q is missing", DefaultTemplate)
+	}
+	return t, nil
+}
+
+// Render заполняет шаблон name данными data и возвращает тему, текстовую и HTML-версию письма
+func (t *Templates) Render(name string, data any) (subject, text, html string, err error) {
+	set, ok := t.sets[name]
+	if !ok {
+		set = t.sets[DefaultTemplate]
+	}
+
+	var buf bytes.Buffer
+	if err := set.text.ExecuteTemplate(&buf, "subject", data); err != nil {
+		return "", "", "", err
+	}
+	subject = strings.TrimSpace(buf.String())
+
+	buf.Reset()
+	if err := set.text.ExecuteTemplate(&buf, "text", data); err != nil {
+		return "", "", "", err
+	}
+	text = strings.TrimSpace(buf.String()) + "\n"
+
+	buf.Reset()
+	if err := set.html.ExecuteTemplate(&buf, "layout", data); err != nil {
+		return "", "", "", err
+	}
+	return subject, text, buf.String(), nil
+}
@@ -0,0 +1,107 @@
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"pet-project/pkg/model"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/text"
+)
+
+var (
+	md = goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM, // таблицы, зачёркивание, автоссылки и списки задач
+		),
+	)
+
+	policy = newPolicy()
+
+	taskRefRe = regexp.MustCompile(`(?:^|[^\w&/])#(\d+)\b`)
+	mentionRe = regexp.MustCompile(`(?:^|[^\w.@/])@([A-Za-z0-9_][A-Za-z0-9_.-]*)`)
+)
+
+// newPolicy — UGC-политика bluemonday с разрешёнными чекбоксами списков задач
+// и классами языка у блоков кода.
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+-]+$`)).OnElements("code")
+	return p
+}
+
+// Render превращает Markdown-источник в безопасный HTML и собирает ссылки на задачи и упоминания.
+func Render(source string) (string, model.References) {
+	if source == "" {
+		return "", model.References{Tasks: []int{}, Mentions: []string{}}
+	}
+
+	src := []byte(source)
+	doc := md.Parser().Parse(text.NewReader(src))
+
+	var buf bytes.Buffer
+	if err := md.Renderer().Render(&buf, src, doc); err != nil {
+		// Не удалось отрендерить — отдаём экранированный исходник, чтобы не потерять текст
+		return "<p>" + html.EscapeString(source) + "</p>", extractReferences(doc, src)
+	}
+
+	return policy.Sanitize(buf.String()), extractReferences(doc, src)
+}
+
+// extractReferences ищет #123 и @user только в обычном тексте: код и ссылки пропускаются.
+func extractReferences(doc ast.Node, src []byte) model.References {
+	var plain strings.Builder
+
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.CodeSpan, *ast.Link, *ast.AutoLink, *ast.Image, *ast.RawHTML:
+			plain.WriteByte(' ')
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			plain.Write(node.Segment.Value(src))
+			if node.SoftLineBreak() || node.HardLineBreak() {
+				plain.WriteByte('\n')
+			}
+		default:
+			plain.WriteByte(' ')
+		}
+		return ast.WalkContinue, nil
+	})
+
+	refs := model.References{Tasks: []int{}, Mentions: []string{}}
+	content := plain.String()
+
+	seenTasks := map[int]bool{}
+	for _, m := range taskRefRe.FindAllStringSubmatch(content, -1) {
+		id, err := strconv.Atoi(m[1])
+		if err != nil || id <= 0 || seenTasks[id] {
+			continue
+		}
+		seenTasks[id] = true
+		refs.Tasks = append(refs.Tasks, id)
+	}
+
+	seenMentions := map[string]bool{}
+	for _, m := range mentionRe.FindAllStringSubmatch(content, -1) {
+		name := strings.TrimRight(m[1], ".-")
+		if name == "" || seenMentions[strings.ToLower(name)] {
+			continue
+		}
+		seenMentions[strings.ToLower(name)] = true
+		refs.Mentions = append(refs.Mentions, name)
+	}
+
+	return refs
+}
@@ -0,0 +1,113 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderSanitizes(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		contains []string
+		absent   []string
+	}{
+		{
+			name:   "script block",
+			source: "<script>alert(1)</script>",
+			absent: []string{"<script", "alert(1)"},
+		},
+		{
+			name:     "inline script",
+			source:   "hi <script>alert(1)</script> there",
+			contains: []string{"hi", "there"},
+			absent:   []string{"<script", "</script>"},
+		},
+		{
+			name:     "javascript link",
+			source:   "[x](javascript:alert(1))",
+			contains: []string{"x"},
+			absent:   []string{"href", "javascript:"},
+		},
+		{
+			name:   "javascript link in raw html",
+			source: `<a href="javascript:alert(1)">x</a>`,
+			absent: []string{"href", "javascript:"},
+		},
+		{
+			name:     "data link",
+			source:   "[x](data:text/html;base64,PHNjcmlwdD4=)",
+			contains: []string{"x"},
+			absent:   []string{"href", "data:"},
+		},
+		{
+			name:     "data image",
+			source:   "![i](data:image/png;base64,AAAA)",
+			contains: []string{`alt="i"`},
+			absent:   []string{"src", "data:"},
+		},
+		{
+			name:   "event handler on image",
+			source: `<img src="x.png" onerror="alert(1)">`,
+			absent: []string{"onerror", "alert(1)"},
+		},
+		{
+			name:   "event handler on paragraph",
+			source: `<p onclick="alert(1)">x</p>`,
+			absent: []string{"onclick", "alert(1)"},
+		},
+		{
+			name:     "safe link",
+			source:   "[ok](https://example.com)",
+			contains: []string{`href="https://example.com"`, `rel="nofollow"`},
+		},
+		{
+			name:   "task list",
+			source: "- [x] done\n- [ ] todo",
+			contains: []string{
+				`<input checked="" disabled="" type="checkbox"> done`,
+				`<input disabled="" type="checkbox"> todo`,
+			},
+		},
+		{
+			name:     "code language class",
+			source:   "```go\nfmt.Println()\n```",
+			contains: []string{`<code class="language-go">`},
+		},
+	}
+	for _, tt := range tests {
+		got, _ := Render(tt.source)
+		for _, want := range tt.contains {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s: Render(%q) = %q, want it to contain %q", tt.name, tt.source, got, want)
+			}
+		}
+		for _, bad := range tt.absent {
+			if strings.Contains(got, bad) {
+				t.Errorf("%s: Render(%q) = %q, must not contain %q", tt.name, tt.source, got, bad)
+			}
+		}
+	}
+}
+
+// Рендерер сейчас отбрасывает сырой HTML сам, поэтому политику проверяем и напрямую
+func TestPolicy(t *testing.T) {
+	tests := []struct {
+		html string
+		want string
+	}{
+		{html: `<p onclick="alert(1)">x</p>`, want: `<p>x</p>`},
+		{html: `<img src="x.png" onerror="alert(1)">`, want: `<img src="x.png">`},
+		{html: `<a href="javascript:alert(1)" onmouseover="alert(1)">x</a>`, want: `x`},
+		{html: `<a href="data:text/html,x">x</a>`, want: `x`},
+		{html: `<script>alert(1)</script>x`, want: `x`},
+		{html: `<input type="checkbox" checked disabled>`, want: `<input type="checkbox" checked="" disabled="">`},
+		{html: `<input type="text" value="x">`, want: ``},
+		{html: `<code class="evil">x</code>`, want: `<code>x</code>`},
+	}
+	for _, tt := range tests {
+		if got := policy.Sanitize(tt.html); got != tt.want {
+			t.Errorf("Sanitize(%q) = %q, want %q", tt.html, got, tt.want)
+		}
+	}
+}
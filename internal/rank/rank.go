@@ -0,0 +1,124 @@
+package rank
+
+import (
+	"errors"
+	"strings"
+)
+
+// Ранги — строки из цифр base62, упорядоченные побайтно (в Postgres колонка с COLLATE "C").
+// Между любыми двумя рангами всегда можно вставить третий, не трогая соседей.
+const digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+const base = len(digits)
+
+// MaxLength — после такой длины колонку стоит перенумеровать, см. Spread
+const MaxLength = 64
+
+var ErrInvalidOrder = errors.New("rank: prev must be less than next")
+
+// Between возвращает ранг строго между prev и next. Пустой prev — начало списка,
+// пустой next — конец.
+func Between(prev, next string) (string, error) {
+	if next != "" && prev >= next {
+		return "", ErrInvalidOrder
+	}
+	if !valid(prev) || !valid(next) {
+		return "", errors.New("rank: invalid rank")
+	}
+	return midpoint(prev, next), nil
+}
+
+// Spread возвращает n равномерно распределённых коротких рангов для перенумерации колонки
+func Spread(n int) []string {
+	ranks := make([]string, n)
+	if n == 0 {
+		return ranks
+	}
+
+	// длина, при которой хватает места для n значений с запасом между ними
+	length, capacity := 1, base-1
+	for capacity < n*2 {
+		length++
+		capacity *= base
+	}
+	step := capacity / (n + 1)
+	for i := range ranks {
+		ranks[i] = encode((i+1)*step, length)
+	}
+	return ranks
+}
+
+func encode(value, length int) string {
+	buf := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		buf[i] = digits[value%base]
+		value /= base
+	}
+	return strings.TrimRight(string(buf), "0")
+}
+
+func valid(r string) bool {
+	for i := 0; i < len(r); i++ {
+		if strings.IndexByte(digits, r[i]) < 0 {
+			return false
+		}
+	}
+	// завершающий ноль не даёт вставить значение перед рангом
+	return !strings.HasSuffix(r, "0")
+}
+
+func midpoint(a, b string) string {
+	if b != "" {
+		// общий префикс (a дополняется нулями)
+		n := 0
+		for n < len(b) && digitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			rest := ""
+			if n < len(a) {
+				rest = a[n:]
+			}
+			return b[:n] + midpoint(rest, b[n:])
+		}
+	}
+
+	da := 0
+	if a != "" {
+		da = strings.IndexByte(digits, a[0])
+	}
+	db := base
+	if b != "" {
+		db = strings.IndexByte(digits, b[0])
+	}
+
+	if db-da > 1 {
+		// На краях списка шагаем на одну цифру, а не делим пополам: карточки чаще всего
+		// добавляют в конец колонки, и так ранги растут в длину намного медленнее
+		switch {
+		case a == "" && b == "":
+			return string(digits[base/2])
+		case b == "":
+			return string(digits[da+1])
+		case a == "":
+			return string(digits[db-1])
+		}
+		return string(digits[(da+db+1)/2])
+	}
+	// соседние цифры: берём первую цифру b, если за ней что-то есть, иначе углубляемся в a
+	if len(b) > 1 {
+		return b[:1]
+	}
+	rest := ""
+	if len(a) > 1 {
+		rest = a[1:]
+	}
+	return string(digits[da]) + midpoint(rest, "")
+}
+
+func digitAt(s string, i int) byte {
+	if i < len(s) {
+		return s[i]
+	}
+	return '0'
+}
@@ -0,0 +1,140 @@
+package rank
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		prev, next string
+		want       string
+	}{
+		{prev: "", next: "", want: "V"},
+		{prev: "V", next: "", want: "W"},
+		{prev: "", next: "V", want: "U"},
+		{prev: "A", next: "C", want: "B"},
+		{prev: "A", next: "B", want: "AV"},
+		{prev: "z", next: "", want: "zV"},
+		{prev: "", next: "1", want: "0V"},
+		{prev: "AB", next: "AD", want: "AC"},
+		{prev: "A", next: "A1", want: "A0V"},
+	}
+	for _, tt := range tests {
+		got, err := Between(tt.prev, tt.next)
+		if err != nil {
+			t.Errorf("Between(%q, %q): %v", tt.prev, tt.next, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Between(%q, %q) = %q, want %q", tt.prev, tt.next, got, tt.want)
+		}
+		if got <= tt.prev || (tt.next != "" && got >= tt.next) {
+			t.Errorf("Between(%q, %q) = %q is out of order", tt.prev, tt.next, got)
+		}
+	}
+}
+
+func TestBetweenErrors(t *testing.T) {
+	tests := []struct {
+		prev, next string
+		wantOrder  bool
+	}{
+		{prev: "B", next: "A", wantOrder: true},
+		{prev: "B", next: "B", wantOrder: true},
+		{prev: "A-", next: ""},
+		{prev: "", next: "A0"},
+		{prev: "A", next: "Bé"},
+	}
+	for _, tt := range tests {
+		_, err := Between(tt.prev, tt.next)
+		if err == nil {
+			t.Errorf("Between(%q, %q) succeeded", tt.prev, tt.next)
+			continue
+		}
+		if errors.Is(err, ErrInvalidOrder) != tt.wantOrder {
+			t.Errorf("Between(%q, %q): err = %v", tt.prev, tt.next, err)
+		}
+	}
+}
+
+// Многократные вставки в начало, конец и середину дают валидные упорядоченные ранги
+func TestBetweenRepeatedInserts(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	ranks := []string{}
+	for i := 0; i < 2000; i++ {
+		pos := rnd.Intn(len(ranks) + 1)
+		switch i % 3 {
+		case 0:
+			pos = len(ranks)
+		case 1:
+			pos = 0
+		}
+		prev, next := "", ""
+		if pos > 0 {
+			prev = ranks[pos-1]
+		}
+		if pos < len(ranks) {
+			next = ranks[pos]
+		}
+		r, err := Between(prev, next)
+		if err != nil {
+			t.Fatalf("insert %d: Between(%q, %q): %v", i, prev, next, err)
+		}
+		if !valid(r) {
+			t.Fatalf("insert %d: invalid rank %q", i, r)
+		}
+		ranks = append(ranks[:pos], append([]string{r}, ranks[pos:]...)...)
+	}
+	if !sort.StringsAreSorted(ranks) {
+		t.Fatal("ranks are out of order")
+	}
+	for i := 1; i < len(ranks); i++ {
+		if ranks[i] == ranks[i-1] {
+			t.Fatalf("duplicate rank %q", ranks[i])
+		}
+	}
+}
+
+func TestBetweenAppendGrowsSlowly(t *testing.T) {
+	last := ""
+	for i := 0; i < 1000; i++ {
+		r, err := Between(last, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		last = r
+	}
+	// при делении пополам длина росла бы на цифру каждые 5-6 вставок, шагом — раз в ~37
+	if len(last) > 1000/30 {
+		t.Errorf("after 1000 appends rank has length %d", len(last))
+	}
+}
+
+func TestSpread(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 30, 61, 500, 10000} {
+		ranks := Spread(n)
+		if len(ranks) != n {
+			t.Fatalf("Spread(%d) returned %d ranks", n, len(ranks))
+		}
+		for i, r := range ranks {
+			if !valid(r) || r == "" {
+				t.Fatalf("Spread(%d)[%d] = %q is invalid", n, i, r)
+			}
+			if i > 0 && ranks[i-1] >= r {
+				t.Fatalf("Spread(%d) is out of order at %d: %q >= %q", n, i, ranks[i-1], r)
+			}
+		}
+		if n > 0 {
+			// после перенумерации можно вставить в начало и в конец
+			if _, err := Between("", ranks[0]); err != nil {
+				t.Errorf("Spread(%d): can't insert before first: %v", n, err)
+			}
+			if _, err := Between(ranks[n-1], ""); err != nil {
+				t.Errorf("Spread(%d): can't insert after last: %v", n, err)
+			}
+		}
+	}
+}
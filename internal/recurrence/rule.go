@@ -0,0 +1,272 @@
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+)
+
+// maxPeriods ограничивает перебор периодов при поиске следующей даты
+const maxPeriods = 10000
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// Rule — подмножество RRULE (RFC 5545): FREQ=DAILY|WEEKLY|MONTHLY, INTERVAL, BYDAY (для WEEKLY),
+// BYMONTHDAY (для MONTHLY, отрицательные значения считаются от конца месяца), COUNT и UNTIL.
+type Rule struct {
+	Freq       string
+	Interval   int
+	ByDay      []time.Weekday
+	ByMonthDay []int
+	Count      int
+	Until      *time.Time
+}
+
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(strings.ToUpper(s), "RRULE:")
+	if s == "" {
+		return nil, errors.New("Recurrence rule is empty")
+	}
+
+	rule := &Rule{Interval: 1}
+	for _, part := range strings.Split(s, ";") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("Invalid rule part %q", part)
+		}
+
+		switch key {
+		case "FREQ":
+			if value != FreqDaily && value != FreqWeekly && value != FreqMonthly {
+				return nil, fmt.Errorf("Unsupported FREQ %q, use DAILY, WEEKLY or MONTHLY", value)
+			}
+			rule.Freq = value
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return nil, errors.New("INTERVAL must be a positive number")
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return nil, errors.New("COUNT must be a positive number")
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = &until
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				wd, ok := weekdays[day]
+				if !ok {
+					return nil, fmt.Errorf("Invalid BYDAY value %q", day)
+				}
+				rule.ByDay = append(rule.ByDay, wd)
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(day)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("Invalid BYMONTHDAY value %q", day)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, n)
+			}
+		case "WKST":
+			// неделя всегда начинается с понедельника
+		default:
+			return nil, fmt.Errorf("Unsupported rule part %q", key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, errors.New("FREQ is required")
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, errors.New("COUNT and UNTIL can't be used together")
+	}
+	if len(rule.ByDay) > 0 && rule.Freq != FreqWeekly {
+		return nil, errors.New("BYDAY is supported only with FREQ=WEEKLY")
+	}
+	if len(rule.ByMonthDay) > 0 && rule.Freq != FreqMonthly {
+		return nil, errors.New("BYMONTHDAY is supported only with FREQ=MONTHLY")
+	}
+	return rule, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			// дата без времени включает весь день
+			if len(value) <= len("2006-01-02") {
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Invalid UNTIL value %q", value)
+}
+
+// String возвращает правило в каноничном виде RRULE
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, wd := range r.ByDay {
+			for name, d := range weekdays {
+				if d == wd {
+					days = append(days, name)
+				}
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, 0, len(r.ByMonthDay))
+		for _, d := range r.ByMonthDay {
+			days = append(days, strconv.Itoa(d))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next возвращает первое вхождение серии, начатой в start, строго после after.
+// COUNT здесь не учитывается — число созданных вхождений хранит сама серия.
+func (r *Rule) Next(start, after time.Time) (time.Time, bool) {
+	if after.Before(start) {
+		after = start.Add(-time.Nanosecond)
+	}
+
+	var next time.Time
+	var ok bool
+	switch r.Freq {
+	case FreqDaily:
+		next, ok = r.nextDaily(start, after)
+	case FreqWeekly:
+		next, ok = r.nextWeekly(start, after)
+	case FreqMonthly:
+		next, ok = r.nextMonthly(start, after)
+	}
+	if !ok || (r.Until != nil && next.After(*r.Until)) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+func (r *Rule) nextDaily(start, after time.Time) (time.Time, bool) {
+	days := int(after.Sub(start).Hours() / 24)
+	k := days / r.Interval
+	if k > 0 {
+		k--
+	}
+	for i := 0; i < maxPeriods; i++ {
+		t := start.AddDate(0, 0, (k+i)*r.Interval)
+		if t.After(after) {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (r *Rule) nextWeekly(start, after time.Time) (time.Time, bool) {
+	days := r.ByDay
+	if len(days) == 0 {
+		days = []time.Weekday{start.Weekday()}
+	}
+	offsets := make([]int, 0, len(days))
+	for _, wd := range days {
+		offsets = append(offsets, (int(wd)+6)%7)
+	}
+	sort.Ints(offsets)
+
+	// Понедельник недели, в которую попадает start, с тем же временем суток
+	weekStart := start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+	weeks := int(after.Sub(weekStart).Hours() / (24 * 7))
+	k := weeks / r.Interval
+	if k > 0 {
+		k--
+	}
+	for i := 0; i < maxPeriods; i++ {
+		period := weekStart.AddDate(0, 0, 7*(k+i)*r.Interval)
+		for _, offset := range offsets {
+			t := period.AddDate(0, 0, offset)
+			if !t.Before(start) && t.After(after) {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func (r *Rule) nextMonthly(start, after time.Time) (time.Time, bool) {
+	monthDays := r.ByMonthDay
+	if len(monthDays) == 0 {
+		monthDays = []int{start.Day()}
+	}
+
+	year, month, _ := start.Date()
+	hour, min, sec := start.Clock()
+	months := (after.Year()-year)*12 + int(after.Month()-month)
+	k := months / r.Interval
+	if k > 0 {
+		k--
+	}
+	for i := 0; i < maxPeriods; i++ {
+		first := time.Date(year, month+time.Month((k+i)*r.Interval), 1, hour, min, sec, 0, start.Location())
+		last := first.AddDate(0, 1, -1).Day()
+
+		candidates := make([]time.Time, 0, len(monthDays))
+		for _, d := range monthDays {
+			if d < 0 {
+				d = last + d + 1
+			}
+			// Несуществующие дни (31 февраля) пропускаются, как в RFC 5545
+			if d < 1 || d > last {
+				continue
+			}
+			candidates = append(candidates, first.AddDate(0, 0, d-1))
+		}
+		sort.Slice(candidates, func(a, b int) bool { return candidates[a].Before(candidates[b]) })
+
+		for _, t := range candidates {
+			if !t.Before(start) && t.After(after) {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
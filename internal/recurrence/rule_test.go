@@ -0,0 +1,198 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "FREQ=DAILY", want: "FREQ=DAILY"},
+		{in: "rrule:freq=weekly;byday=mo,we", want: "FREQ=WEEKLY;BYDAY=MO,WE"},
+		{in: "FREQ=WEEKLY;INTERVAL=1;WKST=MO", want: "FREQ=WEEKLY"},
+		{in: "FREQ=MONTHLY;INTERVAL=2;BYMONTHDAY=1,-1;COUNT=5", want: "FREQ=MONTHLY;INTERVAL=2;BYMONTHDAY=1,-1;COUNT=5"},
+		{in: "FREQ=DAILY;UNTIL=20240131", want: "FREQ=DAILY;UNTIL=20240131T235959Z"},
+		{in: "FREQ=DAILY;UNTIL=2024-01-31", want: "FREQ=DAILY;UNTIL=20240131T235959Z"},
+		{in: "FREQ=DAILY;UNTIL=20240131T100000Z;", want: "FREQ=DAILY;UNTIL=20240131T100000Z"},
+		{in: "", wantErr: true},
+		{in: "INTERVAL=2", wantErr: true},
+		{in: "FREQ=YEARLY", wantErr: true},
+		{in: "FREQ=DAILY;INTERVAL=0", wantErr: true},
+		{in: "FREQ=DAILY;COUNT=-1", wantErr: true},
+		{in: "FREQ=DAILY;COUNT=2;UNTIL=20240131", wantErr: true},
+		{in: "FREQ=DAILY;BYDAY=MO", wantErr: true},
+		{in: "FREQ=WEEKLY;BYDAY=XX", wantErr: true},
+		{in: "FREQ=WEEKLY;BYMONTHDAY=1", wantErr: true},
+		{in: "FREQ=MONTHLY;BYMONTHDAY=32", wantErr: true},
+		{in: "FREQ=MONTHLY;BYMONTHDAY=0", wantErr: true},
+		{in: "FREQ=DAILY;UNTIL=tomorrow", wantErr: true},
+		{in: "FREQ=DAILY;BYHOUR=9", wantErr: true},
+		{in: "FREQ", wantErr: true},
+	}
+	for _, tt := range tests {
+		rule, err := Parse(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q) = %q, want error", tt.in, rule)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		if got := rule.String(); got != tt.want {
+			t.Errorf("Parse(%q).String() = %q, want %q", tt.in, got, tt.want)
+		}
+		// каноничная форма разбирается в то же правило
+		again, err := Parse(rule.String())
+		if err != nil || again.String() != rule.String() {
+			t.Errorf("round trip of %q: %v, %v", rule, again, err)
+		}
+	}
+}
+
+func date(day string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", day)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  string
+		start string
+		want  []string
+	}{
+		{
+			name:  "daily",
+			rule:  "FREQ=DAILY",
+			start: "2024-01-01 09:00",
+			want:  []string{"2024-01-01 09:00", "2024-01-02 09:00", "2024-01-03 09:00"},
+		},
+		{
+			name:  "every third day",
+			rule:  "FREQ=DAILY;INTERVAL=3",
+			start: "2024-01-30 09:00",
+			want:  []string{"2024-01-30 09:00", "2024-02-02 09:00", "2024-02-05 09:00"},
+		},
+		{
+			name:  "weekdays",
+			rule:  "FREQ=WEEKLY;BYDAY=FR,MO,WE",
+			start: "2024-01-01 09:00",
+			want:  []string{"2024-01-01 09:00", "2024-01-03 09:00", "2024-01-05 09:00", "2024-01-08 09:00"},
+		},
+		{
+			name:  "every other week",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH",
+			start: "2024-01-01 09:00",
+			want:  []string{"2024-01-02 09:00", "2024-01-04 09:00", "2024-01-16 09:00", "2024-01-18 09:00"},
+		},
+		{
+			name:  "weekly on start day",
+			rule:  "FREQ=WEEKLY",
+			start: "2024-01-03 18:30",
+			want:  []string{"2024-01-03 18:30", "2024-01-10 18:30", "2024-01-17 18:30"},
+		},
+		{
+			name:  "day of week before start is skipped",
+			rule:  "FREQ=WEEKLY;BYDAY=MO,FR",
+			start: "2024-01-03 09:00",
+			want:  []string{"2024-01-05 09:00", "2024-01-08 09:00"},
+		},
+		{
+			name:  "monthly on 31st skips short months",
+			rule:  "FREQ=MONTHLY",
+			start: "2024-01-31 10:00",
+			want:  []string{"2024-01-31 10:00", "2024-03-31 10:00", "2024-05-31 10:00"},
+		},
+		{
+			name:  "last day of month",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=-1",
+			start: "2024-01-31 10:00",
+			want:  []string{"2024-01-31 10:00", "2024-02-29 10:00", "2024-03-31 10:00", "2024-04-30 10:00"},
+		},
+		{
+			name:  "several days a month",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=15,1",
+			start: "2024-01-10 10:00",
+			want:  []string{"2024-01-15 10:00", "2024-02-01 10:00", "2024-02-15 10:00"},
+		},
+		{
+			name:  "quarterly across year",
+			rule:  "FREQ=MONTHLY;INTERVAL=3",
+			start: "2024-11-05 10:00",
+			want:  []string{"2024-11-05 10:00", "2025-02-05 10:00", "2025-05-05 10:00"},
+		},
+		{
+			name:  "until date is inclusive",
+			rule:  "FREQ=DAILY;UNTIL=20240103",
+			start: "2024-01-01 09:00",
+			want:  []string{"2024-01-01 09:00", "2024-01-02 09:00", "2024-01-03 09:00"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			start := date(tt.start)
+			after := start.AddDate(0, 0, -1)
+			var got []string
+			for i := 0; i < len(tt.want)+1; i++ {
+				next, ok := rule.Next(start, after)
+				if !ok {
+					break
+				}
+				got = append(got, next.Format("2006-01-02 15:04"))
+				after = next
+			}
+			if len(got) > len(tt.want) {
+				got = got[:len(tt.want)]
+				if rule.Until != nil {
+					t.Errorf("got an occurrence after UNTIL")
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("occurrences = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("occurrences = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// Далеко от начала серии следующая дата находится без перебора всех прошедших периодов
+func TestNextFarFromStart(t *testing.T) {
+	tests := []struct {
+		rule  string
+		after string
+		want  string
+	}{
+		{rule: "FREQ=DAILY;INTERVAL=7", after: "2030-06-01 12:00", want: "2030-06-03 09:00"},
+		{rule: "FREQ=WEEKLY;BYDAY=SU", after: "2030-06-01 12:00", want: "2030-06-02 09:00"},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=1", after: "2030-06-01 09:00", want: "2030-07-01 09:00"},
+	}
+	start := date("2024-01-01 09:00")
+	for _, tt := range tests {
+		rule, err := Parse(tt.rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		next, ok := rule.Next(start, date(tt.after))
+		if !ok || next.Format("2006-01-02 15:04") != tt.want {
+			t.Errorf("%s: Next after %s = %v, %v, want %s", tt.rule, tt.after, next, ok, tt.want)
+		}
+	}
+}
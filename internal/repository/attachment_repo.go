@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"pet-project/pkg/model"
+)
+
+type PostgresAttachmentRepository struct {
+	DB *sql.DB
+}
+
+type AttachmentRepository interface {
+	Create(ctx context.Context, att *model.Attachment) error
+	GetByID(ctx context.Context, id int) (*model.Attachment, error)
+	ListByTask(ctx context.Context, taskID int) ([]*model.Attachment, error)
+	ListByComment(ctx context.Context, commentID int) ([]*model.Attachment, error)
+	Delete(ctx context.Context, id int) error
+	// CanAccessTask возвращает sql.ErrNoRows, если задачи нет
+	CanAccessTask(ctx context.Context, taskID, userID int) (bool, error)
+}
+
+const attachmentColumns = `id, task_id, comment_id, user_id, file_name, content_type, size, storage_key, created_at`
+
+func scanAttachment(row interface{ Scan(...any) error }) (*model.Attachment, error) {
+	att := &model.Attachment{}
+	var commentID sql.NullInt64
+	err := row.Scan(&att.ID, &att.TaskID, &commentID, &att.UserID, &att.FileName,
+		&att.ContentType, &att.Size, &att.StorageKey, &att.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if commentID.Valid {
+		id := int(commentID.Int64)
+		att.CommentID = &id
+	}
+	return att, nil
+}
+
+func (r *PostgresAttachmentRepository) Create(ctx context.Context, att *model.Attachment) error {
+	query := `INSERT INTO attachments (task_id, comment_id, user_id, file_name, content_type, size, storage_key, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+	return r.DB.QueryRowContext(ctx, query, att.TaskID, att.CommentID, att.UserID, att.FileName,
+		att.ContentType, att.Size, att.StorageKey, att.CreatedAt).Scan(&att.ID)
+}
+
+func (r *PostgresAttachmentRepository) GetByID(ctx context.Context, id int) (*model.Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM attachments WHERE id = $1`
+	return scanAttachment(r.DB.QueryRowContext(ctx, query, id))
+}
+
+func (r *PostgresAttachmentRepository) ListByTask(ctx context.Context, taskID int) ([]*model.Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM attachments WHERE task_id = $1 ORDER BY created_at, id`
+	return r.list(ctx, query, taskID)
+}
+
+func (r *PostgresAttachmentRepository) ListByComment(ctx context.Context, commentID int) ([]*model.Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM attachments WHERE comment_id = $1 ORDER BY created_at, id`
+	return r.list(ctx, query, commentID)
+}
+
+func (r *PostgresAttachmentRepository) list(ctx context.Context, query string, args ...any) ([]*model.Attachment, error) {
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []*model.Attachment{}
+	for rows.Next() {
+		att, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, att)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+func (r *PostgresAttachmentRepository) Delete(ctx context.Context, id int) error {
+	query := `DELETE FROM attachments WHERE id = $1`
+	_, err := r.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresAttachmentRepository) CanAccessTask(ctx context.Context, taskID, userID int) (bool, error) {
+	var ok bool
+	query := `SELECT project_id IN (` + accessibleProjectIDs + `) FROM tasks WHERE id = $2`
+	if err := r.DB.QueryRowContext(ctx, query, userID, taskID).Scan(&ok); err != nil {
+		return false, err
+	}
+	return ok, nil
+}
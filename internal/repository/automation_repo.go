@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"pet-project/internal/taskquery"
+	"pet-project/pkg/model"
+	"time"
+)
+
+type PostgresAutomationRepository struct {
+	DB *sql.DB
+}
+
+type AutomationRepository interface {
+	CreateRule(rule *model.AutomationRule) error
+	UpdateRule(rule *model.AutomationRule) error
+	GetRuleByID(id int) (*model.AutomationRule, error)
+	DeleteRule(id int) error
+	ListByProject(projectID int) ([]*model.AutomationRule, error)
+	ListEnabled(projectID int, event string) ([]*model.AutomationRule, error)
+
+	TaskMatches(taskID int, query string, viewer int) (bool, error)
+
+	LogRun(run *model.AutomationRun) error
+	HasRun(ruleID, eventID int) (bool, error)
+	ListRuns(projectID int, ruleID *int, limit int) ([]*model.AutomationRun, error)
+}
+
+const automationRuleColumns = `id, project_id, name, enabled, trigger_event, trigger_field, trigger_from, trigger_to,
+	condition, actions, created_by, created_at, updated_at`
+
+func scanAutomationRule(row interface{ Scan(...any) error }) (*model.AutomationRule, error) {
+	rule := &model.AutomationRule{}
+	var actions []byte
+	err := row.Scan(&rule.ID, &rule.ProjectID, &rule.Name, &rule.Enabled, &rule.Trigger.Event, &rule.Trigger.Field,
+		&rule.Trigger.From, &rule.Trigger.To, &rule.Condition, &actions, &rule.CreatedBy, &rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(actions, &rule.Actions); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (r *PostgresAutomationRepository) CreateRule(rule *model.AutomationRule) error {
+	actions, err := json.Marshal(rule.Actions)
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO automation_rules (project_id, name, enabled, trigger_event, trigger_field, trigger_from, trigger_to,
+		condition, actions, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id`
+	return r.DB.QueryRow(query, rule.ProjectID, rule.Name, rule.Enabled, rule.Trigger.Event, rule.Trigger.Field,
+		rule.Trigger.From, rule.Trigger.To, rule.Condition, actions, rule.CreatedBy, rule.CreatedAt, rule.UpdatedAt).Scan(&rule.ID)
+}
+
+func (r *PostgresAutomationRepository) UpdateRule(rule *model.AutomationRule) error {
+	actions, err := json.Marshal(rule.Actions)
+	if err != nil {
+		return err
+	}
+	query := `UPDATE automation_rules SET name = $1, enabled = $2, trigger_event = $3, trigger_field = $4, trigger_from = $5,
+		trigger_to = $6, condition = $7, actions = $8, updated_at = $9 WHERE id = $10`
+	_, err = r.DB.Exec(query, rule.Name, rule.Enabled, rule.Trigger.Event, rule.Trigger.Field, rule.Trigger.From,
+		rule.Trigger.To, rule.Condition, actions, rule.UpdatedAt, rule.ID)
+	return err
+}
+
+func (r *PostgresAutomationRepository) GetRuleByID(id int) (*model.AutomationRule, error) {
+	query := `SELECT ` + automationRuleColumns + ` FROM automation_rules WHERE id = $1`
+	return scanAutomationRule(r.DB.QueryRow(query, id))
+}
+
+// DeleteRule удаляет правило вместе с его журналом
+func (r *PostgresAutomationRepository) DeleteRule(id int) error {
+	_, err := r.DB.Exec(`DELETE FROM automation_rules WHERE id = $1`, id)
+	return err
+}
+
+func (r *PostgresAutomationRepository) ListByProject(projectID int) ([]*model.AutomationRule, error) {
+	query := `SELECT ` + automationRuleColumns + ` FROM automation_rules WHERE project_id = $1 ORDER BY id`
+	return r.listRules(query, projectID)
+}
+
+func (r *PostgresAutomationRepository) ListEnabled(projectID int, event string) ([]*model.AutomationRule, error) {
+	query := `SELECT ` + automationRuleColumns + ` FROM automation_rules
+		WHERE project_id = $1 AND trigger_event = $2 AND enabled ORDER BY id`
+	return r.listRules(query, projectID, event)
+}
+
+func (r *PostgresAutomationRepository) listRules(query string, args ...any) ([]*model.AutomationRule, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []*model.AutomationRule{}
+	for rows.Next() {
+		rule, err := scanAutomationRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// TaskMatches проверяет, подходит ли задача под выражение языка запросов
+func (r *PostgresAutomationRepository) TaskMatches(taskID int, query string, viewer int) (bool, error) {
+	expr, err := taskquery.Parse(query)
+	if err != nil {
+		return false, err
+	}
+	cond, args, err := taskquery.Compile(expr, taskquery.Env{UserID: viewer, Now: time.Now()}, []any{taskID})
+	if err != nil {
+		return false, err
+	}
+
+	var matches bool
+	sqlQuery := `SELECT EXISTS (SELECT 1 FROM tasks WHERE tasks.id = $1 AND ` + cond + `)`
+	if err := r.DB.QueryRow(sqlQuery, args...).Scan(&matches); err != nil {
+		return false, err
+	}
+	return matches, nil
+}
+
+func (r *PostgresAutomationRepository) LogRun(run *model.AutomationRun) error {
+	query := `INSERT INTO automation_runs (rule_id, project_id, task_id, event, event_id, status, message, depth, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`
+	return r.DB.QueryRow(query, run.RuleID, run.ProjectID, run.TaskID, run.Event, nullableID(run.EventID), run.Status,
+		run.Message, run.Depth, run.CreatedAt).Scan(&run.ID)
+}
+
+// HasRun сообщает, срабатывало ли правило на это сообщение outbox
+func (r *PostgresAutomationRepository) HasRun(ruleID, eventID int) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM automation_runs WHERE rule_id = $1 AND event_id = $2)`
+	err := r.DB.QueryRow(query, ruleID, eventID).Scan(&exists)
+	return exists, err
+}
+
+// ListRuns возвращает последние записи журнала проекта, ruleID ограничивает одним правилом
+func (r *PostgresAutomationRepository) ListRuns(projectID int, ruleID *int, limit int) ([]*model.AutomationRun, error) {
+	query := `SELECT id, rule_id, project_id, task_id, event, COALESCE(event_id, 0), status, message, depth, created_at FROM automation_runs
+		WHERE project_id = $1 AND ($2::INT IS NULL OR rule_id = $2)
+		ORDER BY created_at DESC, id DESC LIMIT $3`
+	rows, err := r.DB.Query(query, projectID, ruleID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []*model.AutomationRun{}
+	for rows.Next() {
+		run := &model.AutomationRun{}
+		err := rows.Scan(&run.ID, &run.RuleID, &run.ProjectID, &run.TaskID, &run.Event, &run.EventID, &run.Status,
+			&run.Message, &run.Depth, &run.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"database/sql"
+	"pet-project/pkg/model"
+)
+
+type PostgresCalendarRepository struct {
+	DB *sql.DB
+}
+
+type CalendarRepository interface {
+	CreateFeed(feed *model.CalendarFeed) error
+	GetFeedByID(id int) (*model.CalendarFeed, error)
+	// GetFeedByTokenHash возвращает sql.ErrNoRows, если ленты с таким токеном нет
+	GetFeedByTokenHash(tokenHash string) (*model.CalendarFeed, error)
+	ListFeeds(userID int) ([]*model.CalendarFeed, error)
+	DeleteFeed(id int) error
+	CanAccessProject(projectID, userID int) (bool, error)
+}
+
+const calendarFeedColumns = `id, user_id, project_id, token_hash, created_at`
+
+func scanCalendarFeed(row interface{ Scan(...any) error }) (*model.CalendarFeed, error) {
+	feed := &model.CalendarFeed{}
+	err := row.Scan(&feed.ID, &feed.UserID, &feed.ProjectID, &feed.TokenHash, &feed.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return feed, nil
+}
+
+func (r *PostgresCalendarRepository) CreateFeed(feed *model.CalendarFeed) error {
+	query := `INSERT INTO calendar_feeds (user_id, project_id, token_hash, created_at) VALUES ($1, $2, $3, $4) RETURNING id`
+	return r.DB.QueryRow(query, feed.UserID, feed.ProjectID, feed.TokenHash, feed.CreatedAt).Scan(&feed.ID)
+}
+
+func (r *PostgresCalendarRepository) GetFeedByID(id int) (*model.CalendarFeed, error) {
+	query := `SELECT ` + calendarFeedColumns + ` FROM calendar_feeds WHERE id = $1`
+	return scanCalendarFeed(r.DB.QueryRow(query, id))
+}
+
+func (r *PostgresCalendarRepository) GetFeedByTokenHash(tokenHash string) (*model.CalendarFeed, error) {
+	query := `SELECT ` + calendarFeedColumns + ` FROM calendar_feeds WHERE token_hash = $1`
+	return scanCalendarFeed(r.DB.QueryRow(query, tokenHash))
+}
+
+func (r *PostgresCalendarRepository) ListFeeds(userID int) ([]*model.CalendarFeed, error) {
+	query := `SELECT ` + calendarFeedColumns + ` FROM calendar_feeds WHERE user_id = $1 ORDER BY id`
+	rows, err := r.DB.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	feeds := []*model.CalendarFeed{}
+	for rows.Next() {
+		feed, err := scanCalendarFeed(rows)
+		if err != nil {
+			return nil, err
+		}
+		feeds = append(feeds, feed)
+	}
+	return feeds, rows.Err()
+}
+
+func (r *PostgresCalendarRepository) DeleteFeed(id int) error {
+	_, err := r.DB.Exec(`DELETE FROM calendar_feeds WHERE id = $1`, id)
+	return err
+}
+
+func (r *PostgresCalendarRepository) CanAccessProject(projectID, userID int) (bool, error) {
+	var ok bool
+	query := `SELECT $2::INT IN (` + accessibleProjectIDs + `)`
+	if err := r.DB.QueryRow(query, userID, projectID).Scan(&ok); err != nil {
+		return false, err
+	}
+	return ok, nil
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"pet-project/pkg/model"
+
+	"github.com/lib/pq"
+)
+
+type PostgresChatRepository struct {
+	DB *sql.DB
+}
+
+type ChatRepository interface {
+	CreateIntegration(integration *model.ChatIntegration) error
+	UpdateIntegration(integration *model.ChatIntegration) error
+	GetIntegrationByID(id int) (*model.ChatIntegration, error)
+	DeleteIntegration(id int) error
+	ListIntegrations(projectID int) ([]*model.ChatIntegration, error)
+	// ListSubscribed возвращает активные интеграции проекта с webhook, подписанные на событие
+	ListSubscribed(ctx context.Context, projectID int, event string) ([]*model.ChatIntegration, error)
+
+	LinkAccount(ctx context.Context, account *model.ChatAccount) error
+	// GetAccount возвращает sql.ErrNoRows, если аккаунт мессенджера не привязан
+	GetAccount(ctx context.Context, integrationID int, chatUserID string) (*model.ChatAccount, error)
+
+	// IsSent и MarkSent отсеивают повторную отправку события в канал при повторной доставке из outbox
+	IsSent(ctx context.Context, integrationID, eventID int) (bool, error)
+	MarkSent(ctx context.Context, integrationID, eventID int) error
+
+	UserName(ctx context.Context, userID int) (string, error)
+}
+
+const chatIntegrationColumns = `id, project_id, provider, secret, webhook_url, events, active, created_by, created_at, updated_at`
+
+func scanChatIntegration(row interface{ Scan(...any) error }) (*model.ChatIntegration, error) {
+	integration := &model.ChatIntegration{}
+	err := row.Scan(&integration.ID, &integration.ProjectID, &integration.Provider, &integration.Secret,
+		&integration.WebhookURL, pq.Array(&integration.Events), &integration.Active, &integration.CreatedBy,
+		&integration.CreatedAt, &integration.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return integration, nil
+}
+
+func (r *PostgresChatRepository) CreateIntegration(integration *model.ChatIntegration) error {
+	query := `INSERT INTO chat_integrations (project_id, provider, secret, webhook_url, events, active, created_by,
+		created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`
+	return r.DB.QueryRow(query, integration.ProjectID, integration.Provider, integration.Secret, integration.WebhookURL,
+		pq.Array(integration.Events), integration.Active, integration.CreatedBy, integration.CreatedAt,
+		integration.UpdatedAt).Scan(&integration.ID)
+}
+
+func (r *PostgresChatRepository) UpdateIntegration(integration *model.ChatIntegration) error {
+	query := `UPDATE chat_integrations SET provider = $1, secret = $2, webhook_url = $3, events = $4, active = $5,
+		updated_at = $6 WHERE id = $7`
+	_, err := r.DB.Exec(query, integration.Provider, integration.Secret, integration.WebhookURL,
+		pq.Array(integration.Events), integration.Active, integration.UpdatedAt, integration.ID)
+	return err
+}
+
+func (r *PostgresChatRepository) GetIntegrationByID(id int) (*model.ChatIntegration, error) {
+	query := `SELECT ` + chatIntegrationColumns + ` FROM chat_integrations WHERE id = $1`
+	return scanChatIntegration(r.DB.QueryRow(query, id))
+}
+
+// DeleteIntegration удаляет интеграцию вместе с привязанными аккаунтами
+func (r *PostgresChatRepository) DeleteIntegration(id int) error {
+	_, err := r.DB.Exec(`DELETE FROM chat_integrations WHERE id = $1`, id)
+	return err
+}
+
+func (r *PostgresChatRepository) ListIntegrations(projectID int) ([]*model.ChatIntegration, error) {
+	query := `SELECT ` + chatIntegrationColumns + ` FROM chat_integrations WHERE project_id = $1 ORDER BY id`
+	return r.listIntegrations(context.Background(), query, projectID)
+}
+
+func (r *PostgresChatRepository) ListSubscribed(ctx context.Context, projectID int, event string) ([]*model.ChatIntegration, error) {
+	query := `SELECT ` + chatIntegrationColumns + ` FROM chat_integrations
+		WHERE project_id = $1 AND active AND webhook_url <> '' AND $2 = ANY(events) ORDER BY id`
+	return r.listIntegrations(ctx, query, projectID, event)
+}
+
+func (r *PostgresChatRepository) listIntegrations(ctx context.Context, query string, args ...any) ([]*model.ChatIntegration, error) {
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	integrations := []*model.ChatIntegration{}
+	for rows.Next() {
+		integration, err := scanChatIntegration(rows)
+		if err != nil {
+			return nil, err
+		}
+		integrations = append(integrations, integration)
+	}
+	return integrations, rows.Err()
+}
+
+// LinkAccount привязывает аккаунт мессенджера; повторная привязка переносит его на другого пользователя
+func (r *PostgresChatRepository) LinkAccount(ctx context.Context, account *model.ChatAccount) error {
+	query := `INSERT INTO chat_accounts (integration_id, chat_user_id, chat_user_name, user_id, linked_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (integration_id, chat_user_id) DO UPDATE SET chat_user_name = EXCLUDED.chat_user_name,
+			user_id = EXCLUDED.user_id, linked_at = EXCLUDED.linked_at`
+	_, err := r.DB.ExecContext(ctx, query, account.IntegrationID, account.ChatUserID, account.ChatUserName,
+		account.UserID, account.LinkedAt)
+	return err
+}
+
+func (r *PostgresChatRepository) GetAccount(ctx context.Context, integrationID int, chatUserID string) (*model.ChatAccount, error) {
+	account := &model.ChatAccount{}
+	query := `SELECT integration_id, chat_user_id, chat_user_name, user_id, linked_at FROM chat_accounts
+		WHERE integration_id = $1 AND chat_user_id = $2`
+	err := r.DB.QueryRowContext(ctx, query, integrationID, chatUserID).Scan(&account.IntegrationID, &account.ChatUserID,
+		&account.ChatUserName, &account.UserID, &account.LinkedAt)
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (r *PostgresChatRepository) IsSent(ctx context.Context, integrationID, eventID int) (bool, error) {
+	var sent bool
+	query := `SELECT EXISTS (SELECT 1 FROM chat_messages WHERE integration_id = $1 AND event_id = $2)`
+	err := r.DB.QueryRowContext(ctx, query, integrationID, eventID).Scan(&sent)
+	return sent, err
+}
+
+func (r *PostgresChatRepository) MarkSent(ctx context.Context, integrationID, eventID int) error {
+	query := `INSERT INTO chat_messages (integration_id, event_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	_, err := r.DB.ExecContext(ctx, query, integrationID, eventID)
+	return err
+}
+
+func (r *PostgresChatRepository) UserName(ctx context.Context, userID int) (string, error) {
+	var name string
+	err := r.DB.QueryRowContext(ctx, `SELECT name FROM users WHERE id = $1`, userID).Scan(&name)
+	return name, err
+}
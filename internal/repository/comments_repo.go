@@ -3,6 +3,7 @@ package repository
 import (
 	"database/sql"
 	"pet-project/pkg/model"
+	"time"
 )
 
 type PostgresCommentsRepository struct {
@@ -10,30 +11,89 @@ type PostgresCommentsRepository struct {
 }
 
 type CommentsRepository interface {
-	AddComment(com *model.Comments) error
-	DeleteComment(com_id int) error
+	AddComment(com *model.Comments, events ...*model.ProjectEvent) error
+	GetCommentByID(com_id int) (*model.Comments, error)
+	DeleteComment(com_id int, user_id int) error
 	GetCommentsByTask(task_id int) ([]*model.Comments, error)
 	GetCommentsByUser(user_id int) ([]*model.Comments, error)
-	UpdateCommentText(com_id int, new_text string) error
+	UpdateCommentText(com_id int, new_text string, editor_id int) error
+	GetCommentRevisions(com_id int) ([]*model.CommentRevision, error)
 }
 
-func (r *PostgresCommentsRepository) AddComment(com *model.Comments) error {
-	query := `INSERT INTO comments (task_id, user_id, text, created_at)
+// AddComment сохраняет комментарий с первой ревизией и пишет events в outbox, проставив им ID комментария
+func (r *PostgresCommentsRepository) AddComment(com *model.Comments, events ...*model.ProjectEvent) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO comments (task_id, user_id, text, created_at, updated_at)
 				VALUES ($1, $2, $3, $4, $5) RETURNING id`
-	return r.DB.QueryRow(query, com.TaskID, com.UserID, com.Text, com.CreatedAt).Scan(&com.ID)
+	err = tx.QueryRow(query, com.TaskID, com.UserID, com.Text, com.CreatedAt, com.UpdatedAt).Scan(&com.ID)
+	if err != nil {
+		return err
+	}
+
+	// Первая ревизия — исходный текст комментария
+	revQuery := `INSERT INTO comment_revisions (comment_id, text, edited_by, edited_at) VALUES ($1, $2, $3, $4)`
+	_, err = tx.Exec(revQuery, com.ID, com.Text, com.UserID, com.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		if event == nil {
+			continue
+		}
+		event.TaskID = com.TaskID
+		event.CommentID = com.ID
+		if event.ProjectID == 0 {
+			if err := tx.QueryRow(`SELECT project_id FROM tasks WHERE id = $1`, com.TaskID).Scan(&event.ProjectID); err != nil {
+				return err
+			}
+		}
+	}
+	if err := writeEvents(tx, events); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresCommentsRepository) GetCommentByID(com_id int) (*model.Comments, error) {
+	comment := &model.Comments{}
+	query := `SELECT id, task_id, user_id, text, edited, created_at, updated_at, deleted_at FROM comments WHERE id = $1`
+	err := r.DB.QueryRow(query, com_id).Scan(&comment.ID, &comment.TaskID, &comment.UserID, &comment.Text,
+		&comment.Edited, &comment.CreatedAt, &comment.UpdatedAt, &comment.DeletedAt)
+	if err != nil {
+		return nil, err
+	}
+	comment.Deleted = comment.DeletedAt != nil
+	return comment, nil
 }
 
-func (r *PostgresCommentsRepository) DeleteComment(com_id int) error {
-	query := `DELETE FROM comments WHERE id = $1`
-	_, err := r.DB.Exec(query, com_id)
+func (r *PostgresCommentsRepository) DeleteComment(com_id int, user_id int) error {
+	query := `UPDATE comments SET deleted_at = $1, deleted_by = $2 WHERE id = $3 AND deleted_at IS NULL`
+	res, err := r.DB.Exec(query, time.Now(), user_id, com_id)
 	if err != nil {
 		return err
 	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
 	return nil
 }
 
+// GetCommentsByTask возвращает всю ветку обсуждения, включая удалённые комментарии
+// (без текста), чтобы на их месте можно было показать заглушку.
 func (r *PostgresCommentsRepository) GetCommentsByTask(task_id int) ([]*model.Comments, error) {
-	query := `SELECT task_id, text, created_at, user_id, id FROM comments WHERE task_id = $1`
+	query := `SELECT task_id, CASE WHEN deleted_at IS NULL THEN text ELSE '' END, created_at, updated_at,
+				user_id, id, edited, deleted_at
+				FROM comments WHERE task_id = $1 ORDER BY created_at, id`
 	rows, err := r.DB.Query(query, task_id)
 	if err != nil {
 		return nil, err
@@ -43,10 +103,12 @@ func (r *PostgresCommentsRepository) GetCommentsByTask(task_id int) ([]*model.Co
 
 	for rows.Next() {
 		var comment model.Comments
-		err := rows.Scan(&comment.TaskID, &comment.Text, &comment.CreatedAt, &comment.UserID, &comment.ID)
+		err := rows.Scan(&comment.TaskID, &comment.Text, &comment.CreatedAt, &comment.UpdatedAt,
+			&comment.UserID, &comment.ID, &comment.Edited, &comment.DeletedAt)
 		if err != nil {
 			return nil, err
 		}
+		comment.Deleted = comment.DeletedAt != nil
 		comments = append(comments, &comment)
 	}
 	if err = rows.Err(); err != nil {
@@ -56,7 +118,8 @@ func (r *PostgresCommentsRepository) GetCommentsByTask(task_id int) ([]*model.Co
 }
 
 func (r *PostgresCommentsRepository) GetCommentsByUser(user_id int) ([]*model.Comments, error) {
-	query := `SELECT id, text, created_at, task_id, user_id FROM comments WHERE user_id = $1`
+	query := `SELECT id, text, created_at, updated_at, task_id, user_id, edited FROM comments
+				WHERE user_id = $1 AND deleted_at IS NULL`
 	rows, err := r.DB.Query(query, user_id)
 	if err != nil {
 		return nil, err
@@ -67,7 +130,8 @@ func (r *PostgresCommentsRepository) GetCommentsByUser(user_id int) ([]*model.Co
 
 	for rows.Next() {
 		var comment model.Comments
-		if err := rows.Scan(&comment.ID, &comment.Text, &comment.CreatedAt, &comment.TaskID, &comment.UserID); err != nil {
+		if err := rows.Scan(&comment.ID, &comment.Text, &comment.CreatedAt, &comment.UpdatedAt,
+			&comment.TaskID, &comment.UserID, &comment.Edited); err != nil {
 			return nil, err
 		}
 		comments = append(comments, &comment)
@@ -78,12 +142,63 @@ func (r *PostgresCommentsRepository) GetCommentsByUser(user_id int) ([]*model.Co
 	return comments, nil
 }
 
-func (r *PostgresCommentsRepository) UpdateCommentText(com_id int, new_text string) error {
-	comment := &model.Comments{}
-	query := `UPDATE comments SET text = $1 WHERE id = $2`
-	_, err := r.DB.Exec(query, comment.Text, comment.ID)
+func (r *PostgresCommentsRepository) UpdateCommentText(com_id int, new_text string, editor_id int) error {
+	tx, err := r.DB.Begin()
 	if err != nil {
 		return err
 	}
-	return nil
+	defer tx.Rollback()
+
+	now := time.Now()
+
+	// Комментарии, созданные до появления истории, не имеют исходной ревизии — сохраняем её перед правкой
+	seedQuery := `INSERT INTO comment_revisions (comment_id, text, edited_by, edited_at)
+				SELECT id, text, user_id, created_at FROM comments
+				WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM comment_revisions WHERE comment_id = $1)`
+	if _, err = tx.Exec(seedQuery, com_id); err != nil {
+		return err
+	}
+
+	query := `UPDATE comments SET text = $1, edited = TRUE, updated_at = $2 WHERE id = $3 AND deleted_at IS NULL`
+	res, err := tx.Exec(query, new_text, now, com_id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	revQuery := `INSERT INTO comment_revisions (comment_id, text, edited_by, edited_at) VALUES ($1, $2, $3, $4)`
+	if _, err = tx.Exec(revQuery, com_id, new_text, editor_id, now); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresCommentsRepository) GetCommentRevisions(com_id int) ([]*model.CommentRevision, error) {
+	query := `SELECT id, comment_id, text, edited_by, edited_at FROM comment_revisions
+				WHERE comment_id = $1 ORDER BY edited_at, id`
+	rows, err := r.DB.Query(query, com_id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []*model.CommentRevision{}
+
+	for rows.Next() {
+		var rev model.CommentRevision
+		if err := rows.Scan(&rev.ID, &rev.CommentID, &rev.Text, &rev.EditedBy, &rev.EditedAt); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, &rev)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return revisions, nil
 }
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"database/sql"
+	"pet-project/pkg/model"
+
+	"github.com/lib/pq"
+)
+
+type PostgresCustomFieldRepository struct {
+	DB *sql.DB
+}
+
+type CustomFieldRepository interface {
+	CreateField(field *model.CustomField) error
+	UpdateField(field *model.CustomField) error
+	DeleteField(id int) error
+	GetFieldByID(id int) (*model.CustomField, error)
+	ListByProject(projectID int) ([]*model.CustomField, error)
+	ListValuesByTasks(taskIDs []int) (map[int][]*model.CustomFieldValue, error)
+}
+
+func (r *PostgresCustomFieldRepository) CreateField(field *model.CustomField) error {
+	query := `INSERT INTO custom_fields (project_id, name, type, options, required, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	return r.DB.QueryRow(query, field.ProjectID, field.Name, field.Type, pq.Array(field.Options),
+		field.Required, field.CreatedAt).Scan(&field.ID)
+}
+
+func (r *PostgresCustomFieldRepository) UpdateField(field *model.CustomField) error {
+	query := `UPDATE custom_fields SET name = $1, options = $2, required = $3 WHERE id = $4`
+	_, err := r.DB.Exec(query, field.Name, pq.Array(field.Options), field.Required, field.ID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresCustomFieldRepository) DeleteField(id int) error {
+	query := `DELETE FROM custom_fields WHERE id = $1`
+	_, err := r.DB.Exec(query, id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresCustomFieldRepository) GetFieldByID(id int) (*model.CustomField, error) {
+	field := &model.CustomField{}
+	query := `SELECT id, project_id, name, type, options, required, created_at FROM custom_fields WHERE id = $1`
+	err := r.DB.QueryRow(query, id).Scan(&field.ID, &field.ProjectID, &field.Name, &field.Type,
+		pq.Array(&field.Options), &field.Required, &field.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return field, nil
+}
+
+func (r *PostgresCustomFieldRepository) ListByProject(projectID int) ([]*model.CustomField, error) {
+	query := `SELECT id, project_id, name, type, options, required, created_at FROM custom_fields
+				WHERE project_id = $1 ORDER BY id`
+	rows, err := r.DB.Query(query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fields := []*model.CustomField{}
+	for rows.Next() {
+		var field model.CustomField
+		if err := rows.Scan(&field.ID, &field.ProjectID, &field.Name, &field.Type,
+			pq.Array(&field.Options), &field.Required, &field.CreatedAt); err != nil {
+			return nil, err
+		}
+		fields = append(fields, &field)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// writeCustomValues сохраняет значения полей задачи в транзакции tx; значение без данных удаляет запись
+func writeCustomValues(tx *sql.Tx, taskID int, values []*model.CustomFieldValue) error {
+	for _, v := range values {
+		if v.Value() == nil || (v.Type == model.CustomFieldMultiSelect && len(v.Options) == 0) {
+			if _, err := tx.Exec(`DELETE FROM task_custom_values WHERE task_id = $1 AND field_id = $2`, taskID, v.FieldID); err != nil {
+				return err
+			}
+			continue
+		}
+
+		query := `INSERT INTO task_custom_values (task_id, field_id, text_value, number_value, date_value, user_value, options)
+					VALUES ($1, $2, $3, $4, $5, $6, $7)
+					ON CONFLICT (task_id, field_id) DO UPDATE SET
+						text_value = EXCLUDED.text_value, number_value = EXCLUDED.number_value,
+						date_value = EXCLUDED.date_value, user_value = EXCLUDED.user_value, options = EXCLUDED.options`
+		_, err := tx.Exec(query, taskID, v.FieldID, v.Text, v.Number, v.Date, v.UserID, pq.Array(v.Options))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *PostgresCustomFieldRepository) ListValuesByTasks(taskIDs []int) (map[int][]*model.CustomFieldValue, error) {
+	result := make(map[int][]*model.CustomFieldValue, len(taskIDs))
+	if len(taskIDs) == 0 {
+		return result, nil
+	}
+
+	query := `SELECT v.task_id, v.field_id, f.name, f.type, v.text_value, v.number_value, v.date_value, v.user_value, v.options
+				FROM task_custom_values v JOIN custom_fields f ON f.id = v.field_id
+				WHERE v.task_id = ANY($1) ORDER BY f.id`
+	rows, err := r.DB.Query(query, pq.Array(taskIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v model.CustomFieldValue
+		if err := rows.Scan(&v.TaskID, &v.FieldID, &v.Name, &v.Type, &v.Text, &v.Number, &v.Date,
+			&v.UserID, pq.Array(&v.Options)); err != nil {
+			return nil, err
+		}
+		result[v.TaskID] = append(result[v.TaskID], &v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"pet-project/pkg/model"
+)
+
+// ErrDependencyCycle — новая связь замкнула бы цикл блокировок
+var ErrDependencyCycle = errors.New("Dependency would create a cycle")
+
+// dependencyLockSpace — первый ключ advisory-блокировки графа зависимостей, второй — ID проекта
+const dependencyLockSpace = 0x646570 // "dep"
+
+type PostgresDependencyRepository struct {
+	DB *sql.DB
+}
+
+type DependencyRepository interface {
+	// AddDependency добавляет связь задач проекта projectID или возвращает ErrDependencyCycle
+	AddDependency(projectID int, dep *model.TaskDependency) error
+	RemoveDependency(blockerID, blockedID int) error
+	ListBlockers(taskID int) ([]*model.Task, error)
+	CountOpenBlockers(taskID int) (int, error)
+	// CountOpenSubtreeBlockers считает открытые блокировки задачи и её незакрытых подзадач на любой глубине
+	CountOpenSubtreeBlockers(taskID int) (int, error)
+	GetProjectGraph(projectID int) (*model.DependencyGraph, error)
+}
+
+// AddDependency проверяет цикл и вставляет связь в одной транзакции под блокировкой проекта,
+// иначе встречные связи A→B и B→A, добавленные одновременно, прошли бы проверку обе
+func (r *PostgresDependencyRepository) AddDependency(projectID int, dep *model.TaskDependency) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1, $2)`, dependencyLockSpace, projectID); err != nil {
+		return err
+	}
+
+	// Если blocked уже (транзитивно) блокирует blocker, новая связь замкнёт цикл
+	reachable := `WITH RECURSIVE reachable AS (
+					SELECT blocked_id FROM task_dependencies WHERE blocker_id = $1
+					UNION
+					SELECT d.blocked_id FROM task_dependencies d JOIN reachable r ON d.blocker_id = r.blocked_id
+				)
+				SELECT EXISTS (SELECT 1 FROM reachable WHERE blocked_id = $2)`
+	var cycle bool
+	if err := tx.QueryRow(reachable, dep.BlockedID, dep.BlockerID).Scan(&cycle); err != nil {
+		return err
+	}
+	if cycle {
+		return ErrDependencyCycle
+	}
+
+	query := `INSERT INTO task_dependencies (blocker_id, blocked_id, created_at) VALUES ($1, $2, $3)
+				ON CONFLICT (blocker_id, blocked_id) DO NOTHING`
+	if _, err := tx.Exec(query, dep.BlockerID, dep.BlockedID, dep.CreatedAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresDependencyRepository) RemoveDependency(blockerID, blockedID int) error {
+	query := `DELETE FROM task_dependencies WHERE blocker_id = $1 AND blocked_id = $2`
+	res, err := r.DB.Exec(query, blockerID, blockedID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *PostgresDependencyRepository) ListBlockers(taskID int) ([]*model.Task, error) {
+	query := `SELECT ` + prefixedTaskColumns("t") + ` FROM tasks t
+				JOIN task_dependencies d ON d.blocker_id = t.id
+				WHERE d.blocked_id = $1 ORDER BY t.id`
+	rows, err := r.DB.Query(query, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return scanTasks(rows)
+}
+
+func (r *PostgresDependencyRepository) CountOpenBlockers(taskID int) (int, error) {
+	query := `SELECT COUNT(*) FROM task_dependencies d JOIN tasks t ON t.id = d.blocker_id
+				WHERE d.blocked_id = $1 AND t.status <> 'done'`
+	var cnt int
+	if err := r.DB.QueryRow(query, taskID).Scan(&cnt); err != nil {
+		return 0, err
+	}
+	return cnt, nil
+}
+
+func (r *PostgresDependencyRepository) CountOpenSubtreeBlockers(taskID int) (int, error) {
+	query := `WITH RECURSIVE subtree AS (
+					SELECT id FROM tasks WHERE id = $1
+					UNION ALL
+					SELECT t.id FROM tasks t JOIN subtree s ON t.parent_id = s.id WHERE t.status <> 'done'
+				)
+				SELECT COUNT(*) FROM task_dependencies d JOIN tasks t ON t.id = d.blocker_id
+				WHERE d.blocked_id IN (SELECT id FROM subtree) AND t.status <> 'done'`
+	var cnt int
+	if err := r.DB.QueryRow(query, taskID).Scan(&cnt); err != nil {
+		return 0, err
+	}
+	return cnt, nil
+}
+
+func (r *PostgresDependencyRepository) GetProjectGraph(projectID int) (*model.DependencyGraph, error) {
+	graph := &model.DependencyGraph{Nodes: []*model.DependencyNode{}, Edges: []*model.TaskDependency{}}
+
+	nodeRows, err := r.DB.Query(`SELECT id, title, status, priority FROM tasks WHERE project_id = $1 ORDER BY id`, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer nodeRows.Close()
+	for nodeRows.Next() {
+		var node model.DependencyNode
+		if err := nodeRows.Scan(&node.ID, &node.Title, &node.Status, &node.Priority); err != nil {
+			return nil, err
+		}
+		graph.Nodes = append(graph.Nodes, &node)
+	}
+	if err := nodeRows.Err(); err != nil {
+		return nil, err
+	}
+
+	edgeQuery := `SELECT d.blocker_id, d.blocked_id, d.created_at FROM task_dependencies d
+				JOIN tasks t ON t.id = d.blocked_id
+				WHERE t.project_id = $1 ORDER BY d.blocker_id, d.blocked_id`
+	edgeRows, err := r.DB.Query(edgeQuery, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer edgeRows.Close()
+	for edgeRows.Next() {
+		var dep model.TaskDependency
+		if err := edgeRows.Scan(&dep.BlockerID, &dep.BlockedID, &dep.CreatedAt); err != nil {
+			return nil, err
+		}
+		graph.Edges = append(graph.Edges, &dep)
+	}
+	if err := edgeRows.Err(); err != nil {
+		return nil, err
+	}
+	return graph, nil
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"pet-project/pkg/model"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type PostgresEmailRepository struct {
+	DB *sql.DB
+}
+
+type EmailRepository interface {
+	GetPreference(ctx context.Context, userID int) (*model.NotificationPreference, error)
+	SavePreference(ctx context.Context, pref *model.NotificationPreference) error
+
+	// GetRecipient подставляет defaultMode, если пользователь режим не выбирал
+	GetRecipient(ctx context.Context, userID int, defaultMode string) (*model.EmailRecipient, error)
+	ListDigestRecipients(ctx context.Context, defaultMode string) ([]*model.EmailRecipient, error)
+	ListUnsent(ctx context.Context, userID int, limit int) ([]model.Notification, int, error)
+
+	IsEmailed(ctx context.Context, notifID int) (bool, error)
+	MarkEmailed(ctx context.Context, ids []int, at time.Time) error
+	FinishDigest(ctx context.Context, userID int, upTo, at time.Time) error
+}
+
+func (r *PostgresEmailRepository) GetPreference(ctx context.Context, userID int) (*model.NotificationPreference, error) {
+	pref := &model.NotificationPreference{}
+	var mode sql.NullString
+	query := `SELECT user_id, email_mode, last_digest_at, updated_at FROM notification_preferences WHERE user_id = $1`
+	err := r.DB.QueryRowContext(ctx, query, userID).Scan(&pref.UserID, &mode, &pref.LastDigestAt, &pref.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	pref.EmailMode = mode.String
+	return pref, nil
+}
+
+func (r *PostgresEmailRepository) SavePreference(ctx context.Context, pref *model.NotificationPreference) error {
+	query := `INSERT INTO notification_preferences (user_id, email_mode, updated_at) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET email_mode = EXCLUDED.email_mode, updated_at = EXCLUDED.updated_at
+		RETURNING last_digest_at`
+	var mode sql.NullString
+	if pref.EmailMode != "" {
+		mode = sql.NullString{String: pref.EmailMode, Valid: true}
+	}
+	return r.DB.QueryRowContext(ctx, query, pref.UserID, mode, pref.UpdatedAt).Scan(&pref.LastDigestAt)
+}
+
+const recipientColumns = `u.id, u.name, u.email, COALESCE(p.email_mode, $1), p.last_digest_at`
+
+func scanRecipient(row interface{ Scan(...any) error }) (*model.EmailRecipient, error) {
+	rcpt := &model.EmailRecipient{}
+	err := row.Scan(&rcpt.UserID, &rcpt.Name, &rcpt.Email, &rcpt.Mode, &rcpt.LastDigestAt)
+	if err != nil {
+		return nil, err
+	}
+	return rcpt, nil
+}
+
+func (r *PostgresEmailRepository) GetRecipient(ctx context.Context, userID int, defaultMode string) (*model.EmailRecipient, error) {
+	query := `SELECT ` + recipientColumns + ` FROM users u
+		LEFT JOIN notification_preferences p ON p.user_id = u.id
+		WHERE u.id = $2`
+	return scanRecipient(r.DB.QueryRowContext(ctx, query, defaultMode, userID))
+}
+
+// ListDigestRecipients возвращает пользователей со сводками, у которых есть непрочитанные
+// и ещё не отправленные уведомления
+func (r *PostgresEmailRepository) ListDigestRecipients(ctx context.Context, defaultMode string) ([]*model.EmailRecipient, error) {
+	query := `SELECT ` + recipientColumns + ` FROM users u
+		LEFT JOIN notification_preferences p ON p.user_id = u.id
+		WHERE COALESCE(p.email_mode, $1) IN ('hourly', 'daily')
+			AND EXISTS (SELECT 1 FROM notification n WHERE n.user_id = u.id AND NOT n.is_read AND n.emailed_at IS NULL)
+		ORDER BY u.id`
+	rows, err := r.DB.QueryContext(ctx, query, defaultMode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recipients := []*model.EmailRecipient{}
+	for rows.Next() {
+		rcpt, err := scanRecipient(rows)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, rcpt)
+	}
+	return recipients, rows.Err()
+}
+
+// ListUnsent возвращает до limit самых свежих непрочитанных и не отправленных уведомлений
+// и общее их число
+func (r *PostgresEmailRepository) ListUnsent(ctx context.Context, userID int, limit int) ([]model.Notification, int, error) {
+	query := `SELECT id, user_id, type, message, task_id, is_read, created_at, COUNT(*) OVER () FROM notification
+		WHERE user_id = $1 AND NOT is_read AND emailed_at IS NULL
+		ORDER BY created_at DESC, id DESC LIMIT $2`
+	rows, err := r.DB.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	notifications := []model.Notification{}
+	total := 0
+	for rows.Next() {
+		var notif model.Notification
+		err := rows.Scan(&notif.ID, &notif.UserID, &notif.Type, &notif.Message, &notif.TaskID, &notif.IsRead, &notif.CreatedAt, &total)
+		if err != nil {
+			return nil, 0, err
+		}
+		notifications = append(notifications, notif)
+	}
+	return notifications, total, rows.Err()
+}
+
+func (r *PostgresEmailRepository) IsEmailed(ctx context.Context, notifID int) (bool, error) {
+	var emailed bool
+	err := r.DB.QueryRowContext(ctx, `SELECT emailed_at IS NOT NULL FROM notification WHERE id = $1`, notifID).Scan(&emailed)
+	return emailed, err
+}
+
+func (r *PostgresEmailRepository) MarkEmailed(ctx context.Context, ids []int, at time.Time) error {
+	_, err := r.DB.ExecContext(ctx, `UPDATE notification SET emailed_at = $1 WHERE id = ANY($2)`, at, pq.Array(ids))
+	return err
+}
+
+// FinishDigest отмечает отправленными все неотправленные уведомления пользователя до upTo (самого
+// свежего в сводке) — и те, что в неё не поместились, чтобы не копились, — и запоминает время сводки
+func (r *PostgresEmailRepository) FinishDigest(ctx context.Context, userID int, upTo, at time.Time) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE notification SET emailed_at = $1
+		WHERE user_id = $2 AND NOT is_read AND emailed_at IS NULL AND created_at <= $3`
+	if _, err := tx.ExecContext(ctx, query, at, userID, upTo); err != nil {
+		return err
+	}
+	upsert := `INSERT INTO notification_preferences (user_id, last_digest_at, updated_at) VALUES ($1, $2, $2)
+		ON CONFLICT (user_id) DO UPDATE SET last_digest_at = EXCLUDED.last_digest_at`
+	if _, err := tx.ExecContext(ctx, upsert, userID, at); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
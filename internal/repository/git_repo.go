@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"pet-project/pkg/model"
+)
+
+type PostgresGitRepository struct {
+	DB *sql.DB
+}
+
+type GitRepository interface {
+	CreateIntegration(integration *model.GitIntegration) error
+	UpdateIntegration(integration *model.GitIntegration) error
+	GetIntegrationByID(id int) (*model.GitIntegration, error)
+	DeleteIntegration(id int) error
+	ListIntegrations(projectID int) ([]*model.GitIntegration, error)
+
+	// SaveLink создаёт связь или обновляет заголовок и состояние существующей и возвращает
+	// прежнее состояние; пустая строка — связь новая
+	SaveLink(ctx context.Context, link *model.GitLink) (string, error)
+	ListLinks(taskID int) ([]*model.GitLink, error)
+
+	// FindUserID ищет автора коммита среди пользователей по адресу без учёта регистра
+	FindUserID(ctx context.Context, email string) (int, error)
+}
+
+const gitIntegrationColumns = `id, project_id, provider, secret, close_status, review_status, active, created_by, created_at, updated_at`
+
+func scanGitIntegration(row interface{ Scan(...any) error }) (*model.GitIntegration, error) {
+	integration := &model.GitIntegration{}
+	err := row.Scan(&integration.ID, &integration.ProjectID, &integration.Provider, &integration.Secret,
+		&integration.CloseStatus, &integration.ReviewStatus, &integration.Active, &integration.CreatedBy,
+		&integration.CreatedAt, &integration.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return integration, nil
+}
+
+func (r *PostgresGitRepository) CreateIntegration(integration *model.GitIntegration) error {
+	query := `INSERT INTO git_integrations (project_id, provider, secret, close_status, review_status, active, created_by,
+		created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`
+	return r.DB.QueryRow(query, integration.ProjectID, integration.Provider, integration.Secret, integration.CloseStatus,
+		integration.ReviewStatus, integration.Active, integration.CreatedBy, integration.CreatedAt,
+		integration.UpdatedAt).Scan(&integration.ID)
+}
+
+func (r *PostgresGitRepository) UpdateIntegration(integration *model.GitIntegration) error {
+	query := `UPDATE git_integrations SET provider = $1, secret = $2, close_status = $3, review_status = $4, active = $5,
+		updated_at = $6 WHERE id = $7`
+	_, err := r.DB.Exec(query, integration.Provider, integration.Secret, integration.CloseStatus, integration.ReviewStatus,
+		integration.Active, integration.UpdatedAt, integration.ID)
+	return err
+}
+
+func (r *PostgresGitRepository) GetIntegrationByID(id int) (*model.GitIntegration, error) {
+	query := `SELECT ` + gitIntegrationColumns + ` FROM git_integrations WHERE id = $1`
+	return scanGitIntegration(r.DB.QueryRow(query, id))
+}
+
+// DeleteIntegration удаляет интеграцию; связи задач с коммитами остаются без ссылки на неё
+func (r *PostgresGitRepository) DeleteIntegration(id int) error {
+	_, err := r.DB.Exec(`DELETE FROM git_integrations WHERE id = $1`, id)
+	return err
+}
+
+func (r *PostgresGitRepository) ListIntegrations(projectID int) ([]*model.GitIntegration, error) {
+	query := `SELECT ` + gitIntegrationColumns + ` FROM git_integrations WHERE project_id = $1 ORDER BY id`
+	rows, err := r.DB.Query(query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	integrations := []*model.GitIntegration{}
+	for rows.Next() {
+		integration, err := scanGitIntegration(rows)
+		if err != nil {
+			return nil, err
+		}
+		integrations = append(integrations, integration)
+	}
+	return integrations, rows.Err()
+}
+
+// SaveLink — upsert по (task_id, integration_id, kind, ref): один коммит, попавший в несколько веток
+// или присланный повторно, остаётся одной связью. Прежнее состояние читается в том же запросе.
+func (r *PostgresGitRepository) SaveLink(ctx context.Context, link *model.GitLink) (string, error) {
+	query := `WITH old AS (
+			SELECT state FROM git_links WHERE task_id = $1 AND integration_id = $2 AND kind = $3 AND ref = $4
+		)
+		INSERT INTO git_links (task_id, integration_id, kind, ref, title, url, author, state, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		ON CONFLICT (task_id, integration_id, kind, ref) DO UPDATE SET title = EXCLUDED.title, url = EXCLUDED.url,
+			state = EXCLUDED.state, updated_at = EXCLUDED.updated_at
+		RETURNING id, (SELECT state FROM old)`
+	var previous sql.NullString
+	err := r.DB.QueryRowContext(ctx, query, link.TaskID, link.IntegrationID, link.Kind, link.Ref, link.Title, link.URL,
+		link.Author, link.State, link.UpdatedAt).Scan(&link.ID, &previous)
+	if err != nil {
+		return "", err
+	}
+	return previous.String, nil
+}
+
+func (r *PostgresGitRepository) ListLinks(taskID int) ([]*model.GitLink, error) {
+	query := `SELECT id, task_id, COALESCE(integration_id, 0), kind, ref, title, url, author, state, created_at, updated_at
+		FROM git_links WHERE task_id = $1 ORDER BY created_at, id`
+	rows, err := r.DB.Query(query, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []*model.GitLink{}
+	for rows.Next() {
+		link := &model.GitLink{}
+		err := rows.Scan(&link.ID, &link.TaskID, &link.IntegrationID, &link.Kind, &link.Ref, &link.Title, &link.URL,
+			&link.Author, &link.State, &link.CreatedAt, &link.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+func (r *PostgresGitRepository) FindUserID(ctx context.Context, email string) (int, error) {
+	var id int
+	query := `SELECT id FROM users WHERE lower(email) = lower($1) ORDER BY id LIMIT 1`
+	err := r.DB.QueryRowContext(ctx, query, email).Scan(&id)
+	return id, err
+}
@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"pet-project/pkg/model"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+type PostgresImportRepository struct {
+	DB *sql.DB
+}
+
+type ImportRepository interface {
+	CreateJob(job *model.ImportJob) error
+	// GetJob и ListJobs не загружают Payload
+	GetJob(id int) (*model.ImportJob, error)
+	ListJobs(userID int) ([]*model.ImportJob, error)
+	GetPayload(ctx context.Context, id int) ([]byte, error)
+	// UpdateJob сохраняет состояние задания; у завершённого задания исходный файл удаляется
+	UpdateJob(ctx context.Context, job *model.ImportJob) error
+	// ListPending возвращает задания в очереди и прерванные в процессе, старые первыми
+	ListPending(ctx context.Context) ([]*model.ImportJob, error)
+
+	// CreateProject создаёт проект и в той же транзакции привязывает к нему задание job: после сбоя
+	// продолжение импорта не создаст второй проект
+	CreateProject(ctx context.Context, job *model.ImportJob, project *model.Project) error
+	// CreateTask создаёт задачу вместе с записью item о ней в одной транзакции: после сбоя
+	// задача либо есть и учтена, либо её нет
+	CreateTask(ctx context.Context, task *model.Task, values []*model.CustomFieldValue, item *model.ImportItem, events ...*model.ProjectEvent) error
+	// GetItem возвращает sql.ErrNoRows, если задача выгрузки ещё не создана
+	GetItem(ctx context.Context, jobID int, externalID string) (*model.ImportItem, error)
+	SaveItem(ctx context.Context, item *model.ImportItem) error
+
+	// FindUsersByEmail возвращает ID пользователей по адресам в нижнем регистре
+	FindUsersByEmail(ctx context.Context, emails []string) (map[string]int, error)
+}
+
+const importJobColumns = `id, user_id, project_id, source, status, options, total, processed, report, error,
+	created_at, updated_at, finished_at`
+
+func scanImportJob(row interface{ Scan(...any) error }) (*model.ImportJob, error) {
+	job := &model.ImportJob{}
+	var options, report []byte
+	err := row.Scan(&job.ID, &job.UserID, &job.ProjectID, &job.Source, &job.Status, &options, &job.Total,
+		&job.Processed, &report, &job.Error, &job.CreatedAt, &job.UpdatedAt, &job.FinishedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(options, &job.Options); err != nil {
+		return nil, err
+	}
+	if report != nil {
+		job.Report = &model.ImportReport{}
+		if err := json.Unmarshal(report, job.Report); err != nil {
+			return nil, err
+		}
+	}
+	return job, nil
+}
+
+func (r *PostgresImportRepository) CreateJob(job *model.ImportJob) error {
+	options, err := json.Marshal(job.Options)
+	if err != nil {
+		return err
+	}
+	report, err := json.Marshal(job.Report)
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO import_jobs (user_id, project_id, source, status, options, payload, total, processed, report,
+		created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`
+	return r.DB.QueryRow(query, job.UserID, job.ProjectID, job.Source, job.Status, options, job.Payload, job.Total,
+		job.Processed, report, job.CreatedAt, job.UpdatedAt).Scan(&job.ID)
+}
+
+func (r *PostgresImportRepository) GetJob(id int) (*model.ImportJob, error) {
+	query := `SELECT ` + importJobColumns + ` FROM import_jobs WHERE id = $1`
+	return scanImportJob(r.DB.QueryRow(query, id))
+}
+
+func (r *PostgresImportRepository) ListJobs(userID int) ([]*model.ImportJob, error) {
+	query := `SELECT ` + importJobColumns + ` FROM import_jobs WHERE user_id = $1 ORDER BY id DESC`
+	return r.listJobs(context.Background(), query, userID)
+}
+
+func (r *PostgresImportRepository) ListPending(ctx context.Context) ([]*model.ImportJob, error) {
+	query := `SELECT ` + importJobColumns + ` FROM import_jobs WHERE status IN ('queued', 'running') ORDER BY id`
+	return r.listJobs(ctx, query)
+}
+
+func (r *PostgresImportRepository) listJobs(ctx context.Context, query string, args ...any) ([]*model.ImportJob, error) {
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []*model.ImportJob{}
+	for rows.Next() {
+		job, err := scanImportJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+func (r *PostgresImportRepository) GetPayload(ctx context.Context, id int) ([]byte, error) {
+	var payload []byte
+	err := r.DB.QueryRowContext(ctx, `SELECT payload FROM import_jobs WHERE id = $1`, id).Scan(&payload)
+	return payload, err
+}
+
+func (r *PostgresImportRepository) UpdateJob(ctx context.Context, job *model.ImportJob) error {
+	report, err := json.Marshal(job.Report)
+	if err != nil {
+		return err
+	}
+	query := `UPDATE import_jobs SET project_id = $1, status = $2, total = $3, processed = $4, report = $5, error = $6,
+		updated_at = $7, finished_at = $8, payload = CASE WHEN $2 = 'done' THEN NULL ELSE payload END WHERE id = $9`
+	_, err = r.DB.ExecContext(ctx, query, job.ProjectID, job.Status, job.Total, job.Processed, report, job.Error,
+		job.UpdatedAt, job.FinishedAt, job.ID)
+	return err
+}
+
+func (r *PostgresImportRepository) CreateProject(ctx context.Context, job *model.ImportJob, project *model.Project) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, insertProjectQuery, project.Name, project.Description, project.OwnerID, project.CreatedAt, project.UpdatedAt).
+		Scan(&project.ID)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE import_jobs SET project_id = $1 WHERE id = $2`, project.ID, job.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresImportRepository) CreateTask(ctx context.Context, task *model.Task, values []*model.CustomFieldValue, item *model.ImportItem, events ...*model.ProjectEvent) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertTask(tx, task, values, events); err != nil {
+		return err
+	}
+	item.TaskID = task.ID
+	query := `INSERT INTO import_items (job_id, external_id, task_id, comments) VALUES ($1, $2, $3, $4)`
+	if _, err := tx.ExecContext(ctx, query, item.JobID, item.ExternalID, item.TaskID, item.Comments); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresImportRepository) GetItem(ctx context.Context, jobID int, externalID string) (*model.ImportItem, error) {
+	item := &model.ImportItem{}
+	query := `SELECT job_id, external_id, task_id, comments FROM import_items WHERE job_id = $1 AND external_id = $2`
+	err := r.DB.QueryRowContext(ctx, query, jobID, externalID).Scan(&item.JobID, &item.ExternalID, &item.TaskID, &item.Comments)
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (r *PostgresImportRepository) SaveItem(ctx context.Context, item *model.ImportItem) error {
+	query := `INSERT INTO import_items (job_id, external_id, task_id, comments) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (job_id, external_id) DO UPDATE SET task_id = EXCLUDED.task_id, comments = EXCLUDED.comments`
+	_, err := r.DB.ExecContext(ctx, query, item.JobID, item.ExternalID, item.TaskID, item.Comments)
+	return err
+}
+
+func (r *PostgresImportRepository) FindUsersByEmail(ctx context.Context, emails []string) (map[string]int, error) {
+	users := make(map[string]int)
+	if len(emails) == 0 {
+		return users, nil
+	}
+	rows, err := r.DB.QueryContext(ctx, `SELECT id, email FROM users WHERE lower(email) = ANY($1)`, pq.Array(emails))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var email string
+		if err := rows.Scan(&id, &email); err != nil {
+			return nil, err
+		}
+		users[strings.ToLower(email)] = id
+	}
+	return users, rows.Err()
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"pet-project/pkg/model"
+)
+
+type PostgresInboundEmailRepository struct {
+	DB *sql.DB
+}
+
+type InboundEmailRepository interface {
+	// FindUserByEmail сравнивает адреса без учёта регистра: почтовые клиенты его не сохраняют
+	FindUserByEmail(ctx context.Context, email string) (*model.User, error)
+	// CanAccessTask возвращает sql.ErrNoRows, если задачи нет
+	CanAccessTask(ctx context.Context, taskID, userID int) (bool, error)
+	IsProcessed(ctx context.Context, messageID string) (bool, error)
+	MarkProcessed(ctx context.Context, messageID string, commentID int) error
+}
+
+func (r *PostgresInboundEmailRepository) FindUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	user := &model.User{}
+	query := `SELECT id, name, email FROM users WHERE lower(email) = lower($1) ORDER BY id LIMIT 1`
+	err := r.DB.QueryRowContext(ctx, query, email).Scan(&user.ID, &user.Name, &user.Email)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (r *PostgresInboundEmailRepository) CanAccessTask(ctx context.Context, taskID, userID int) (bool, error) {
+	var ok bool
+	query := `SELECT project_id IN (` + accessibleProjectIDs + `) FROM tasks WHERE id = $2`
+	if err := r.DB.QueryRowContext(ctx, query, userID, taskID).Scan(&ok); err != nil {
+		return false, err
+	}
+	return ok, nil
+}
+
+func (r *PostgresInboundEmailRepository) IsProcessed(ctx context.Context, messageID string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM inbound_emails WHERE message_id = $1)`
+	err := r.DB.QueryRowContext(ctx, query, messageID).Scan(&exists)
+	return exists, err
+}
+
+func (r *PostgresInboundEmailRepository) MarkProcessed(ctx context.Context, messageID string, commentID int) error {
+	query := `INSERT INTO inbound_emails (message_id, comment_id) VALUES ($1, $2) ON CONFLICT (message_id) DO NOTHING`
+	_, err := r.DB.ExecContext(ctx, query, messageID, commentID)
+	return err
+}
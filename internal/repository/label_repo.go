@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"database/sql"
+	"pet-project/pkg/model"
+
+	"github.com/lib/pq"
+)
+
+type PostgresLabelRepository struct {
+	DB *sql.DB
+}
+
+type LabelRepository interface {
+	CreateLabel(label *model.Label) error
+	UpdateLabel(label *model.Label) error
+	DeleteLabel(id int) error
+	GetLabelByID(id int) (*model.Label, error)
+	ListByProject(projectID int) ([]*model.Label, error)
+	UsageByProject(projectID int) ([]*model.LabelUsage, error)
+	AddToTask(taskID, labelID int) error
+	RemoveFromTask(taskID, labelID int) error
+	ListByTasks(taskIDs []int) (map[int][]*model.Label, error)
+}
+
+func (r *PostgresLabelRepository) CreateLabel(label *model.Label) error {
+	query := `INSERT INTO labels (project_id, name, color, created_at) VALUES ($1, $2, $3, $4) RETURNING id`
+	return r.DB.QueryRow(query, label.ProjectID, label.Name, label.Color, label.CreatedAt).Scan(&label.ID)
+}
+
+func (r *PostgresLabelRepository) UpdateLabel(label *model.Label) error {
+	query := `UPDATE labels SET name = $1, color = $2 WHERE id = $3`
+	_, err := r.DB.Exec(query, label.Name, label.Color, label.ID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresLabelRepository) DeleteLabel(id int) error {
+	query := `DELETE FROM labels WHERE id = $1`
+	_, err := r.DB.Exec(query, id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresLabelRepository) GetLabelByID(id int) (*model.Label, error) {
+	label := &model.Label{}
+	query := `SELECT id, project_id, name, color, created_at FROM labels WHERE id = $1`
+	err := r.DB.QueryRow(query, id).Scan(&label.ID, &label.ProjectID, &label.Name, &label.Color, &label.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return label, nil
+}
+
+func (r *PostgresLabelRepository) ListByProject(projectID int) ([]*model.Label, error) {
+	query := `SELECT id, project_id, name, color, created_at FROM labels WHERE project_id = $1 ORDER BY name`
+	rows, err := r.DB.Query(query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	labels := []*model.Label{}
+	for rows.Next() {
+		var label model.Label
+		if err := rows.Scan(&label.ID, &label.ProjectID, &label.Name, &label.Color, &label.CreatedAt); err != nil {
+			return nil, err
+		}
+		labels = append(labels, &label)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+func (r *PostgresLabelRepository) UsageByProject(projectID int) ([]*model.LabelUsage, error) {
+	query := `SELECT l.id, l.project_id, l.name, l.color, l.created_at, COUNT(tl.task_id)
+				FROM labels l LEFT JOIN task_labels tl ON tl.label_id = l.id
+				WHERE l.project_id = $1
+				GROUP BY l.id ORDER BY COUNT(tl.task_id) DESC, l.name`
+	rows, err := r.DB.Query(query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usage := []*model.LabelUsage{}
+	for rows.Next() {
+		var u model.LabelUsage
+		if err := rows.Scan(&u.ID, &u.ProjectID, &u.Name, &u.Color, &u.CreatedAt, &u.TaskCount); err != nil {
+			return nil, err
+		}
+		usage = append(usage, &u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+func (r *PostgresLabelRepository) AddToTask(taskID, labelID int) error {
+	query := `INSERT INTO task_labels (task_id, label_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	_, err := r.DB.Exec(query, taskID, labelID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresLabelRepository) RemoveFromTask(taskID, labelID int) error {
+	query := `DELETE FROM task_labels WHERE task_id = $1 AND label_id = $2`
+	_, err := r.DB.Exec(query, taskID, labelID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListByTasks возвращает метки сразу для нескольких задач одним запросом
+func (r *PostgresLabelRepository) ListByTasks(taskIDs []int) (map[int][]*model.Label, error) {
+	result := make(map[int][]*model.Label, len(taskIDs))
+	if len(taskIDs) == 0 {
+		return result, nil
+	}
+
+	query := `SELECT tl.task_id, l.id, l.project_id, l.name, l.color, l.created_at
+				FROM task_labels tl JOIN labels l ON l.id = tl.label_id
+				WHERE tl.task_id = ANY($1) ORDER BY l.name`
+	rows, err := r.DB.Query(query, pq.Array(taskIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskID int
+		var label model.Label
+		if err := rows.Scan(&taskID, &label.ID, &label.ProjectID, &label.Name, &label.Color, &label.CreatedAt); err != nil {
+			return nil, err
+		}
+		result[taskID] = append(result[taskID], &label)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
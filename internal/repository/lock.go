@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log"
+)
+
+// PostgresLocker — advisory-блокировки Postgres, чтобы фоновые задачи на нескольких репликах
+// не выполнялись одновременно. Блокировка сессионная, поэтому держится на выделенном соединении.
+type PostgresLocker struct {
+	DB *sql.DB
+}
+
+type Locker interface {
+	TryLock(ctx context.Context, key int64) (unlock func(), ok bool, err error)
+}
+
+func (l *PostgresLocker) TryLock(ctx context.Context, key int64) (func(), bool, error) {
+	conn, err := l.DB.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var ok bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&ok); err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	if !ok {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, key); err != nil {
+			log.Println("Failed to release advisory lock:", err)
+		}
+		conn.Close()
+	}
+	return unlock, true, nil
+}
@@ -19,13 +19,38 @@ type NotificationRepository interface {
 	CountUnread(ctx context.Context, userID int) (int, error)
 }
 
+// Create сохраняет уведомление и в той же транзакции ставит его в outbox для доставки по WebSocket.
+// Если уведомление с таким Key у пользователя уже есть, ничего не делает и оставляет ID нулевым.
 func (r *PostgresNotificationRepository) Create(ctx context.Context, notif *model.Notification) error {
-	query := `INSERT INTO notification (user_id, type, message, is_read, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id`
-	return r.DB.QueryRowContext(ctx, query, notif.UserID, notif.Type, notif.Message, notif.IsRead, notif.CreatedAt).Scan(&notif.ID)
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO notification (user_id, type, message, task_id, is_read, created_at, dedupe_key) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id, dedupe_key) WHERE dedupe_key IS NOT NULL DO NOTHING
+		RETURNING id`
+	var key sql.NullString
+	if notif.Key != "" {
+		key = sql.NullString{String: notif.Key, Valid: true}
+	}
+	err = tx.QueryRowContext(ctx, query, notif.UserID, notif.Type, notif.Message, notif.TaskID, notif.IsRead, notif.CreatedAt, key).Scan(&notif.ID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := writeOutbox(tx, model.TopicNotification, notif); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *PostgresNotificationRepository) GetByUserID(ctx context.Context, userID int, limit, offset int) ([]model.Notification, error) {
-	query := `SELECT id, user_id, type, message, is_read, created_at FROM notification WHERE user_id = $1 LIMIT $2 OFFSET $3`
+	query := `SELECT id, user_id, type, message, task_id, is_read, created_at FROM notification WHERE user_id = $1 LIMIT $2 OFFSET $3`
 	rows, err := r.DB.QueryContext(ctx, query, userID, limit, offset)
 	if err != nil {
 		return nil, err
@@ -41,6 +66,7 @@ func (r *PostgresNotificationRepository) GetByUserID(ctx context.Context, userID
 			&notif.UserID,
 			&notif.Type,
 			&notif.Message,
+			&notif.TaskID,
 			&notif.IsRead,
 			&notif.CreatedAt,
 		)
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"pet-project/pkg/model"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type PostgresOutboxRepository struct {
+	DB *sql.DB
+}
+
+type OutboxRepository interface {
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.OutboxMessage, error)
+	ListConsumed(ctx context.Context, ids []int) (map[int][]string, error)
+	MarkConsumed(ctx context.Context, id int, consumer string) error
+	Finish(ctx context.Context, msg *model.OutboxMessage) error
+	DeletePublished(ctx context.Context, before time.Time) (int64, error)
+}
+
+// writeOutbox записывает сообщение в транзакции tx: подписчики получат его, только если
+// транзакция с изменением зафиксируется
+func writeOutbox(tx *sql.Tx, topic string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	query := `INSERT INTO outbox (topic, payload, status, next_attempt_at, created_at) VALUES ($1, $2, $3, $4, $4)`
+	_, err = tx.Exec(query, topic, string(body), model.OutboxPending, now)
+	return err
+}
+
+// writeEvents записывает события проекта в outbox; nil-события пропускаются
+func writeEvents(tx *sql.Tx, events []*model.ProjectEvent) error {
+	for _, event := range events {
+		if event == nil {
+			continue
+		}
+		if err := writeOutbox(tx, model.TopicProjectEvent, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const outboxColumns = `id, topic, payload, status, attempts, next_attempt_at, last_error, created_at, published_at`
+
+// ClaimDue забирает наступившие сообщения по порядку записи и сдвигает их next_attempt_at на leaseUntil,
+// чтобы другие реплики их пропустили. Если диспетчер упадёт, сообщения вернутся в работу после аренды.
+func (r *PostgresOutboxRepository) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.OutboxMessage, error) {
+	query := `UPDATE outbox SET next_attempt_at = $2, attempts = attempts + 1
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + outboxColumns
+	rows, err := r.DB.QueryContext(ctx, query, now, leaseUntil, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []*model.OutboxMessage{}
+	for rows.Next() {
+		msg := &model.OutboxMessage{}
+		err := rows.Scan(&msg.ID, &msg.Topic, &msg.Payload, &msg.Status, &msg.Attempts, &msg.NextAttemptAt,
+			&msg.LastError, &msg.CreatedAt, &msg.PublishedAt)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// UPDATE ... RETURNING не гарантирует порядок
+	sort.Slice(messages, func(i, j int) bool { return messages[i].ID < messages[j].ID })
+	return messages, nil
+}
+
+// ListConsumed возвращает подписчиков, уже обработавших каждое из сообщений
+func (r *PostgresOutboxRepository) ListConsumed(ctx context.Context, ids []int) (map[int][]string, error) {
+	query := `SELECT message_id, consumer FROM outbox_consumed WHERE message_id = ANY($1)`
+	rows, err := r.DB.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	consumed := map[int][]string{}
+	for rows.Next() {
+		var id int
+		var consumer string
+		if err := rows.Scan(&id, &consumer); err != nil {
+			return nil, err
+		}
+		consumed[id] = append(consumed[id], consumer)
+	}
+	return consumed, rows.Err()
+}
+
+func (r *PostgresOutboxRepository) MarkConsumed(ctx context.Context, id int, consumer string) error {
+	query := `INSERT INTO outbox_consumed (message_id, consumer, consumed_at) VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING`
+	_, err := r.DB.ExecContext(ctx, query, id, consumer, time.Now())
+	return err
+}
+
+// Finish сохраняет итог обработки: опубликовано, повтор в next_attempt_at или отказ
+func (r *PostgresOutboxRepository) Finish(ctx context.Context, msg *model.OutboxMessage) error {
+	query := `UPDATE outbox SET status = $1, next_attempt_at = $2, last_error = $3, published_at = $4 WHERE id = $5`
+	_, err := r.DB.ExecContext(ctx, query, msg.Status, msg.NextAttemptAt, msg.LastError, msg.PublishedAt, msg.ID)
+	return err
+}
+
+// DeletePublished удаляет опубликованные сообщения старше before вместе с отметками подписчиков
+func (r *PostgresOutboxRepository) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.DB.ExecContext(ctx, `DELETE FROM outbox WHERE status = 'published' AND published_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -14,12 +14,15 @@ type ProjectRepository interface {
 	UpdateProject(project *model.Project) error
 	GetByIDProject(id int) (*model.Project, error)
 	DeleteProject(id int) error
+	// CanAccessProject — владелец или участник задач проекта (исполнитель, наблюдатель)
+	CanAccessProject(projectID, userID int) (bool, error)
 }
 
-func (rp *PostgresProjectRepository) CreateProject(project *model.Project) error {
-	query := `INSERT INTO projects (name, description, owner_id, created_at, updated_at)
+const insertProjectQuery = `INSERT INTO projects (name, description, owner_id, created_at, updated_at)
 	          VALUES ($1, $2, $3, $4, $5) RETURNING id`
-	return rp.DB.QueryRow(query, project.Name, project.Description, project.OwnerID, project.CreatedAt, project.UpdatedAt).
+
+func (rp *PostgresProjectRepository) CreateProject(project *model.Project) error {
+	return rp.DB.QueryRow(insertProjectQuery, project.Name, project.Description, project.OwnerID, project.CreatedAt, project.UpdatedAt).
 		Scan(&project.ID)
 }
 
@@ -51,3 +54,12 @@ func (rp *PostgresProjectRepository) DeleteProject(id int) error {
 	}
 	return nil
 }
+
+func (rp *PostgresProjectRepository) CanAccessProject(projectID, userID int) (bool, error) {
+	var ok bool
+	query := `SELECT $2::INT IN (` + accessibleProjectIDs + `)`
+	if err := rp.DB.QueryRow(query, userID, projectID).Scan(&ok); err != nil {
+		return false, err
+	}
+	return ok, nil
+}
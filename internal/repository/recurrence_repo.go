@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"database/sql"
+	"pet-project/pkg/model"
+	"time"
+)
+
+type PostgresRecurrenceRepository struct {
+	DB *sql.DB
+}
+
+type RecurrenceRepository interface {
+	CreateSeries(rec *model.Recurrence, taskID int) error
+	GetSeries(id int) (*model.Recurrence, error)
+	UpdateSeries(rec *model.Recurrence) error
+	DeleteSeries(id int) error
+	ListDue(now time.Time) ([]int, error)
+	LastOccurrence(seriesID int) (*time.Time, error)
+	CountOpen(seriesID int) (int, error)
+	Materialize(rec *model.Recurrence, expectedNext time.Time, task *model.Task, events ...*model.ProjectEvent) (bool, error)
+	ApplyTemplate(rec *model.Recurrence, from time.Time, updatedAt time.Time) (int64, error)
+}
+
+const recurrenceColumns = `id, project_id, rule, title, description, priority, assigned_to, start_at, next_at,
+	generated, created_by, created_at, updated_at`
+
+func scanRecurrence(row interface{ Scan(...any) error }) (*model.Recurrence, error) {
+	rec := &model.Recurrence{}
+	var assignedTo sql.NullInt64
+	err := row.Scan(&rec.ID, &rec.ProjectID, &rec.Rule, &rec.Title, &rec.Description, &rec.Priority, &assignedTo,
+		&rec.StartAt, &rec.NextAt, &rec.Generated, &rec.CreatedBy, &rec.CreatedAt, &rec.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	rec.AssignedTo = int(assignedTo.Int64)
+	return rec, nil
+}
+
+// CreateSeries создаёт серию и делает задачу taskID её первым вхождением
+func (r *PostgresRecurrenceRepository) CreateSeries(rec *model.Recurrence, taskID int) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO recurrences (project_id, rule, title, description, priority, assigned_to, start_at, next_at,
+		generated, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id`
+	err = tx.QueryRow(query, rec.ProjectID, rec.Rule, rec.Title, rec.Description, rec.Priority, nullableID(rec.AssignedTo),
+		rec.StartAt, rec.NextAt, rec.Generated, rec.CreatedBy, rec.CreatedAt, rec.UpdatedAt).Scan(&rec.ID)
+	if err != nil {
+		return err
+	}
+
+	link := `UPDATE tasks SET series_id = $1, due_date = COALESCE(due_date, $2) WHERE id = $3`
+	if _, err := tx.Exec(link, rec.ID, rec.StartAt, taskID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresRecurrenceRepository) GetSeries(id int) (*model.Recurrence, error) {
+	query := `SELECT ` + recurrenceColumns + ` FROM recurrences WHERE id = $1`
+	return scanRecurrence(r.DB.QueryRow(query, id))
+}
+
+func (r *PostgresRecurrenceRepository) UpdateSeries(rec *model.Recurrence) error {
+	query := `UPDATE recurrences SET rule = $1, title = $2, description = $3, priority = $4, assigned_to = $5,
+		start_at = $6, next_at = $7, updated_at = $8 WHERE id = $9`
+	_, err := r.DB.Exec(query, rec.Rule, rec.Title, rec.Description, rec.Priority, nullableID(rec.AssignedTo),
+		rec.StartAt, rec.NextAt, rec.UpdatedAt, rec.ID)
+	return err
+}
+
+// DeleteSeries останавливает серию; уже созданные вхождения остаются обычными задачами
+func (r *PostgresRecurrenceRepository) DeleteSeries(id int) error {
+	_, err := r.DB.Exec(`DELETE FROM recurrences WHERE id = $1`, id)
+	return err
+}
+
+func (r *PostgresRecurrenceRepository) ListDue(now time.Time) ([]int, error) {
+	rows, err := r.DB.Query(`SELECT id FROM recurrences WHERE next_at <= $1 ORDER BY next_at`, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *PostgresRecurrenceRepository) LastOccurrence(seriesID int) (*time.Time, error) {
+	var last *time.Time
+	err := r.DB.QueryRow(`SELECT MAX(due_date) FROM tasks WHERE series_id = $1`, seriesID).Scan(&last)
+	if err != nil {
+		return nil, err
+	}
+	return last, nil
+}
+
+func (r *PostgresRecurrenceRepository) CountOpen(seriesID int) (int, error) {
+	var count int
+	err := r.DB.QueryRow(`SELECT COUNT(*) FROM tasks WHERE series_id = $1 AND status <> 'done'`, seriesID).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Materialize создаёт очередное вхождение серии. Сдвиг next_at выполняется только если он
+// всё ещё равен expectedNext, поэтому параллельные планировщики не создадут дубликат —
+// проигравший получает false. Метки, исполнители, наблюдатели и пользовательские поля
+// копируются с предыдущего вхождения. События пишутся в outbox в той же транзакции.
+func (r *PostgresRecurrenceRepository) Materialize(rec *model.Recurrence, expectedNext time.Time, task *model.Task, events ...*model.ProjectEvent) (bool, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	advance := `UPDATE recurrences SET next_at = $1, generated = generated + 1, updated_at = $2
+		WHERE id = $3 AND next_at = $4`
+	res, err := tx.Exec(advance, rec.NextAt, task.CreatedAt, rec.ID, expectedNext)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	var prevID sql.NullInt64
+	err = tx.QueryRow(`SELECT id FROM tasks WHERE series_id = $1 ORDER BY due_date DESC NULLS LAST, id DESC LIMIT 1`, rec.ID).
+		Scan(&prevID)
+	if err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+
+	insert := `INSERT INTO tasks (title, description, status, priority, assigned_to, project_id, series_id, created_at, updated_at, due_date, rank)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`
+	err = tx.QueryRow(insert, task.Title, task.Description, task.Status, task.Priority, nullableID(task.AssignedTo),
+		task.ProjectID, rec.ID, task.CreatedAt, task.UpdatedAt, task.DueDate, task.Rank).Scan(&task.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if prevID.Valid {
+		copies := []string{
+			`INSERT INTO task_labels (task_id, label_id) SELECT $1, label_id FROM task_labels WHERE task_id = $2`,
+			`INSERT INTO task_assignees (task_id, user_id) SELECT $1, user_id FROM task_assignees WHERE task_id = $2`,
+			`INSERT INTO task_watchers (task_id, user_id) SELECT $1, user_id FROM task_watchers WHERE task_id = $2`,
+			`INSERT INTO task_custom_values (task_id, field_id, text_value, number_value, date_value, user_value, options)
+				SELECT $1, field_id, text_value, number_value, date_value, user_value, options
+				FROM task_custom_values WHERE task_id = $2`,
+		}
+		for _, query := range copies {
+			if _, err := tx.Exec(query, task.ID, prevID.Int64); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	for _, event := range events {
+		if event != nil {
+			event.ProjectID = task.ProjectID
+			event.TaskID = task.ID
+		}
+	}
+	if err := writeEvents(tx, events); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	rec.Generated++
+	return true, nil
+}
+
+// ApplyTemplate переносит шаблон серии на незакрытые вхождения начиная с даты from
+func (r *PostgresRecurrenceRepository) ApplyTemplate(rec *model.Recurrence, from time.Time, updatedAt time.Time) (int64, error) {
+	query := `UPDATE tasks SET title = $1, description = $2, priority = $3, assigned_to = $4, updated_at = $5
+		WHERE series_id = $6 AND status <> 'done' AND due_date >= $7`
+	res, err := r.DB.Exec(query, rec.Title, rec.Description, rec.Priority, nullableID(rec.AssignedTo), updatedAt, rec.ID, from)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"pet-project/pkg/model"
+	"time"
+)
+
+type PostgresReminderRepository struct {
+	DB *sql.DB
+}
+
+type ReminderRepository interface {
+	ListOpenDueBefore(ctx context.Context, until time.Time) ([]*model.Task, error)
+	Claim(ctx context.Context, taskID int, kind string, dueDate time.Time) (bool, error)
+	Release(ctx context.Context, taskID int, kind string, dueDate time.Time) error
+}
+
+// ListOpenDueBefore возвращает незакрытые задачи со сроком не позже until, включая просроченные
+func (r *PostgresReminderRepository) ListOpenDueBefore(ctx context.Context, until time.Time) ([]*model.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE status <> 'done' AND due_date IS NOT NULL AND due_date <= $1
+		ORDER BY due_date`
+	rows, err := r.DB.QueryContext(ctx, query, until)
+	if err != nil {
+		return nil, err
+	}
+	return scanTasks(rows)
+}
+
+// Claim отмечает напоминание kind для задачи отправленным. Срок входит в ключ, поэтому после
+// переноса срока напоминания срабатывают заново. false — напоминание уже отправлялось.
+func (r *PostgresReminderRepository) Claim(ctx context.Context, taskID int, kind string, dueDate time.Time) (bool, error) {
+	query := `INSERT INTO task_reminders (task_id, kind, due_date, sent_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING`
+	res, err := r.DB.ExecContext(ctx, query, taskID, kind, dueDate, time.Now())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// Release снимает отметку Claim, чтобы неотправленное напоминание ушло при следующей проверке
+func (r *PostgresReminderRepository) Release(ctx context.Context, taskID int, kind string, dueDate time.Time) error {
+	query := `DELETE FROM task_reminders WHERE task_id = $1 AND kind = $2 AND due_date = $3`
+	_, err := r.DB.ExecContext(ctx, query, taskID, kind, dueDate)
+	return err
+}
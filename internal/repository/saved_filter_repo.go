@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"database/sql"
+	"pet-project/pkg/model"
+)
+
+type PostgresSavedFilterRepository struct {
+	DB *sql.DB
+}
+
+type SavedFilterRepository interface {
+	CreateFilter(filter *model.SavedFilter) error
+	UpdateFilter(filter *model.SavedFilter) error
+	GetFilterByID(id int) (*model.SavedFilter, error)
+	DeleteFilter(id int) error
+	ListVisible(userID int, projectID *int) ([]*model.SavedFilter, error)
+	CanAccessProject(projectID, userID int) (bool, error)
+}
+
+const savedFilterColumns = `id, user_id, project_id, name, query, shared, created_at, updated_at`
+
+func scanSavedFilter(row interface{ Scan(...any) error }) (*model.SavedFilter, error) {
+	filter := &model.SavedFilter{}
+	err := row.Scan(&filter.ID, &filter.UserID, &filter.ProjectID, &filter.Name, &filter.Query, &filter.Shared,
+		&filter.CreatedAt, &filter.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+func (r *PostgresSavedFilterRepository) CreateFilter(filter *model.SavedFilter) error {
+	query := `INSERT INTO saved_filters (user_id, project_id, name, query, shared, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	return r.DB.QueryRow(query, filter.UserID, filter.ProjectID, filter.Name, filter.Query, filter.Shared,
+		filter.CreatedAt, filter.UpdatedAt).Scan(&filter.ID)
+}
+
+func (r *PostgresSavedFilterRepository) UpdateFilter(filter *model.SavedFilter) error {
+	query := `UPDATE saved_filters SET name = $1, query = $2, shared = $3, updated_at = $4 WHERE id = $5`
+	_, err := r.DB.Exec(query, filter.Name, filter.Query, filter.Shared, filter.UpdatedAt, filter.ID)
+	return err
+}
+
+func (r *PostgresSavedFilterRepository) GetFilterByID(id int) (*model.SavedFilter, error) {
+	query := `SELECT ` + savedFilterColumns + ` FROM saved_filters WHERE id = $1`
+	return scanSavedFilter(r.DB.QueryRow(query, id))
+}
+
+func (r *PostgresSavedFilterRepository) DeleteFilter(id int) error {
+	_, err := r.DB.Exec(`DELETE FROM saved_filters WHERE id = $1`, id)
+	return err
+}
+
+// ListVisible возвращает свои фильтры пользователя и общие фильтры доступных ему проектов;
+// projectID ограничивает выборку одним проектом
+func (r *PostgresSavedFilterRepository) ListVisible(userID int, projectID *int) ([]*model.SavedFilter, error) {
+	query := `SELECT ` + savedFilterColumns + ` FROM saved_filters
+		WHERE (user_id = $1 OR (shared AND project_id IN (` + accessibleProjectIDs + `)))
+			AND ($2::INT IS NULL OR project_id = $2)
+		ORDER BY name, id`
+	rows, err := r.DB.Query(query, userID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	filters := []*model.SavedFilter{}
+	for rows.Next() {
+		filter, err := scanSavedFilter(rows)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	return filters, rows.Err()
+}
+
+func (r *PostgresSavedFilterRepository) CanAccessProject(projectID, userID int) (bool, error) {
+	var ok bool
+	query := `SELECT $2::INT IN (` + accessibleProjectIDs + `)`
+	if err := r.DB.QueryRow(query, userID, projectID).Scan(&ok); err != nil {
+		return false, err
+	}
+	return ok, nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"pet-project/pkg/model"
+	"strings"
+)
+
+type PostgresSearchRepository struct {
+	DB *sql.DB
+}
+
+type SearchRepository interface {
+	Search(query model.SearchQuery) ([]*model.SearchResult, error)
+}
+
+// Маркеры подсветки: ts_headline не экранирует текст, поэтому сначала выделяем совпадения
+// служебными символами, а в HTML превращаем их уже после экранирования.
+const (
+	HighlightStart = "\x02"
+	HighlightStop  = "\x03"
+)
+
+var headlineOptions = fmt.Sprintf("StartSel=%s, StopSel=%s, MaxWords=30, MinWords=10, MaxFragments=2", HighlightStart, HighlightStop)
+
+// accessibleProjectsCTE — проекты, которыми пользователь владеет или в задачах которых участвует
+const accessibleProjectsCTE = `WITH accessible AS (
+	SELECT id FROM projects WHERE owner_id = $2
+	UNION SELECT project_id FROM tasks WHERE assigned_to = $2
+	UNION SELECT t.project_id FROM task_assignees ta JOIN tasks t ON t.id = ta.task_id WHERE ta.user_id = $2
+	UNION SELECT t.project_id FROM task_watchers tw JOIN tasks t ON t.id = tw.task_id WHERE tw.user_id = $2
+), q AS (
+	SELECT websearch_to_tsquery('simple', $1) AS query
+)`
+
+var searchParts = map[string]string{
+	model.SearchTask: `SELECT 'task' AS type, t.id, t.project_id, NULL::INT AS task_id, t.title,
+		ts_headline('simple', t.title || ' ' || COALESCE(t.description, ''), q.query, $3) AS snippet,
+		ts_rank_cd(t.search_vector, q.query) AS rank
+		FROM tasks t, q
+		WHERE t.search_vector @@ q.query AND t.project_id IN (SELECT id FROM accessible)`,
+	model.SearchComment: `SELECT 'comment', c.id, t.project_id, c.task_id, t.title,
+		ts_headline('simple', c.text, q.query, $3),
+		ts_rank_cd(c.search_vector, q.query)
+		FROM comments c JOIN tasks t ON t.id = c.task_id, q
+		WHERE c.search_vector @@ q.query AND c.deleted_at IS NULL AND t.project_id IN (SELECT id FROM accessible)`,
+	model.SearchProject: `SELECT 'project', p.id, p.id, NULL::INT, p.name,
+		ts_headline('simple', p.name || ' ' || COALESCE(p.description, ''), q.query, $3),
+		ts_rank_cd(p.search_vector, q.query)
+		FROM projects p, q
+		WHERE p.search_vector @@ q.query AND p.id IN (SELECT id FROM accessible)`,
+}
+
+func (r *PostgresSearchRepository) Search(query model.SearchQuery) ([]*model.SearchResult, error) {
+	parts := make([]string, 0, len(query.Types))
+	for _, kind := range query.Types {
+		part, ok := searchParts[kind]
+		if !ok {
+			return nil, fmt.Errorf("unknown search type %q", kind)
+		}
+		parts = append(parts, part)
+	}
+	if len(parts) == 0 {
+		return []*model.SearchResult{}, nil
+	}
+
+	sqlQuery := accessibleProjectsCTE + `
+		SELECT type, id, project_id, task_id, title, snippet, rank FROM (` + strings.Join(parts, "\nUNION ALL\n") + `) found
+		ORDER BY rank DESC, type, id
+		LIMIT $4 OFFSET $5`
+	rows, err := r.DB.Query(sqlQuery, query.Query, query.UserID, headlineOptions, query.Limit, query.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []*model.SearchResult{}
+	for rows.Next() {
+		res := &model.SearchResult{}
+		if err := rows.Scan(&res.Type, &res.ID, &res.ProjectID, &res.TaskID, &res.Title, &res.Snippet, &res.Rank); err != nil {
+			return nil, err
+		}
+		results = append(results, res)
+	}
+	return results, rows.Err()
+}
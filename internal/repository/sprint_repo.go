@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"database/sql"
+	"pet-project/pkg/model"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type PostgresSprintRepository struct {
+	DB *sql.DB
+}
+
+type SprintRepository interface {
+	CreateSprint(sprint *model.Sprint) error
+	UpdateSprint(sprint *model.Sprint) error
+	GetSprintByID(id int) (*model.Sprint, error)
+	ListByProject(projectID int) ([]*model.Sprint, error)
+	DeleteSprint(id int) error
+	StartSprint(id int, startedAt time.Time) (bool, error)
+	CompleteSprint(id int, carryTo *int, completedAt time.Time) (int64, error)
+
+	SetTaskSprint(taskID int, sprintID *int, updatedAt time.Time) error
+	ListTasks(sprintID int) ([]*model.Task, error)
+	ListScopeTasks(sprintID int) ([]*model.Task, error)
+	ListHistory(taskIDs []int) ([]*model.TaskEvent, error)
+}
+
+const sprintColumns = `id, project_id, name, goal, start_date, end_date, status, started_at, completed_at, created_at, updated_at`
+
+func scanSprint(row interface{ Scan(...any) error }) (*model.Sprint, error) {
+	sprint := &model.Sprint{}
+	err := row.Scan(&sprint.ID, &sprint.ProjectID, &sprint.Name, &sprint.Goal, &sprint.StartDate, &sprint.EndDate,
+		&sprint.Status, &sprint.StartedAt, &sprint.CompletedAt, &sprint.CreatedAt, &sprint.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return sprint, nil
+}
+
+func (r *PostgresSprintRepository) CreateSprint(sprint *model.Sprint) error {
+	query := `INSERT INTO sprints (project_id, name, goal, start_date, end_date, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+	return r.DB.QueryRow(query, sprint.ProjectID, sprint.Name, sprint.Goal, sprint.StartDate, sprint.EndDate,
+		sprint.Status, sprint.CreatedAt, sprint.UpdatedAt).Scan(&sprint.ID)
+}
+
+func (r *PostgresSprintRepository) UpdateSprint(sprint *model.Sprint) error {
+	query := `UPDATE sprints SET name = $1, goal = $2, start_date = $3, end_date = $4, updated_at = $5 WHERE id = $6`
+	_, err := r.DB.Exec(query, sprint.Name, sprint.Goal, sprint.StartDate, sprint.EndDate, sprint.UpdatedAt, sprint.ID)
+	return err
+}
+
+func (r *PostgresSprintRepository) GetSprintByID(id int) (*model.Sprint, error) {
+	query := `SELECT ` + sprintColumns + ` FROM sprints WHERE id = $1`
+	return scanSprint(r.DB.QueryRow(query, id))
+}
+
+func (r *PostgresSprintRepository) ListByProject(projectID int) ([]*model.Sprint, error) {
+	query := `SELECT ` + sprintColumns + ` FROM sprints WHERE project_id = $1 ORDER BY start_date, id`
+	rows, err := r.DB.Query(query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sprints := []*model.Sprint{}
+	for rows.Next() {
+		sprint, err := scanSprint(rows)
+		if err != nil {
+			return nil, err
+		}
+		sprints = append(sprints, sprint)
+	}
+	return sprints, rows.Err()
+}
+
+// DeleteSprint удаляет спринт, его задачи возвращаются в бэклог (sprint_id обнуляется внешним ключом)
+func (r *PostgresSprintRepository) DeleteSprint(id int) error {
+	_, err := r.DB.Exec(`DELETE FROM sprints WHERE id = $1`, id)
+	return err
+}
+
+// StartSprint переводит спринт в active. false — в проекте уже есть активный спринт
+// (это гарантирует частичный уникальный индекс) или спринт не в статусе planned.
+func (r *PostgresSprintRepository) StartSprint(id int, startedAt time.Time) (bool, error) {
+	query := `UPDATE sprints SET status = 'active', started_at = $1, updated_at = $1 WHERE id = $2 AND status = 'planned'`
+	res, err := r.DB.Exec(query, startedAt, id)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return false, nil
+		}
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// CompleteSprint закрывает спринт и переносит незакрытые задачи в carryTo (nil — в бэклог).
+// Возвращает число перенесённых задач.
+func (r *PostgresSprintRepository) CompleteSprint(id int, carryTo *int, completedAt time.Time) (int64, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`UPDATE tasks SET sprint_id = $1, updated_at = $2 WHERE sprint_id = $3 AND status <> 'done'`,
+		carryTo, completedAt, id)
+	if err != nil {
+		return 0, err
+	}
+	carried, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	query := `UPDATE sprints SET status = 'completed', completed_at = $1, updated_at = $1 WHERE id = $2`
+	if _, err := tx.Exec(query, completedAt, id); err != nil {
+		return 0, err
+	}
+	return carried, tx.Commit()
+}
+
+func (r *PostgresSprintRepository) SetTaskSprint(taskID int, sprintID *int, updatedAt time.Time) error {
+	_, err := r.DB.Exec(`UPDATE tasks SET sprint_id = $1, updated_at = $2 WHERE id = $3`, sprintID, updatedAt, taskID)
+	return err
+}
+
+func (r *PostgresSprintRepository) ListTasks(sprintID int) ([]*model.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE sprint_id = $1 ORDER BY rank, id`
+	rows, err := r.DB.Query(query, sprintID)
+	if err != nil {
+		return nil, err
+	}
+	return scanTasks(rows)
+}
+
+// ListScopeTasks возвращает задачи, которые сейчас в спринте или когда-либо в нём были
+func (r *PostgresSprintRepository) ListScopeTasks(sprintID int) ([]*model.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE sprint_id = $1
+		OR id IN (SELECT task_id FROM task_history WHERE field = 'sprint_id' AND $2 IN (old_value, new_value))`
+	rows, err := r.DB.Query(query, sprintID, strconv.Itoa(sprintID))
+	if err != nil {
+		return nil, err
+	}
+	return scanTasks(rows)
+}
+
+func (r *PostgresSprintRepository) ListHistory(taskIDs []int) ([]*model.TaskEvent, error) {
+	query := `SELECT id, task_id, field, old_value, new_value, changed_at FROM task_history
+		WHERE task_id = ANY($1) ORDER BY changed_at, id`
+	rows, err := r.DB.Query(query, pq.Array(taskIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*model.TaskEvent{}
+	for rows.Next() {
+		event := &model.TaskEvent{}
+		if err := rows.Scan(&event.ID, &event.TaskID, &event.Field, &event.OldValue, &event.NewValue, &event.ChangedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"database/sql"
+	"pet-project/pkg/model"
+	"time"
+)
+
+type PostgresStatsRepository struct {
+	DB *sql.DB
+}
+
+type StatsRepository interface {
+	ProjectStats(projectID int, weeks int, now time.Time) (*model.ProjectStats, error)
+	UserSummary(userID int, now time.Time) (*model.UserSummary, error)
+}
+
+// closedTasksCTE — закрытые задачи проекта с моментами начала работы и закрытия из task_history.
+// Для задач, закрытых до появления истории, моментом закрытия считается updated_at.
+const closedTasksCTE = `WITH closed AS (
+	SELECT t.id, t.created_at,
+		COALESCE((SELECT MAX(h.changed_at) FROM task_history h
+			WHERE h.task_id = t.id AND h.field = 'status' AND h.new_value = 'done'), t.updated_at) AS done_at,
+		(SELECT MIN(h.changed_at) FROM task_history h
+			WHERE h.task_id = t.id AND h.field = 'status' AND h.new_value = 'in_progress') AS started_at
+	FROM tasks t
+	WHERE t.project_id = $1 AND t.status = 'done'
+)`
+
+func (r *PostgresStatsRepository) ProjectStats(projectID int, weeks int, now time.Time) (*model.ProjectStats, error) {
+	stats := &model.ProjectStats{
+		ProjectID:  projectID,
+		ByStatus:   map[string]int{},
+		ByPriority: map[string]int{},
+		ByAssignee: []*model.AssigneeStats{},
+		Throughput: []*model.WeekThroughput{},
+	}
+
+	// Один проход по задачам: разрезы по статусу, по приоритету и общий итог
+	counts := `SELECT status, priority, GROUPING(status, priority), COUNT(*),
+			COUNT(*) FILTER (WHERE status <> 'done'),
+			COUNT(*) FILTER (WHERE status <> 'done' AND due_date < $2)
+		FROM tasks WHERE project_id = $1
+		GROUP BY GROUPING SETS ((status), (priority), ())`
+	rows, err := r.DB.Query(counts, projectID, now)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var status, priority sql.NullString
+		var grouping, total, open, overdue int
+		if err := rows.Scan(&status, &priority, &grouping, &total, &open, &overdue); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		switch grouping {
+		case 1: // сгруппировано по status
+			stats.ByStatus[status.String] = total
+		case 2: // по priority
+			stats.ByPriority[priority.String] = total
+		case 3:
+			stats.Total, stats.Open, stats.Overdue = total, open, overdue
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	assignees := `SELECT a.user_id,
+			COUNT(*) FILTER (WHERE t.status <> 'done'),
+			COUNT(*) FILTER (WHERE t.status = 'done'),
+			COUNT(*) FILTER (WHERE t.status <> 'done' AND t.due_date < $2)
+		FROM (
+			SELECT id AS task_id, assigned_to AS user_id FROM tasks WHERE project_id = $1 AND assigned_to IS NOT NULL
+			UNION
+			SELECT ta.task_id, ta.user_id FROM task_assignees ta JOIN tasks pt ON pt.id = ta.task_id WHERE pt.project_id = $1
+		) a
+		JOIN tasks t ON t.id = a.task_id
+		GROUP BY a.user_id
+		ORDER BY a.user_id`
+	rows, err = r.DB.Query(assignees, projectID, now)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		item := &model.AssigneeStats{}
+		if err := rows.Scan(&item.UserID, &item.Open, &item.Done, &item.Overdue); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		stats.ByAssignee = append(stats.ByAssignee, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	times := closedTasksCTE + `
+		SELECT AVG(EXTRACT(EPOCH FROM done_at - created_at)) / 3600,
+			AVG(EXTRACT(EPOCH FROM done_at - started_at)) FILTER (WHERE started_at IS NOT NULL AND started_at <= done_at) / 3600
+		FROM closed`
+	if err := r.DB.QueryRow(times, projectID).Scan(&stats.AvgLeadTimeHours, &stats.AvgCycleTimeHours); err != nil {
+		return nil, err
+	}
+
+	// Недели без закрытых задач тоже попадают в ряд — их даёт generate_series
+	throughput := closedTasksCTE + `
+		SELECT w.week, COUNT(c.id)
+		FROM generate_series(date_trunc('week', $2::timestamp) - ($3 - 1) * INTERVAL '1 week',
+			date_trunc('week', $2::timestamp), INTERVAL '1 week') AS w(week)
+		LEFT JOIN closed c ON date_trunc('week', c.done_at) = w.week
+		GROUP BY w.week
+		ORDER BY w.week`
+	rows, err = r.DB.Query(throughput, projectID, now, weeks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var week time.Time
+		item := &model.WeekThroughput{}
+		if err := rows.Scan(&week, &item.Done); err != nil {
+			return nil, err
+		}
+		item.Week = week.Format("2006-01-02")
+		stats.Throughput = append(stats.Throughput, item)
+	}
+	return stats, rows.Err()
+}
+
+// UserSummary собирает проекты, которыми пользователь владеет или в которых он исполнитель
+func (r *PostgresStatsRepository) UserSummary(userID int, now time.Time) (*model.UserSummary, error) {
+	query := `WITH mine AS (
+			SELECT task_id FROM task_assignees WHERE user_id = $1
+			UNION
+			SELECT id FROM tasks WHERE assigned_to = $1
+		), user_projects AS (
+			SELECT id FROM projects WHERE owner_id = $1
+			UNION
+			SELECT t.project_id FROM tasks t JOIN mine m ON m.task_id = t.id
+		)
+		SELECT p.id, p.name, p.owner_id = $1,
+			COUNT(t.id),
+			COUNT(t.id) FILTER (WHERE t.status <> 'done'),
+			COUNT(t.id) FILTER (WHERE t.status = 'done'),
+			COUNT(t.id) FILTER (WHERE t.status <> 'done' AND t.due_date < $2),
+			COUNT(t.id) FILTER (WHERE t.status <> 'done' AND m.task_id IS NOT NULL),
+			COUNT(t.id) FILTER (WHERE t.status <> 'done' AND t.due_date < $2 AND m.task_id IS NOT NULL)
+		FROM projects p
+		JOIN user_projects up ON up.id = p.id
+		LEFT JOIN tasks t ON t.project_id = p.id
+		LEFT JOIN mine m ON m.task_id = t.id
+		GROUP BY p.id, p.name, p.owner_id
+		ORDER BY p.name, p.id`
+	rows, err := r.DB.Query(query, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summary := &model.UserSummary{UserID: userID, Projects: []*model.ProjectSummary{}}
+	for rows.Next() {
+		p := &model.ProjectSummary{}
+		err := rows.Scan(&p.ProjectID, &p.Name, &p.OwnedByMe, &p.Total, &p.Open, &p.Done, &p.Overdue,
+			&p.AssignedToMe, &p.OverdueForMe)
+		if err != nil {
+			return nil, err
+		}
+		summary.Open += p.Open
+		summary.Overdue += p.Overdue
+		summary.AssignedToMe += p.AssignedToMe
+		summary.OverdueForMe += p.OverdueForMe
+		summary.Projects = append(summary.Projects, p)
+	}
+	return summary, rows.Err()
+}
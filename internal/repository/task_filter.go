@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"fmt"
+	"pet-project/internal/taskquery"
+	"pet-project/pkg/model"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// buildTaskFilter превращает TaskFilter в условия WHERE для таблицы tasks.
+// Значения передаются только через плейсхолдеры, нумерация продолжает args.
+func buildTaskFilter(filter model.TaskFilter, args []any) ([]string, []any, error) {
+	conds := []string{}
+
+	if names := normalizeLabelNames(filter.LabelsAny); len(names) > 0 {
+		args = append(args, pq.Array(names))
+		conds = append(conds, fmt.Sprintf(`tasks.id IN (SELECT tl.task_id FROM task_labels tl
+				JOIN labels l ON l.id = tl.label_id WHERE lower(l.name) = ANY($%d))`, len(args)))
+	}
+
+	if names := normalizeLabelNames(filter.LabelsAll); len(names) > 0 {
+		args = append(args, pq.Array(names))
+		conds = append(conds, fmt.Sprintf(`tasks.id IN (SELECT tl.task_id FROM task_labels tl
+				JOIN labels l ON l.id = tl.label_id WHERE lower(l.name) = ANY($%d)
+				GROUP BY tl.task_id HAVING COUNT(DISTINCT lower(l.name)) = %d)`, len(args), len(names)))
+	}
+
+	for _, cond := range filter.CustomFields {
+		var condSQL string
+		condSQL, args = customFieldCondition(cond, args)
+		conds = append(conds, condSQL)
+	}
+
+	if filter.Query != "" {
+		expr, err := taskquery.Parse(filter.Query)
+		if err != nil {
+			return nil, nil, err
+		}
+		var condSQL string
+		condSQL, args, err = taskquery.Compile(expr, taskquery.Env{UserID: filter.Viewer, Now: time.Now()}, args)
+		if err != nil {
+			return nil, nil, err
+		}
+		conds = append(conds, condSQL)
+	}
+
+	return conds, args, nil
+}
+
+var customFieldColumns = map[string]string{
+	model.CustomFieldText:   "v.text_value",
+	model.CustomFieldNumber: "v.number_value",
+	model.CustomFieldDate:   "v.date_value",
+	model.CustomFieldUser:   "v.user_value",
+}
+
+var comparisonOps = map[string]string{
+	"eq":  "=",
+	"gt":  ">",
+	"gte": ">=",
+	"lt":  "<",
+	"lte": "<=",
+}
+
+// customFieldCondition строит условие по значению поля. Тип и оператор уже проверены сервисом,
+// поэтому в SQL подставляются только имена колонок и операторы из белых списков.
+func customFieldCondition(cond model.CustomFieldCondition, args []any) (string, []any) {
+	args = append(args, cond.FieldID)
+	fieldArg := len(args)
+	value := cond.Value
+	if cond.Op == "contains" {
+		if text, ok := value.(string); ok {
+			value = taskquery.EscapeLike(text)
+		}
+	}
+	args = append(args, value)
+	valueArg := len(args)
+
+	var cmp string
+	switch {
+	case cond.Type == model.CustomFieldSelect || cond.Type == model.CustomFieldMultiSelect:
+		cmp = fmt.Sprintf("$%d = ANY(v.options)", valueArg)
+	case cond.Op == "contains":
+		cmp = fmt.Sprintf("v.text_value ILIKE '%%' || $%d || '%%'", valueArg)
+	case cond.Op == "ne":
+		cmp = fmt.Sprintf("%s = $%d", customFieldColumns[cond.Type], valueArg)
+	default:
+		cmp = fmt.Sprintf("%s %s $%d", customFieldColumns[cond.Type], comparisonOps[cond.Op], valueArg)
+	}
+
+	exists := fmt.Sprintf(`EXISTS (SELECT 1 FROM task_custom_values v
+				WHERE v.task_id = tasks.id AND v.field_id = $%d AND %s)`, fieldArg, cmp)
+	if cond.Op == "ne" {
+		return "NOT " + exists, args
+	}
+	return exists, args
+}
+
+var taskSortColumns = map[string]string{
+	"id":         "tasks.id",
+	"title":      "tasks.title",
+	"status":     "tasks.status",
+	"priority":   "tasks.priority",
+	"created_at": "tasks.created_at",
+	"updated_at": "tasks.updated_at",
+	"due_date":   "tasks.due_date",
+	"rank":       "tasks.rank",
+}
+
+// buildTaskOrder возвращает ORDER BY для выборки задач; задачи без значения всегда в конце
+func buildTaskOrder(sort *model.TaskSort, args []any) (string, []any) {
+	if sort == nil {
+		return "tasks.id", args
+	}
+
+	direction := "ASC"
+	if sort.Desc {
+		direction = "DESC"
+	}
+
+	if !sort.Custom {
+		column, ok := taskSortColumns[sort.Field]
+		if !ok {
+			return "tasks.id", args
+		}
+		return fmt.Sprintf("%s %s NULLS LAST, tasks.id", column, direction), args
+	}
+
+	var column string
+	switch sort.Type {
+	case model.CustomFieldSelect:
+		column = "v.options[1]"
+	case model.CustomFieldMultiSelect:
+		column = "array_to_string(v.options, ',')"
+	default:
+		column = customFieldColumns[sort.Type]
+	}
+
+	args = append(args, sort.FieldID)
+	order := fmt.Sprintf(`(SELECT %s FROM task_custom_values v WHERE v.task_id = tasks.id AND v.field_id = $%d) %s NULLS LAST, tasks.id`,
+		column, len(args), direction)
+	return order, args
+}
+
+func normalizeLabelNames(names []string) []string {
+	seen := map[string]bool{}
+	result := []string{}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+	return result
+}
+
+func (rt *PostgresTaskRepository) ListByProjectFiltered(projectID int, filter model.TaskFilter) ([]*model.Task, error) {
+	return rt.listFiltered("tasks.project_id = $1", projectID, filter)
+}
+
+// ListAccessibleFiltered выбирает задачи из всех доступных пользователю проектов
+func (rt *PostgresTaskRepository) ListAccessibleFiltered(userID int, filter model.TaskFilter) ([]*model.Task, error) {
+	return rt.listFiltered(`tasks.project_id IN (`+accessibleProjectIDs+`)`, userID, filter)
+}
+
+// accessibleProjectIDs — проекты пользователя $1: свои и те, в задачах которых он участвует
+const accessibleProjectIDs = `SELECT id FROM projects WHERE owner_id = $1
+		UNION SELECT project_id FROM tasks WHERE assigned_to = $1
+		UNION SELECT t.project_id FROM task_assignees ta JOIN tasks t ON t.id = ta.task_id WHERE ta.user_id = $1
+		UNION SELECT t.project_id FROM task_watchers tw JOIN tasks t ON t.id = tw.task_id WHERE tw.user_id = $1`
+
+// listFiltered дополняет условие scope с единственным параметром $1 условиями фильтра
+func (rt *PostgresTaskRepository) listFiltered(scope string, scopeArg any, filter model.TaskFilter) ([]*model.Task, error) {
+	conds, args, err := buildTaskFilter(filter, []any{scopeArg})
+	if err != nil {
+		return nil, err
+	}
+	conds = append([]string{scope}, conds...)
+
+	order, args := buildTaskOrder(filter.Sort, args)
+
+	query := `SELECT ` + prefixedTaskColumns("tasks") + ` FROM tasks WHERE ` + strings.Join(conds, " AND ") + ` ORDER BY ` + order
+	rows, err := rt.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanTasks(rows)
+}
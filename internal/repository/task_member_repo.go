@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+type PostgresTaskMemberRepository struct {
+	DB *sql.DB
+}
+
+// TaskMemberRepository — исполнители и наблюдатели задачи.
+// Основной исполнитель (tasks.assigned_to) всегда считается одним из исполнителей.
+type TaskMemberRepository interface {
+	AddAssignee(taskID, userID int) error
+	RemoveAssignee(taskID, userID int) error
+	IsAssignee(taskID, userID int) (bool, error)
+	AddWatcher(taskID, userID int) error
+	RemoveWatcher(taskID, userID int) error
+	ListAssigneesByTasks(taskIDs []int) (map[int][]int, error)
+	ListWatchersByTasks(taskIDs []int) (map[int][]int, error)
+}
+
+func (r *PostgresTaskMemberRepository) AddAssignee(taskID, userID int) error {
+	query := `INSERT INTO task_assignees (task_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	_, err := r.DB.Exec(query, taskID, userID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresTaskMemberRepository) RemoveAssignee(taskID, userID int) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM task_assignees WHERE task_id = $1 AND user_id = $2`, taskID, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE tasks SET assigned_to = NULL WHERE id = $1 AND assigned_to = $2`, taskID, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresTaskMemberRepository) IsAssignee(taskID, userID int) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM tasks WHERE id = $1 AND assigned_to = $2)
+				OR EXISTS (SELECT 1 FROM task_assignees WHERE task_id = $1 AND user_id = $2)`
+	var ok bool
+	if err := r.DB.QueryRow(query, taskID, userID).Scan(&ok); err != nil {
+		return false, err
+	}
+	return ok, nil
+}
+
+func (r *PostgresTaskMemberRepository) AddWatcher(taskID, userID int) error {
+	query := `INSERT INTO task_watchers (task_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	_, err := r.DB.Exec(query, taskID, userID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresTaskMemberRepository) RemoveWatcher(taskID, userID int) error {
+	query := `DELETE FROM task_watchers WHERE task_id = $1 AND user_id = $2`
+	_, err := r.DB.Exec(query, taskID, userID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresTaskMemberRepository) ListAssigneesByTasks(taskIDs []int) (map[int][]int, error) {
+	query := `SELECT task_id, user_id FROM task_assignees WHERE task_id = ANY($1)
+				UNION
+				SELECT id, assigned_to FROM tasks WHERE id = ANY($1) AND assigned_to IS NOT NULL
+				ORDER BY 1, 2`
+	return r.listPairs(query, taskIDs)
+}
+
+func (r *PostgresTaskMemberRepository) ListWatchersByTasks(taskIDs []int) (map[int][]int, error) {
+	query := `SELECT task_id, user_id FROM task_watchers WHERE task_id = ANY($1) ORDER BY task_id, user_id`
+	return r.listPairs(query, taskIDs)
+}
+
+func (r *PostgresTaskMemberRepository) listPairs(query string, taskIDs []int) (map[int][]int, error) {
+	result := make(map[int][]int, len(taskIDs))
+	if len(taskIDs) == 0 {
+		return result, nil
+	}
+
+	rows, err := r.DB.Query(query, pq.Array(taskIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskID, userID int
+		if err := rows.Scan(&taskID, &userID); err != nil {
+			return nil, err
+		}
+		result[taskID] = append(result[taskID], userID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
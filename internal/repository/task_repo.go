@@ -2,85 +2,373 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"log"
+	"pet-project/internal/rank"
 	"pet-project/pkg/model"
+	"strings"
+	"time"
 )
 
 type PostgresTaskRepository struct {
 	DB *sql.DB
 }
 
+var (
+	ErrNeighbourNotInColumn  = errors.New("Neighbour task is not in the target column")
+	ErrNeighboursNotAdjacent = errors.New("Neighbour tasks must be adjacent in the target column")
+)
+
 type TaskRepository interface {
-	CreateTask(task *model.Task) error                      ///
-	UpdateTask(task *model.Task) error                      ///
-	GetByIDTask(id int) (*model.Task, error)                ///
-	ListByProjectTask(projectID int) ([]*model.Task, error) ///
-	DeleteTask(id int) error                                ///
-}
-
-func (rt *PostgresTaskRepository) CreateTask(task *model.Task) error {
-	query := `INSERT INTO tasks (title, description, status, priority, assigned_to, project_id, created_at, updated_at, due_date)
-	 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
-	_, err := rt.DB.Exec(query, task.Title, task.Description,
-		task.Status, task.Priority, task.AssignedTo, task.ProjectID, task.CreatedAt, task.UpdatedAt, task.DueDate)
+	CreateTask(task *model.Task, values []*model.CustomFieldValue, events ...*model.ProjectEvent) error ///
+	UpdateTask(task *model.Task, values []*model.CustomFieldValue, events ...*model.ProjectEvent) error ///
+	GetByIDTask(id int) (*model.Task, error)                                                            ///
+	ListByProjectTask(projectID int) ([]*model.Task, error)                                             ///
+	DeleteTask(id int) error                                                                            ///
+
+	ListByProjectFiltered(projectID int, filter model.TaskFilter) ([]*model.Task, error)
+	ListAccessibleFiltered(userID int, filter model.TaskFilter) ([]*model.Task, error)
+
+	ListSubtree(rootID int) ([]*model.Task, error)
+	ListAncestorIDs(id int) ([]int, error)
+	SetParent(id int, parentID *int, updatedAt time.Time) error
+	CloseDescendants(id int, updatedAt time.Time) error
+	SetEstimates(id int, original, remaining *int, updatedAt time.Time) error
+
+	LastRank(projectID int, status string) (string, error)
+	MoveTask(id, projectID int, status string, afterID, beforeID *int, updatedAt time.Time, events ...*model.ProjectEvent) error
+}
+
+const taskColumns = `id, title, description, status, priority, assigned_to, project_id, parent_id, created_at, updated_at, due_date,
+	original_estimate, remaining_estimate, series_id, rank, sprint_id`
+
+// prefixedTaskColumns возвращает taskColumns с алиасом таблицы для запросов с JOIN
+func prefixedTaskColumns(alias string) string {
+	cols := strings.Split(taskColumns, ",")
+	for i, col := range cols {
+		cols[i] = alias + "." + strings.TrimSpace(col)
+	}
+	return strings.Join(cols, ", ")
+}
+
+func scanTask(row interface{ Scan(...any) error }) (*model.Task, error) {
+	task := &model.Task{}
+	var assignedTo sql.NullInt64
+	err := row.Scan(&task.ID, &task.Title, &task.Description, &task.Status, &task.Priority,
+		&assignedTo, &task.ProjectID, &task.ParentID, &task.CreatedAt, &task.UpdatedAt, &task.DueDate,
+		&task.OriginalEstimate, &task.RemainingEstimate, &task.SeriesID, &task.Rank, &task.SprintID)
+	if err != nil {
+		return nil, err
+	}
+	task.AssignedTo = int(assignedTo.Int64)
+	return task, nil
+}
+
+// nullableID превращает нулевой ID в NULL, чтобы не нарушать внешние ключи
+func nullableID(id int) any {
+	if id <= 0 {
+		return nil
+	}
+	return id
+}
+
+func scanTasks(rows *sql.Rows) ([]*model.Task, error) {
+	defer rows.Close()
+	tasks := []*model.Task{}
+
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// CreateTask создаёт задачу и в той же транзакции сохраняет значения её полей и пишет events в outbox
+func (rt *PostgresTaskRepository) CreateTask(task *model.Task, values []*model.CustomFieldValue, events ...*model.ProjectEvent) error {
+	tx, err := rt.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertTask(tx, task, values, events); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertTask добавляет задачу со значениями полей в транзакции tx и пишет events в outbox, проставив им ID задачи
+func insertTask(tx *sql.Tx, task *model.Task, values []*model.CustomFieldValue, events []*model.ProjectEvent) error {
+	query := `INSERT INTO tasks (title, description, status, priority, assigned_to, project_id, parent_id, created_at, updated_at, due_date, rank)
+	 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`
+	err := tx.QueryRow(query, task.Title, task.Description,
+		task.Status, task.Priority, nullableID(task.AssignedTo), task.ProjectID, task.ParentID, task.CreatedAt, task.UpdatedAt, task.DueDate, task.Rank).
+		Scan(&task.ID)
 	if err != nil {
 		log.Println("Failed to create task:", err)
 		return err
 	}
-	return nil
+	if err := writeCustomValues(tx, task.ID, values); err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		if event != nil {
+			event.ProjectID = task.ProjectID
+			event.TaskID = task.ID
+		}
+	}
+	return writeEvents(tx, events)
 }
 
-func (rt *PostgresTaskRepository) UpdateTask(task *model.Task) error {
-	query := `UPDATE tasks SET title = $1, description = $2, status = $3, priority = $4, assigned_to = $5, updated_at = $6, due_date = $7 WHERE id = $8`
-	_, err := rt.DB.Exec(query, task.Title, task.Description,
-		task.Status, task.Priority, task.AssignedTo, task.UpdatedAt, task.DueDate, task.ID)
+// UpdateTask сохраняет задачу вместе со значениями её полей и пишет events в outbox
+func (rt *PostgresTaskRepository) UpdateTask(task *model.Task, values []*model.CustomFieldValue, events ...*model.ProjectEvent) error {
+	tx, err := rt.DB.Begin()
 	if err != nil {
 		return err
 	}
-	return nil
+	defer tx.Rollback()
+
+	query := `UPDATE tasks SET title = $1, description = $2, status = $3, priority = $4, assigned_to = $5, updated_at = $6, due_date = $7, rank = $8
+	 WHERE id = $9`
+	_, err = tx.Exec(query, task.Title, task.Description,
+		task.Status, task.Priority, nullableID(task.AssignedTo), task.UpdatedAt, task.DueDate, task.Rank, task.ID)
+	if err != nil {
+		return err
+	}
+	if err := writeCustomValues(tx, task.ID, values); err != nil {
+		return err
+	}
+	if err := writeEvents(tx, events); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (rt *PostgresTaskRepository) GetByIDTask(id int) (*model.Task, error) {
-	task := &model.Task{}
-	query := `SELECT id, title, description, status, priority, assigned_to, created_at, updated_at, due_date FROM tasks WHERE id = $1`
-	row := rt.DB.QueryRow(query, id)
-	err := row.Scan(&task.ID, &task.Title, &task.Description, &task.Status, &task.Priority, &task.AssignedTo, &task.CreatedAt, &task.UpdatedAt, &task.DueDate)
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE id = $1`
+	return scanTask(rt.DB.QueryRow(query, id))
+}
+
+func (rt *PostgresTaskRepository) ListByProjectTask(projectID int) ([]*model.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE project_id = $1`
+	rows, err := rt.DB.Query(query, projectID)
 	if err != nil {
 		return nil, err
 	}
-	return task, nil
+	return scanTasks(rows)
 }
 
-func (rt *PostgresTaskRepository) ListByProjectTask(projectID int) ([]*model.Task, error) {
-	query := `SELECT id, title, description, status, priority, assigned_to, created_at, updated_at, due_date FROM tasks WHERE project_id = $1`
-	rows, err := rt.DB.Query(query, projectID)
+// DeleteTask удаляет задачу, а её прямые подзадачи переносит к родителю удаляемой задачи
+func (rt *PostgresTaskRepository) DeleteTask(id int) error {
+	tx, err := rt.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	reparent := `UPDATE tasks SET parent_id = (SELECT parent_id FROM tasks WHERE id = $1) WHERE parent_id = $1`
+	if _, err := tx.Exec(reparent, id); err != nil {
+		return err
+	}
+
+	query := `DELETE FROM tasks WHERE id = $1`
+	if _, err := tx.Exec(query, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListSubtree возвращает задачу rootID и всех её потомков
+func (rt *PostgresTaskRepository) ListSubtree(rootID int) ([]*model.Task, error) {
+	query := `WITH RECURSIVE subtree AS (
+					SELECT * FROM tasks WHERE id = $1
+					UNION ALL
+					SELECT t.* FROM tasks t JOIN subtree s ON t.parent_id = s.id
+				)
+				SELECT ` + taskColumns + ` FROM subtree ORDER BY created_at, id`
+	rows, err := rt.DB.Query(query, rootID)
+	if err != nil {
+		return nil, err
+	}
+	return scanTasks(rows)
+}
+
+// ListAncestorIDs возвращает цепочку родителей задачи от ближайшего к корню
+func (rt *PostgresTaskRepository) ListAncestorIDs(id int) ([]int, error) {
+	query := `WITH RECURSIVE ancestors AS (
+					SELECT parent_id, 1 AS depth FROM tasks WHERE id = $1
+					UNION ALL
+					SELECT t.parent_id, a.depth + 1 FROM tasks t JOIN ancestors a ON t.id = a.parent_id
+				)
+				SELECT parent_id FROM ancestors WHERE parent_id IS NOT NULL ORDER BY depth`
+	rows, err := rt.DB.Query(query, id)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	tasks := []*model.Task{}
 
+	ids := []int{}
 	for rows.Next() {
-		var task model.Task
-		err := rows.Scan(&task.ID, &task.Title, &task.Description, &task.Status, &task.Priority,
-			&task.AssignedTo, &task.CreatedAt, &task.UpdatedAt, &task.DueDate)
-		if err != nil {
+		var parentID int
+		if err := rows.Scan(&parentID); err != nil {
 			return nil, err
 		}
-		tasks = append(tasks, &task)
+		ids = append(ids, parentID)
 	}
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
-	return tasks, nil
+	return ids, nil
+}
 
+func (rt *PostgresTaskRepository) SetParent(id int, parentID *int, updatedAt time.Time) error {
+	query := `UPDATE tasks SET parent_id = $1, updated_at = $2 WHERE id = $3`
+	_, err := rt.DB.Exec(query, parentID, updatedAt, id)
+	if err != nil {
+		return err
+	}
+	return nil
 }
 
-func (rt *PostgresTaskRepository) DeleteTask(id int) error {
-	query := `DELETE FROM tasks WHERE id = $1`
-	_, err := rt.DB.Exec(query, id)
+// CloseDescendants переводит все незакрытые подзадачи (на любой глубине) в статус done
+func (rt *PostgresTaskRepository) CloseDescendants(id int, updatedAt time.Time) error {
+	query := `WITH RECURSIVE descendants AS (
+					SELECT id FROM tasks WHERE parent_id = $1
+					UNION ALL
+					SELECT t.id FROM tasks t JOIN descendants d ON t.parent_id = d.id
+				)
+				UPDATE tasks SET status = 'done', updated_at = $2
+				WHERE id IN (SELECT id FROM descendants) AND status <> 'done'`
+	_, err := rt.DB.Exec(query, id, updatedAt)
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+func (rt *PostgresTaskRepository) SetEstimates(id int, original, remaining *int, updatedAt time.Time) error {
+	query := `UPDATE tasks SET original_estimate = $1, remaining_estimate = $2, updated_at = $3 WHERE id = $4`
+	_, err := rt.DB.Exec(query, original, remaining, updatedAt, id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// LastRank возвращает ранг последней карточки в колонке status проекта
+func (rt *PostgresTaskRepository) LastRank(projectID int, status string) (string, error) {
+	var last sql.NullString
+	err := rt.DB.QueryRow(`SELECT MAX(rank) FROM tasks WHERE project_id = $1 AND status = $2`, projectID, status).Scan(&last)
+	if err != nil {
+		return "", err
+	}
+	return last.String, nil
+}
+
+// MoveTask переносит задачу в колонку status между afterID (выше) и beforeID (ниже); без соседей — в конец.
+// Колонка блокируется на время транзакции, чтобы параллельные перемещения не получили одинаковый ранг.
+// Если между соседями не осталось места, колонка перенумеровывается целиком.
+func (rt *PostgresTaskRepository) MoveTask(id, projectID int, status string, afterID, beforeID *int, updatedAt time.Time,
+	events ...*model.ProjectEvent) error {
+	tx, err := rt.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT id, rank FROM tasks WHERE project_id = $1 AND status = $2 AND id <> $3
+		ORDER BY rank, id FOR UPDATE`, projectID, status, id)
+	if err != nil {
+		return err
+	}
+	var ids []int
+	var ranks []string
+	for rows.Next() {
+		var cardID int
+		var cardRank string
+		if err := rows.Scan(&cardID, &cardRank); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, cardID)
+		ranks = append(ranks, cardRank)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	indexOf := func(cardID int) int {
+		for i, existing := range ids {
+			if existing == cardID {
+				return i
+			}
+		}
+		return -1
+	}
+
+	// pos — индекс, на который встанет задача
+	pos := len(ids)
+	switch {
+	case afterID != nil:
+		i := indexOf(*afterID)
+		if i < 0 {
+			return ErrNeighbourNotInColumn
+		}
+		pos = i + 1
+		if beforeID != nil && indexOf(*beforeID) != pos {
+			return ErrNeighboursNotAdjacent
+		}
+	case beforeID != nil:
+		pos = indexOf(*beforeID)
+		if pos < 0 {
+			return ErrNeighbourNotInColumn
+		}
+	}
+
+	var prev, next string
+	if pos > 0 {
+		prev = ranks[pos-1]
+	}
+	if pos < len(ids) {
+		next = ranks[pos]
+	}
+
+	newRank, err := rank.Between(prev, next)
+	// Карточки без ранга (созданные до появления доски) тоже требуют перенумерации
+	unranked := (pos > 0 && prev == "") || (pos < len(ids) && next == "")
+	if err == nil && !unranked && len(newRank) <= rank.MaxLength {
+		query := `UPDATE tasks SET status = $1, rank = $2, updated_at = $3 WHERE id = $4`
+		if _, err := tx.Exec(query, status, newRank, updatedAt, id); err != nil {
+			return err
+		}
+		if err := writeEvents(tx, events); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	ids = append(ids[:pos], append([]int{id}, ids[pos:]...)...)
+	spread := rank.Spread(len(ids))
+	for i, cardID := range ids {
+		query := `UPDATE tasks SET rank = $1 WHERE id = $2`
+		if _, err := tx.Exec(query, spread[i], cardID); err != nil {
+			return err
+		}
+	}
+	query := `UPDATE tasks SET status = $1, updated_at = $2 WHERE id = $3`
+	if _, err := tx.Exec(query, status, updatedAt, id); err != nil {
+		return err
+	}
+	if err := writeEvents(tx, events); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"pet-project/pkg/model"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type PostgresWebhookRepository struct {
+	DB *sql.DB
+}
+
+type WebhookRepository interface {
+	CreateWebhook(webhook *model.Webhook) error
+	UpdateWebhook(webhook *model.Webhook) error
+	GetWebhookByID(id int) (*model.Webhook, error)
+	DeleteWebhook(id int) error
+	ListByProject(projectID int) ([]*model.Webhook, error)
+	ListSubscribed(projectID int, event string) ([]*model.Webhook, error)
+
+	EnqueueDelivery(delivery *model.WebhookDelivery) error
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.WebhookDelivery, error)
+	FinishAttempt(ctx context.Context, delivery *model.WebhookDelivery, attempt *model.WebhookAttempt) error
+	GetDelivery(id int) (*model.WebhookDelivery, error)
+	ListDeliveries(webhookID int, status string, limit int) ([]*model.WebhookDelivery, error)
+	ListAttempts(deliveryID int) ([]*model.WebhookAttempt, error)
+}
+
+const webhookColumns = `id, project_id, url, secret, events, active, created_by, created_at, updated_at`
+
+func scanWebhook(row interface{ Scan(...any) error }) (*model.Webhook, error) {
+	webhook := &model.Webhook{}
+	err := row.Scan(&webhook.ID, &webhook.ProjectID, &webhook.URL, &webhook.Secret, pq.Array(&webhook.Events),
+		&webhook.Active, &webhook.CreatedBy, &webhook.CreatedAt, &webhook.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (r *PostgresWebhookRepository) CreateWebhook(webhook *model.Webhook) error {
+	query := `INSERT INTO webhooks (project_id, url, secret, events, active, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+	return r.DB.QueryRow(query, webhook.ProjectID, webhook.URL, webhook.Secret, pq.Array(webhook.Events), webhook.Active,
+		webhook.CreatedBy, webhook.CreatedAt, webhook.UpdatedAt).Scan(&webhook.ID)
+}
+
+func (r *PostgresWebhookRepository) UpdateWebhook(webhook *model.Webhook) error {
+	query := `UPDATE webhooks SET url = $1, secret = $2, events = $3, active = $4, updated_at = $5 WHERE id = $6`
+	_, err := r.DB.Exec(query, webhook.URL, webhook.Secret, pq.Array(webhook.Events), webhook.Active, webhook.UpdatedAt, webhook.ID)
+	return err
+}
+
+func (r *PostgresWebhookRepository) GetWebhookByID(id int) (*model.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1`
+	return scanWebhook(r.DB.QueryRow(query, id))
+}
+
+// DeleteWebhook удаляет подписку вместе с очередью и журналом отправок
+func (r *PostgresWebhookRepository) DeleteWebhook(id int) error {
+	_, err := r.DB.Exec(`DELETE FROM webhooks WHERE id = $1`, id)
+	return err
+}
+
+func (r *PostgresWebhookRepository) ListByProject(projectID int) ([]*model.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE project_id = $1 ORDER BY id`
+	return r.listWebhooks(query, projectID)
+}
+
+func (r *PostgresWebhookRepository) ListSubscribed(projectID int, event string) ([]*model.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE project_id = $1 AND active AND $2 = ANY(events) ORDER BY id`
+	return r.listWebhooks(query, projectID, event)
+}
+
+func (r *PostgresWebhookRepository) listWebhooks(query string, args ...any) ([]*model.Webhook, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []*model.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+const deliveryColumns = `id, webhook_id, event, event_id, payload, status, attempts, next_attempt_at, last_status_code, last_error,
+	created_at, delivered_at`
+
+func scanDelivery(row interface{ Scan(...any) error }) (*model.WebhookDelivery, error) {
+	delivery := &model.WebhookDelivery{}
+	var eventID, statusCode sql.NullInt64
+	err := row.Scan(&delivery.ID, &delivery.WebhookID, &delivery.Event, &eventID, &delivery.Payload, &delivery.Status,
+		&delivery.Attempts, &delivery.NextAttemptAt, &statusCode, &delivery.LastError, &delivery.CreatedAt, &delivery.DeliveredAt)
+	if err != nil {
+		return nil, err
+	}
+	delivery.EventID = int(eventID.Int64)
+	if statusCode.Valid {
+		code := int(statusCode.Int64)
+		delivery.LastStatusCode = &code
+	}
+	return delivery, nil
+}
+
+// EnqueueDelivery ставит отправку в очередь. Отправка того же события outbox в тот же webhook
+// уже стоит в очереди — тогда ничего не делает и оставляет ID нулевым.
+func (r *PostgresWebhookRepository) EnqueueDelivery(delivery *model.WebhookDelivery) error {
+	query := `INSERT INTO webhook_deliveries (webhook_id, event, event_id, payload, status, attempts, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (webhook_id, event_id) WHERE event_id IS NOT NULL DO NOTHING
+		RETURNING id`
+	err := r.DB.QueryRow(query, delivery.WebhookID, delivery.Event, nullableID(delivery.EventID), delivery.Payload,
+		delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.CreatedAt).Scan(&delivery.ID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	return err
+}
+
+// ClaimDue забирает наступившие отправки и сдвигает их next_attempt_at на leaseUntil: пока воркер
+// отправляет, другие реплики их не видят, а если он упадёт, отправка вернётся в очередь после аренды.
+// Счётчик попыток увеличивается сразу при захвате.
+func (r *PostgresWebhookRepository) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	query := `UPDATE webhook_deliveries SET next_attempt_at = $2, attempts = attempts + 1
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at, id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + deliveryColumns
+	rows, err := r.DB.QueryContext(ctx, query, now, leaseUntil, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanDeliveries(rows)
+}
+
+// FinishAttempt записывает попытку в журнал и сохраняет новое состояние отправки
+func (r *PostgresWebhookRepository) FinishAttempt(ctx context.Context, delivery *model.WebhookDelivery, attempt *model.WebhookAttempt) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	insert := `INSERT INTO webhook_attempts (delivery_id, attempt, status_code, error, response, duration_ms, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	err = tx.QueryRowContext(ctx, insert, attempt.DeliveryID, attempt.Attempt, attempt.StatusCode, attempt.Error,
+		attempt.Response, attempt.DurationMs, attempt.CreatedAt).Scan(&attempt.ID)
+	if err != nil {
+		return err
+	}
+
+	update := `UPDATE webhook_deliveries SET status = $1, next_attempt_at = $2, last_status_code = $3, last_error = $4,
+		delivered_at = $5 WHERE id = $6`
+	_, err = tx.ExecContext(ctx, update, delivery.Status, delivery.NextAttemptAt, delivery.LastStatusCode, delivery.LastError,
+		delivery.DeliveredAt, delivery.ID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresWebhookRepository) GetDelivery(id int) (*model.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE id = $1`
+	return scanDelivery(r.DB.QueryRow(query, id))
+}
+
+// ListDeliveries возвращает последние отправки webhook, status ограничивает выборку одним статусом
+func (r *PostgresWebhookRepository) ListDeliveries(webhookID int, status string, limit int) ([]*model.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries
+		WHERE webhook_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC, id DESC LIMIT $3`
+	rows, err := r.DB.Query(query, webhookID, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanDeliveries(rows)
+}
+
+func scanDeliveries(rows *sql.Rows) ([]*model.WebhookDelivery, error) {
+	defer rows.Close()
+	deliveries := []*model.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+func (r *PostgresWebhookRepository) ListAttempts(deliveryID int) ([]*model.WebhookAttempt, error) {
+	query := `SELECT id, delivery_id, attempt, status_code, error, response, duration_ms, created_at
+		FROM webhook_attempts WHERE delivery_id = $1 ORDER BY attempt, id`
+	rows, err := r.DB.Query(query, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attempts := []*model.WebhookAttempt{}
+	for rows.Next() {
+		attempt := &model.WebhookAttempt{}
+		var statusCode sql.NullInt64
+		err := rows.Scan(&attempt.ID, &attempt.DeliveryID, &attempt.Attempt, &statusCode, &attempt.Error, &attempt.Response,
+			&attempt.DurationMs, &attempt.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if statusCode.Valid {
+			code := int(statusCode.Int64)
+			attempt.StatusCode = &code
+		}
+		attempts = append(attempts, attempt)
+	}
+	return attempts, rows.Err()
+}
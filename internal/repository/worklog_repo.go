@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"pet-project/pkg/model"
+	"strings"
+	"time"
+)
+
+type PostgresWorklogRepository struct {
+	DB *sql.DB
+}
+
+type WorklogRepository interface {
+	CreateWorklog(wl *model.Worklog) error
+	UpdateWorklog(wl *model.Worklog) error
+	DeleteWorklog(id int) error
+	GetWorklogByID(id int) (*model.Worklog, error)
+	ListByTask(taskID int) ([]*model.Worklog, error)
+	StartTimer(timer *model.Timer) error
+	GetTimer(userID int) (*model.Timer, error)
+	// StopTimer удаляет таймер, запущенный в startedAt, и записывает wl в одной транзакции;
+	// sql.ErrNoRows — таймер уже остановлен
+	StopTimer(wl *model.Worklog, startedAt time.Time) error
+	Summary(scope model.WorklogScope) (*model.TimeSummary, error)
+}
+
+func (r *PostgresWorklogRepository) CreateWorklog(wl *model.Worklog) error {
+	query := `INSERT INTO worklogs (task_id, user_id, minutes, work_date, note, created_at, updated_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	return r.DB.QueryRow(query, wl.TaskID, wl.UserID, wl.Minutes, wl.WorkDate, wl.Note, wl.CreatedAt, wl.UpdatedAt).Scan(&wl.ID)
+}
+
+func (r *PostgresWorklogRepository) UpdateWorklog(wl *model.Worklog) error {
+	query := `UPDATE worklogs SET minutes = $1, work_date = $2, note = $3, updated_at = $4 WHERE id = $5`
+	_, err := r.DB.Exec(query, wl.Minutes, wl.WorkDate, wl.Note, wl.UpdatedAt, wl.ID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresWorklogRepository) DeleteWorklog(id int) error {
+	query := `DELETE FROM worklogs WHERE id = $1`
+	_, err := r.DB.Exec(query, id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresWorklogRepository) GetWorklogByID(id int) (*model.Worklog, error) {
+	wl := &model.Worklog{}
+	query := `SELECT id, task_id, user_id, minutes, work_date, note, created_at, updated_at FROM worklogs WHERE id = $1`
+	err := r.DB.QueryRow(query, id).Scan(&wl.ID, &wl.TaskID, &wl.UserID, &wl.Minutes, &wl.WorkDate,
+		&wl.Note, &wl.CreatedAt, &wl.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return wl, nil
+}
+
+func (r *PostgresWorklogRepository) ListByTask(taskID int) ([]*model.Worklog, error) {
+	query := `SELECT id, task_id, user_id, minutes, work_date, note, created_at, updated_at FROM worklogs
+				WHERE task_id = $1 ORDER BY work_date, id`
+	rows, err := r.DB.Query(query, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	worklogs := []*model.Worklog{}
+	for rows.Next() {
+		var wl model.Worklog
+		if err := rows.Scan(&wl.ID, &wl.TaskID, &wl.UserID, &wl.Minutes, &wl.WorkDate,
+			&wl.Note, &wl.CreatedAt, &wl.UpdatedAt); err != nil {
+			return nil, err
+		}
+		worklogs = append(worklogs, &wl)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return worklogs, nil
+}
+
+// StartTimer запускает таймер; если у пользователя уже есть активный таймер, вернётся ошибка уникальности
+func (r *PostgresWorklogRepository) StartTimer(timer *model.Timer) error {
+	query := `INSERT INTO timers (user_id, task_id, started_at) VALUES ($1, $2, $3)`
+	_, err := r.DB.Exec(query, timer.UserID, timer.TaskID, timer.StartedAt)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresWorklogRepository) GetTimer(userID int) (*model.Timer, error) {
+	timer := &model.Timer{}
+	query := `SELECT user_id, task_id, started_at FROM timers WHERE user_id = $1`
+	err := r.DB.QueryRow(query, userID).Scan(&timer.UserID, &timer.TaskID, &timer.StartedAt)
+	if err != nil {
+		return nil, err
+	}
+	return timer, nil
+}
+
+func (r *PostgresWorklogRepository) StopTimer(wl *model.Worklog, startedAt time.Time) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var taskID int
+	query := `DELETE FROM timers WHERE user_id = $1 AND task_id = $2 AND started_at = $3 RETURNING task_id`
+	if err := tx.QueryRow(query, wl.UserID, wl.TaskID, startedAt).Scan(&taskID); err != nil {
+		return err
+	}
+	insert := `INSERT INTO worklogs (task_id, user_id, minutes, work_date, note, created_at, updated_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	err = tx.QueryRow(insert, taskID, wl.UserID, wl.Minutes, wl.WorkDate, wl.Note, wl.CreatedAt, wl.UpdatedAt).Scan(&wl.ID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Summary считает затраченное время в заданных рамках: всего, по пользователям, по задачам и по дням
+func (r *PostgresWorklogRepository) Summary(scope model.WorklogScope) (*model.TimeSummary, error) {
+	conds := []string{"TRUE"}
+	args := []any{}
+	add := func(cond string, value any) {
+		args = append(args, value)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if scope.TaskID > 0 {
+		add("w.task_id = $%d", scope.TaskID)
+	}
+	if scope.ProjectID > 0 {
+		add("t.project_id = $%d", scope.ProjectID)
+	}
+	if scope.UserID > 0 {
+		add("w.user_id = $%d", scope.UserID)
+	}
+	if scope.From != nil {
+		add("w.work_date >= $%d", *scope.From)
+	}
+	if scope.To != nil {
+		add("w.work_date <= $%d", *scope.To)
+	}
+	from := ` FROM worklogs w JOIN tasks t ON t.id = w.task_id WHERE ` + strings.Join(conds, " AND ")
+
+	summary := &model.TimeSummary{ByUser: []*model.UserTime{}, ByTask: []*model.TaskTime{}, ByDay: []*model.DayTime{}}
+
+	if err := r.DB.QueryRow(`SELECT COALESCE(SUM(w.minutes), 0)`+from, args...).Scan(&summary.TotalMinutes); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.DB.Query(`SELECT w.user_id, SUM(w.minutes)`+from+` GROUP BY w.user_id ORDER BY 2 DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var ut model.UserTime
+		if err := rows.Scan(&ut.UserID, &ut.Minutes); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		summary.ByUser = append(summary.ByUser, &ut)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.DB.Query(`SELECT t.id, t.title, SUM(w.minutes)`+from+` GROUP BY t.id, t.title ORDER BY 3 DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var tt model.TaskTime
+		if err := rows.Scan(&tt.TaskID, &tt.Title, &tt.Minutes); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		summary.ByTask = append(summary.ByTask, &tt)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.DB.Query(`SELECT to_char(w.work_date, 'YYYY-MM-DD'), SUM(w.minutes)`+from+` GROUP BY 1 ORDER BY 1`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var dt model.DayTime
+		if err := rows.Scan(&dt.Date, &dt.Minutes); err != nil {
+			return nil, err
+		}
+		summary.ByDay = append(summary.ByDay, &dt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
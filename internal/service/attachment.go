@@ -0,0 +1,206 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"pet-project/internal/repository"
+	"pet-project/internal/storage"
+	"pet-project/pkg/model"
+	"strings"
+	"time"
+)
+
+const DefaultMaxAttachmentSize = 10 << 20 // 10 МБ
+
+// DefaultAllowedAttachmentTypes — скриншоты, логи и типовые архивы/документы
+var DefaultAllowedAttachmentTypes = []string{
+	"image/png", "image/jpeg", "image/gif", "image/webp",
+	"text/plain", "application/pdf", "application/zip", "application/x-gzip",
+}
+
+type AttachmentService struct {
+	Repository   repository.AttachmentRepository
+	Tasks        repository.TaskRepository
+	Comments     repository.CommentsRepository
+	Storage      storage.Storage
+	MaxSize      int64
+	AllowedTypes []string
+}
+
+func (s *AttachmentService) maxSize() int64 {
+	if s.MaxSize <= 0 {
+		return DefaultMaxAttachmentSize
+	}
+	return s.MaxSize
+}
+
+// ErrAttachmentNotFound — вложения, задачи или комментария нет, либо у пользователя нет доступа к проекту
+var ErrAttachmentNotFound = errors.New("Attachment not found")
+
+// checkTaskAccess проверяет, что пользователь имеет доступ к проекту задачи
+func (s *AttachmentService) checkTaskAccess(ctx context.Context, taskID, userID int) error {
+	ok, err := s.Repository.CanAccessTask(ctx, taskID, userID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !ok) {
+		return errors.New("Task not found")
+	}
+	return err
+}
+
+func (s *AttachmentService) UploadToTask(ctx context.Context, taskID, userID int, fileName string, size int64, content io.Reader) (*model.Attachment, error) {
+	if err := s.checkTaskAccess(ctx, taskID, userID); err != nil {
+		return nil, err
+	}
+	att := &model.Attachment{TaskID: taskID, UserID: userID, FileName: fileName, Size: size}
+	return att, s.upload(ctx, att, content)
+}
+
+func (s *AttachmentService) UploadToComment(ctx context.Context, comID, userID int, fileName string, size int64, content io.Reader) (*model.Attachment, error) {
+	com, err := s.Comments.GetCommentByID(comID)
+	if err != nil {
+		return nil, errors.New("Comment not found")
+	}
+	if err := s.checkTaskAccess(ctx, com.TaskID, userID); err != nil {
+		return nil, errors.New("Comment not found")
+	}
+	if com.Deleted {
+		return nil, errors.New("Can't attach files to deleted comment")
+	}
+	att := &model.Attachment{TaskID: com.TaskID, CommentID: &com.ID, UserID: userID, FileName: fileName, Size: size}
+	return att, s.upload(ctx, att, content)
+}
+
+func (s *AttachmentService) upload(ctx context.Context, att *model.Attachment, content io.Reader) error {
+	if att.UserID <= 0 {
+		return errors.New("invalid user ID")
+	}
+	if att.Size <= 0 {
+		return errors.New("File is empty")
+	}
+	if att.Size > s.maxSize() {
+		return fmt.Errorf("File is too large, max size is %d bytes", s.maxSize())
+	}
+
+	att.FileName = filepath.Base(strings.ReplaceAll(att.FileName, "\\", "/"))
+	if att.FileName == "" || att.FileName == "." || att.FileName == "/" {
+		att.FileName = "file"
+	}
+
+	// Тип определяем по содержимому, а не по тому, что прислал клиент
+	buffered := bufio.NewReaderSize(content, 512)
+	head, err := buffered.Peek(512)
+	if err != nil && err != io.EOF && !errors.Is(err, bufio.ErrBufferFull) {
+		return err
+	}
+	att.ContentType = http.DetectContentType(head)
+	if !s.typeAllowed(att.ContentType) {
+		return fmt.Errorf("File type %s is not allowed", att.ContentType)
+	}
+
+	key, err := newStorageKey(att.TaskID)
+	if err != nil {
+		return err
+	}
+	att.StorageKey = key
+	att.CreatedAt = time.Now()
+
+	if err := s.Storage.Put(ctx, key, io.LimitReader(buffered, att.Size), att.Size, att.ContentType); err != nil {
+		return err
+	}
+
+	if err := s.Repository.Create(ctx, att); err != nil {
+		if delErr := s.Storage.Delete(ctx, key); delErr != nil {
+			log.Println("Failed to remove orphaned attachment:", delErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *AttachmentService) typeAllowed(contentType string) bool {
+	allowed := s.AllowedTypes
+	if len(allowed) == 0 {
+		allowed = DefaultAllowedAttachmentTypes
+	}
+	mediaType := strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+	for _, t := range allowed {
+		if strings.EqualFold(t, mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+func newStorageKey(taskID int) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("tasks/%d/%s", taskID, hex.EncodeToString(buf)), nil
+}
+
+func (s *AttachmentService) ListByTask(ctx context.Context, taskID, userID int) ([]*model.Attachment, error) {
+	if err := s.checkTaskAccess(ctx, taskID, userID); err != nil {
+		return nil, err
+	}
+	return s.Repository.ListByTask(ctx, taskID)
+}
+
+func (s *AttachmentService) ListByComment(ctx context.Context, comID, userID int) ([]*model.Attachment, error) {
+	com, err := s.Comments.GetCommentByID(comID)
+	if err != nil {
+		return nil, errors.New("Comment not found")
+	}
+	if err := s.checkTaskAccess(ctx, com.TaskID, userID); err != nil {
+		return nil, errors.New("Comment not found")
+	}
+	return s.Repository.ListByComment(ctx, comID)
+}
+
+// Open возвращает метаданные вложения и его содержимое, если пользователь имеет доступ к проекту задачи;
+// закрыть содержимое должен вызывающий
+func (s *AttachmentService) Open(ctx context.Context, id, userID int) (*model.Attachment, io.ReadSeekCloser, error) {
+	att, err := s.Repository.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, ErrAttachmentNotFound
+	}
+	if err := s.checkTaskAccess(ctx, att.TaskID, userID); err != nil {
+		return nil, nil, ErrAttachmentNotFound
+	}
+	content, err := s.Storage.Open(ctx, att.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return att, content, nil
+}
+
+func (s *AttachmentService) Delete(ctx context.Context, id, userID int) error {
+	att, err := s.Repository.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if att.UserID != userID {
+		return errors.New("No permission to delete attachment")
+	}
+	if err := s.Repository.Delete(ctx, id); err != nil {
+		return err
+	}
+	return s.Storage.Delete(ctx, att.StorageKey)
+}
+
+// RemoveFiles удаляет содержимое вложений из хранилища. Записи в БД удаляются каскадно вместе с задачей.
+func (s *AttachmentService) RemoveFiles(ctx context.Context, attachments []*model.Attachment) {
+	for _, att := range attachments {
+		if err := s.Storage.Delete(ctx, att.StorageKey); err != nil {
+			log.Println("Failed to remove attachment file:", att.StorageKey, err)
+		}
+	}
+}
@@ -0,0 +1,487 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"pet-project/internal/repository"
+	"pet-project/internal/taskquery"
+	"pet-project/pkg/model"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultAutomationDepth = 5
+	maxRuleActions         = 10
+	defaultRunsLimit       = 50
+	maxRunsLimit           = 200
+)
+
+// automationTriggerFields — поля, изменение которых может запускать правило
+var automationTriggerFields = []string{"status", "priority", "title", "description", "assignee", "due"}
+
+// automationSetFields — поля, которые может менять действие set_field
+var automationSetFields = []string{"status", "priority", "title", "description", "due"}
+
+var automationRecipients = []string{"owner", "assignees", "watchers", "actor", "user"}
+
+// AutomationService выполняет правила проектов на события задач и комментариев из outbox.
+// Действия правила порождают новые события (тоже через outbox), поэтому цепочка ограничена MaxDepth
+// и одно правило срабатывает в цепочке не больше одного раза.
+type AutomationService struct {
+	Repository repository.AutomationRepository
+	Tasks      *TaskService
+	Comments   repository.CommentsRepository
+	Projects   repository.ProjectRepository
+	Client     *http.Client
+
+	// MaxDepth — сколько правил подряд может запустить одно событие, 0 — по умолчанию 5
+	MaxDepth int
+}
+
+func (s *AutomationService) CreateRule(rule *model.AutomationRule) error {
+	if err := validateRule(rule); err != nil {
+		return err
+	}
+	now := time.Now()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	return s.Repository.CreateRule(rule)
+}
+
+func (s *AutomationService) GetRule(rule_id int) (*model.AutomationRule, error) {
+	return s.Repository.GetRuleByID(rule_id)
+}
+
+func (s *AutomationService) ListRules(project_id int) ([]*model.AutomationRule, error) {
+	return s.Repository.ListByProject(project_id)
+}
+
+func (s *AutomationService) UpdateRule(rule *model.AutomationRule) error {
+	if err := validateRule(rule); err != nil {
+		return err
+	}
+	rule.UpdatedAt = time.Now()
+	return s.Repository.UpdateRule(rule)
+}
+
+func (s *AutomationService) DeleteRule(rule_id int) error {
+	return s.Repository.DeleteRule(rule_id)
+}
+
+func (s *AutomationService) ListRuns(project_id int, rule_id *int, limit int) ([]*model.AutomationRun, error) {
+	if limit <= 0 {
+		limit = defaultRunsLimit
+	}
+	if limit > maxRunsLimit {
+		limit = maxRunsLimit
+	}
+	return s.Repository.ListRuns(project_id, rule_id, limit)
+}
+
+func validateRule(rule *model.AutomationRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" {
+		return errors.New("Rule name is required")
+	}
+	if utf8.RuneCountInString(rule.Name) > 255 {
+		return errors.New("Rule name is too long")
+	}
+
+	trigger := rule.Trigger
+	switch trigger.Event {
+	case model.EventTaskCreated, model.EventCommentCreated:
+		if trigger.Field != "" || trigger.From != nil || trigger.To != nil {
+			return fmt.Errorf("Trigger %s doesn't take a field", trigger.Event)
+		}
+	case model.EventTaskUpdated:
+		if trigger.Field != "" && !containsString(automationTriggerFields, trigger.Field) {
+			return fmt.Errorf("Unknown trigger field %q", trigger.Field)
+		}
+		if trigger.Field == "" && (trigger.From != nil || trigger.To != nil) {
+			return errors.New("Trigger from and to require a field")
+		}
+	default:
+		return errors.New("Invalid trigger event, use task_created, task_updated or comment_created")
+	}
+
+	rule.Condition = strings.TrimSpace(rule.Condition)
+	if err := validateTaskQuery(rule.Condition); err != nil {
+		return err
+	}
+
+	if len(rule.Actions) == 0 {
+		return errors.New("Rule must have at least one action")
+	}
+	if len(rule.Actions) > maxRuleActions {
+		return fmt.Errorf("Rule can have at most %d actions", maxRuleActions)
+	}
+	for i, action := range rule.Actions {
+		if err := validateAction(action); err != nil {
+			return fmt.Errorf("Action %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func validateAction(action model.AutomationAction) error {
+	switch action.Type {
+	case model.ActionSetField:
+		if !containsString(automationSetFields, action.Field) {
+			return fmt.Errorf("Field %q can't be set", action.Field)
+		}
+		if action.Field == "due" && action.Value != "" {
+			if _, err := taskquery.ParseTime(action.Value, time.Now()); err != nil {
+				return err
+			}
+		}
+		if action.Value == "" && action.Field != "description" && action.Field != "due" {
+			return fmt.Errorf("Value for %s is required", action.Field)
+		}
+		if err := checkFieldValue(action.Field, action.Value); err != nil {
+			return err
+		}
+	case model.ActionAssign:
+		if action.UserID <= 0 {
+			return errors.New("User ID is required")
+		}
+	case model.ActionAddComment:
+		if strings.TrimSpace(action.Text) == "" {
+			return errors.New("Comment text is required")
+		}
+	case model.ActionNotify:
+		if !containsString(automationRecipients, action.To) {
+			return errors.New("Invalid recipient, use owner, assignees, watchers, actor or user")
+		}
+		if action.To == "user" && action.UserID <= 0 {
+			return errors.New("User ID is required")
+		}
+	case model.ActionWebhook:
+		u, err := url.Parse(action.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("Webhook URL must be an absolute http or https URL")
+		}
+	default:
+		return fmt.Errorf("Unknown action %q", action.Type)
+	}
+	return nil
+}
+
+// HandleEvent — подписчик outbox: проверяет событие правилами проекта и выполняет подходящие.
+// Ошибки самих правил пишутся в журнал и на доставку события не влияют.
+func (s *AutomationService) HandleEvent(ctx context.Context, msg *model.OutboxMessage) error {
+	event, err := decodeEvent(msg)
+	if err != nil {
+		return err
+	}
+	return s.Dispatch(event)
+}
+
+// Dispatch выполняет правила, подходящие событию. Правило, уже сработавшее на это сообщение
+// outbox, пропускается, поэтому повторная доставка действия не повторяет.
+func (s *AutomationService) Dispatch(event model.ProjectEvent) error {
+	rules, err := s.Repository.ListEnabled(event.ProjectID, event.Type)
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		if !triggerMatches(rule.Trigger, event) {
+			continue
+		}
+		if event.ID != 0 {
+			ran, err := s.Repository.HasRun(rule.ID, event.ID)
+			if err != nil {
+				return err
+			}
+			if ran {
+				continue
+			}
+		}
+		s.run(rule, event)
+	}
+	return nil
+}
+
+func triggerMatches(trigger model.AutomationTrigger, event model.ProjectEvent) bool {
+	if trigger.Event != event.Type {
+		return false
+	}
+	if trigger.Field == "" {
+		return true
+	}
+	change, ok := event.Changes[trigger.Field]
+	if !ok {
+		return false
+	}
+	if trigger.From != nil && change.Old != *trigger.From {
+		return false
+	}
+	if trigger.To != nil && change.New != *trigger.To {
+		return false
+	}
+	return true
+}
+
+func (s *AutomationService) run(rule *model.AutomationRule, event model.ProjectEvent) {
+	runLog := &model.AutomationRun{
+		RuleID:    rule.ID,
+		ProjectID: event.ProjectID,
+		TaskID:    event.TaskID,
+		Event:     event.Type,
+		EventID:   event.ID,
+		Depth:     len(event.Chain),
+	}
+
+	switch {
+	case containsInt(event.Chain, rule.ID):
+		runLog.Status = model.RunSkipped
+		runLog.Message = "Rule already ran in this chain, loop stopped"
+	case len(event.Chain) >= s.maxDepth():
+		runLog.Status = model.RunSkipped
+		runLog.Message = fmt.Sprintf("Chain is longer than %d rules, stopped", s.maxDepth())
+	default:
+		if rule.Condition != "" {
+			matches, err := s.Repository.TaskMatches(event.TaskID, rule.Condition, event.ActorID)
+			if err != nil {
+				runLog.Status = model.RunFailed
+				runLog.Message = "Condition: " + err.Error()
+				break
+			}
+			if !matches {
+				return
+			}
+		}
+		if err := s.execute(rule, event); err != nil {
+			runLog.Status = model.RunFailed
+			runLog.Message = err.Error()
+			break
+		}
+		runLog.Status = model.RunSuccess
+	}
+
+	runLog.CreatedAt = time.Now()
+	if err := s.Repository.LogRun(runLog); err != nil {
+		log.Println("Automation: failed to log run:", err)
+	}
+}
+
+// execute выполняет действия правила по порядку и останавливается на первой ошибке
+func (s *AutomationService) execute(rule *model.AutomationRule, event model.ProjectEvent) error {
+	task, err := s.Tasks.Repository.GetByIDTask(event.TaskID)
+	if err != nil {
+		return errors.New("Task not found")
+	}
+	chain := append(append([]int{}, event.Chain...), rule.ID)
+
+	for i, action := range rule.Actions {
+		if err := s.apply(rule, action, task, event, chain); err != nil {
+			return fmt.Errorf("Action %d (%s): %w", i+1, action.Type, err)
+		}
+	}
+	return nil
+}
+
+// apply выполняет одно действие. Порождённые им события пишутся в outbox вместе с изменением
+// и несут chain — цепочку правил, которая к нему привела.
+func (s *AutomationService) apply(rule *model.AutomationRule, action model.AutomationAction, task *model.Task,
+	event model.ProjectEvent, chain []int) error {
+	now := time.Now()
+
+	switch action.Type {
+	case model.ActionSetField, model.ActionAssign:
+		old := *task
+		if action.Type == model.ActionAssign {
+			task.AssignedTo = action.UserID
+		} else if err := setTaskField(task, action.Field, expandTemplate(action.Value, task), now); err != nil {
+			return err
+		}
+		task.UpdatedAt = now
+		followUp := taskUpdatedEvent(&old, task, rule.CreatedBy)
+		if followUp != nil {
+			followUp.Chain = chain
+		}
+		if err := s.Tasks.saveTask(task, &old, nil, followUp); err != nil {
+			return err
+		}
+		if action.Type == model.ActionAssign && s.Tasks.Members != nil {
+			if err := s.Tasks.Members.AddAssignee(task.ID, action.UserID); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case model.ActionAddComment:
+		com := &model.Comments{
+			TaskID:    task.ID,
+			UserID:    rule.CreatedBy,
+			Text:      expandTemplate(action.Text, task),
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		return s.Comments.AddComment(com, &model.ProjectEvent{
+			Type:      model.EventCommentCreated,
+			ProjectID: task.ProjectID,
+			ActorID:   rule.CreatedBy,
+			Chain:     chain,
+		})
+
+	case model.ActionNotify:
+		recipients, err := s.recipients(action, task, event)
+		if err != nil {
+			return err
+		}
+		message := expandTemplate(action.Text, task)
+		if message == "" {
+			message = fmt.Sprintf("Rule \"%s\" ran on task #%d \"%s\"", rule.Name, task.ID, shortTitle(task.Title))
+		}
+		s.Tasks.notify(task.ID, recipients, "automation", message)
+		return nil
+
+	case model.ActionWebhook:
+		return s.callWebhook(action.URL, rule, task, event)
+	}
+	return fmt.Errorf("Unknown action %q", action.Type)
+}
+
+// checkFieldValue ограничивает статус и приоритет допустимыми значениями
+func checkFieldValue(field, value string) error {
+	switch field {
+	case "status":
+		if !containsString(BoardStatuses, value) {
+			return errors.New("Invalid status, use pending, in_progress or done")
+		}
+	case "priority":
+		if !containsString(taskPriorities, value) {
+			return errors.New("Invalid priority, use low, medium or high")
+		}
+	}
+	return nil
+}
+
+func setTaskField(task *model.Task, field, value string, now time.Time) error {
+	if err := checkFieldValue(field, value); err != nil {
+		return err
+	}
+	switch field {
+	case "status":
+		task.Status = value
+	case "priority":
+		task.Priority = value
+	case "title":
+		task.Title = value
+	case "description":
+		task.Description = value
+	case "due":
+		if value == "" {
+			task.DueDate = nil
+			return nil
+		}
+		due, err := taskquery.ParseTime(value, now)
+		if err != nil {
+			return err
+		}
+		task.DueDate = &due
+	default:
+		return fmt.Errorf("Field %q can't be set", field)
+	}
+	return nil
+}
+
+func (s *AutomationService) recipients(action model.AutomationAction, task *model.Task, event model.ProjectEvent) ([]int, error) {
+	switch action.To {
+	case "owner":
+		project, err := s.Projects.GetByIDProject(task.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		return []int{project.OwnerID}, nil
+	case "assignees", "watchers":
+		if s.Tasks.Members == nil {
+			if action.To == "assignees" && task.AssignedTo > 0 {
+				return []int{task.AssignedTo}, nil
+			}
+			return nil, nil
+		}
+		list := s.Tasks.Members.ListAssigneesByTasks
+		if action.To == "watchers" {
+			list = s.Tasks.Members.ListWatchersByTasks
+		}
+		members, err := list([]int{task.ID})
+		if err != nil {
+			return nil, err
+		}
+		return members[task.ID], nil
+	case "actor":
+		if event.ActorID == 0 {
+			return nil, nil
+		}
+		return []int{event.ActorID}, nil
+	case "user":
+		return []int{action.UserID}, nil
+	}
+	return nil, fmt.Errorf("Invalid recipient %q", action.To)
+}
+
+func (s *AutomationService) callWebhook(target string, rule *model.AutomationRule, task *model.Task, event model.ProjectEvent) error {
+	payload := map[string]any{
+		"rule":    map[string]any{"id": rule.ID, "name": rule.Name},
+		"event":   event.Type,
+		"actorID": event.ActorID,
+		"changes": event.Changes,
+		"task":    task,
+	}
+	if event.CommentID != 0 {
+		payload["commentID"] = event.CommentID
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Post(target, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("Webhook responded with %s", resp.Status)
+	}
+	return nil
+}
+
+func (s *AutomationService) maxDepth() int {
+	if s.MaxDepth <= 0 {
+		return defaultAutomationDepth
+	}
+	return s.MaxDepth
+}
+
+func expandTemplate(text string, task *model.Task) string {
+	return strings.NewReplacer(
+		"{task.id}", strconv.Itoa(task.ID),
+		"{task.title}", task.Title,
+		"{task.status}", task.Status,
+		"{task.priority}", task.Priority,
+	).Replace(text)
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
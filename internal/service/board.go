@@ -0,0 +1,130 @@
+package service
+
+import (
+	"errors"
+	"pet-project/internal/rank"
+	"pet-project/pkg/model"
+	"time"
+)
+
+// BoardStatuses — колонки доски в порядке отображения
+var BoardStatuses = []string{"pending", "in_progress", "done"}
+
+// endRank возвращает ранг для карточки в конце колонки
+func (s *TaskService) endRank(project_id int, status string) (string, error) {
+	last, err := s.Repository.LastRank(project_id, status)
+	if err != nil {
+		return "", err
+	}
+	r, err := rank.Between(last, "")
+	if err != nil {
+		// в колонке битый ранг — ставим карточку в начало, перенумерация случится при первом перемещении
+		return rank.Between("", "")
+	}
+	return r, nil
+}
+
+// MoveTask меняет статус и позицию задачи на доске за одну операцию.
+// after_id — карточка, под которой окажется задача, before_id — над которой; без обоих — в конец колонки.
+func (s *TaskService) MoveTask(task_id int, status string, after_id, before_id *int, user_id int) (*model.Task, error) {
+	if !containsString(BoardStatuses, status) {
+		return nil, errors.New("Invalid status")
+	}
+
+	task, err := s.Repository.GetByIDTask(task_id)
+	if err != nil {
+		return nil, errors.New("Task not found")
+	}
+	allowed, err := s.isAssignee(task, user_id)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, errors.New("No permission to move task")
+	}
+
+	for _, neighbour_id := range []*int{after_id, before_id} {
+		if neighbour_id == nil {
+			continue
+		}
+		if *neighbour_id == task_id {
+			return nil, errors.New("Task can't be its own neighbour")
+		}
+		neighbour, err := s.Repository.GetByIDTask(*neighbour_id)
+		if err != nil {
+			return nil, errors.New("Neighbour task not found")
+		}
+		if neighbour.ProjectID != task.ProjectID || neighbour.Status != status {
+			return nil, errors.New("Neighbour task is not in the target column")
+		}
+	}
+
+	closing := status == "done" && task.Status != "done"
+	if closing && s.Dependencies != nil {
+		// Закрытие закроет и подзадачи, поэтому их блокировки проверяются вместе с задачей
+		open, err := s.Dependencies.CountOpenSubtreeBlockers(task.ID)
+		if err != nil {
+			return nil, err
+		}
+		if open > 0 {
+			return nil, errors.New("Task or its subtasks are blocked by open tasks and can't be closed")
+		}
+	}
+
+	target := *task
+	target.Status = status
+	now := time.Now()
+	if err := s.Repository.MoveTask(task.ID, task.ProjectID, status, after_id, before_id, now, taskUpdatedEvent(task, &target, user_id)); err != nil {
+		return nil, err
+	}
+
+	if closing {
+		if err := s.Repository.CloseDescendants(task.ID, now); err != nil {
+			return nil, err
+		}
+		if task.SeriesID != nil && s.Recurrences != nil {
+			s.onOccurrenceClosed(*task.SeriesID)
+		}
+	}
+
+	moved, err := s.Repository.GetByIDTask(task.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachLabels(moved); err != nil {
+		return nil, err
+	}
+	if err := s.attachMembers(moved); err != nil {
+		return nil, err
+	}
+	renderDescription(moved)
+	return moved, nil
+}
+
+// GetBoard раскладывает задачи проекта по колонкам; фильтр тот же, что у списка задач
+func (s *TaskService) GetBoard(project_id int, filter model.TaskFilter) (*model.Board, error) {
+	filter.Sort = &model.TaskSort{Field: "rank"}
+	tasks, err := s.ListByProjectTask(project_id, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	board := &model.Board{ProjectID: project_id}
+	columns := map[string]*model.BoardColumn{}
+	for _, status := range BoardStatuses {
+		column := &model.BoardColumn{Status: status, Tasks: []*model.Task{}}
+		columns[status] = column
+		board.Columns = append(board.Columns, column)
+	}
+	for _, task := range tasks {
+		column, ok := columns[task.Status]
+		if !ok {
+			// статусы вне стандартного набора получают свои колонки в конце доски
+			column = &model.BoardColumn{Status: task.Status, Tasks: []*model.Task{}}
+			columns[task.Status] = column
+			board.Columns = append(board.Columns, column)
+		}
+		column.Tasks = append(column.Tasks, task)
+	}
+	return board, nil
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"pet-project/internal/ical"
+	"pet-project/internal/repository"
+	"pet-project/pkg/model"
+	"strings"
+	"time"
+)
+
+var ErrCalendarFeedNotFound = errors.New("Calendar feed not found")
+
+const (
+	// calendarQuery — задачи со сроком; закрытые остаются в ленте месяц, чтобы клиент увидел их завершение
+	calendarQuery = "due IS NOT EMPTY AND (status != done OR updated > now-30d)"
+	// calendarRefresh — рекомендуемый клиентам период обновления подписки
+	calendarRefresh = time.Hour
+)
+
+type CalendarService struct {
+	Repository repository.CalendarRepository
+	Tasks      *TaskService
+	Projects   repository.ProjectRepository
+	// AppURL — внешний адрес приложения: из него строятся ссылки на ленту и задачи и домен в UID записей
+	AppURL string
+}
+
+// CreateFeed выпускает новую ленту; токен в URL и есть доступ к ней, поэтому сохраняется только его хеш
+func (s *CalendarService) CreateFeed(feed *model.CalendarFeed) error {
+	if feed.ProjectID != nil {
+		if err := s.checkProjectAccess(*feed.ProjectID, feed.UserID); err != nil {
+			return err
+		}
+	}
+	token, err := generateSecret()
+	if err != nil {
+		return err
+	}
+	feed.TokenHash = calendarTokenHash(token)
+	feed.CreatedAt = time.Now()
+	if err := s.Repository.CreateFeed(feed); err != nil {
+		return err
+	}
+	feed.Token = token
+	feed.URL = s.FeedURL(token)
+	return nil
+}
+
+func (s *CalendarService) FeedURL(token string) string {
+	return strings.TrimRight(s.AppURL, "/") + "/calendar/" + token + ".ics"
+}
+
+func (s *CalendarService) ListFeeds(user_id int) ([]*model.CalendarFeed, error) {
+	return s.Repository.ListFeeds(user_id)
+}
+
+// DeleteFeed отзывает ленту: клиенты, подписанные по её адресу, перестанут получать обновления
+func (s *CalendarService) DeleteFeed(feed_id int, user_id int) error {
+	feed, err := s.Repository.GetFeedByID(feed_id)
+	if err != nil || feed.UserID != user_id {
+		return ErrCalendarFeedNotFound
+	}
+	return s.Repository.DeleteFeed(feed.ID)
+}
+
+// WriteFeed пишет ленту по токену. kind — ical.KindEvent (по умолчанию, его понимают все клиенты)
+// или ical.KindTodo. Доступ к проекту проверяется при каждом запросе: ленты участника,
+// удалённого из проекта, становятся пустыми.
+func (s *CalendarService) WriteFeed(w io.Writer, token string, kind string) error {
+	feed, err := s.Repository.GetFeedByTokenHash(calendarTokenHash(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrCalendarFeedNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	filter := model.TaskFilter{Query: calendarQuery, Viewer: feed.UserID}
+	name := "Мои задачи"
+	var tasks []*model.Task
+	if feed.ProjectID == nil {
+		filter.Query = "assignee = me AND " + calendarQuery
+		tasks, err = s.Tasks.ListAccessibleTasks(feed.UserID, filter)
+	} else {
+		if err := s.checkProjectAccess(*feed.ProjectID, feed.UserID); err != nil {
+			return ErrCalendarFeedNotFound
+		}
+		if project, err := s.Projects.GetByIDProject(*feed.ProjectID); err == nil {
+			name = project.Name
+		}
+		tasks, err = s.Tasks.ListByProjectTask(*feed.ProjectID, filter)
+	}
+	if err != nil {
+		return err
+	}
+
+	cal := &ical.Calendar{ProdID: "-//pet-project//tasks//RU", Name: name, RefreshInterval: calendarRefresh}
+	for _, task := range tasks {
+		cal.Items = append(cal.Items, s.calendarItem(task, kind))
+	}
+	return ical.Write(w, cal)
+}
+
+// calendarItem переводит задачу в запись календаря. UID постоянен для задачи, а SEQUENCE — секунды
+// от создания до последнего изменения: он растёт с каждым изменением, и клиент заменяет старую версию.
+func (s *CalendarService) calendarItem(task *model.Task, kind string) ical.Item {
+	link := strings.TrimRight(s.AppURL, "/") + fmt.Sprintf("/tasks/%d", task.ID)
+	item := ical.Item{
+		Kind:     kind,
+		UID:      fmt.Sprintf("task-%d@%s", task.ID, s.uidDomain()),
+		Sequence: int(task.UpdatedAt.Sub(task.CreatedAt) / time.Second),
+		Stamp:    task.UpdatedAt,
+		Created:  task.CreatedAt,
+		Modified: task.UpdatedAt,
+		Summary:  task.Title,
+		URL:      link,
+		Priority: calendarPriority(task.Priority),
+		Due:      *task.DueDate,
+		// срок ровно в полночь — это срок «на день», без времени
+		AllDay: task.DueDate.Hour() == 0 && task.DueDate.Minute() == 0 && task.DueDate.Second() == 0,
+	}
+
+	description := task.Description
+	if description != "" {
+		description += "\n\n"
+	}
+	item.Description = description + link
+
+	item.Categories = []string{task.Status}
+	for _, label := range task.Labels {
+		item.Categories = append(item.Categories, label.Name)
+	}
+
+	if kind == ical.KindTodo {
+		switch task.Status {
+		case "done":
+			item.Status = "COMPLETED"
+			completed := task.UpdatedAt
+			item.Completed = &completed
+		case "in_progress":
+			item.Status = "IN-PROCESS"
+		default:
+			item.Status = "NEEDS-ACTION"
+		}
+	} else {
+		item.Status = "CONFIRMED"
+		if task.Status == "done" {
+			item.Summary = "✓ " + item.Summary
+		}
+	}
+	return item
+}
+
+func (s *CalendarService) uidDomain() string {
+	if u, err := url.Parse(s.AppURL); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	return "localhost"
+}
+
+func (s *CalendarService) checkProjectAccess(project_id int, user_id int) error {
+	ok, err := s.Repository.CanAccessProject(project_id, user_id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("Project not found")
+	}
+	return nil
+}
+
+// calendarPriority — PRIORITY по RFC 5545: 1 высший, 5 средний, 9 низший
+func calendarPriority(priority string) int {
+	switch priority {
+	case "high":
+		return 1
+	case "medium":
+		return 5
+	case "low":
+		return 9
+	}
+	return 0
+}
+
+func calendarTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
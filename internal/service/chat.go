@@ -0,0 +1,360 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"pet-project/internal/chat"
+	"pet-project/internal/repository"
+	"pet-project/pkg/model"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultChatLinkTTL = 15 * time.Minute
+	chatListLimit      = 20
+	chatCommentPreview = 200
+)
+
+var (
+	ErrChatIntegrationNotFound = errors.New("Chat integration not found")
+	ErrChatSignature           = errors.New("Invalid signature")
+)
+
+var taskPriorities = []string{"low", "medium", "high"}
+
+const chatHelp = "Commands:\n" +
+	"`/task create [low|medium|high] <title>` — create a task\n" +
+	"`/task list` — open tasks of the project, `/task list mine` — yours\n" +
+	"`/task done <id>` — close a task\n" +
+	"`/task link <code>` — link your chat account (get the code in the app)"
+
+// ChatService обслуживает интеграции со Slack и Mattermost: выполняет slash-команды от имени
+// привязанного пользователя и публикует события проекта во входящий webhook канала.
+type ChatService struct {
+	Repository repository.ChatRepository
+	Tasks      *TaskService
+	Comments   repository.CommentsRepository
+	Client     *http.Client
+	// LinkTTL — сколько действует код привязки аккаунта, 0 — 15 минут
+	LinkTTL time.Duration
+}
+
+func (s *ChatService) CreateIntegration(integration *model.ChatIntegration) error {
+	if err := validateChatIntegration(integration); err != nil {
+		return err
+	}
+	now := time.Now()
+	integration.CreatedAt = now
+	integration.UpdatedAt = now
+	return s.Repository.CreateIntegration(integration)
+}
+
+func (s *ChatService) GetIntegration(integration_id int) (*model.ChatIntegration, error) {
+	return s.Repository.GetIntegrationByID(integration_id)
+}
+
+func (s *ChatService) ListIntegrations(project_id int) ([]*model.ChatIntegration, error) {
+	return s.Repository.ListIntegrations(project_id)
+}
+
+func (s *ChatService) UpdateIntegration(integration *model.ChatIntegration) error {
+	if err := validateChatIntegration(integration); err != nil {
+		return err
+	}
+	integration.UpdatedAt = time.Now()
+	return s.Repository.UpdateIntegration(integration)
+}
+
+func (s *ChatService) DeleteIntegration(integration_id int) error {
+	return s.Repository.DeleteIntegration(integration_id)
+}
+
+// validateChatIntegration: Secret выдаёт мессенджер при создании команды, поэтому он обязателен
+func validateChatIntegration(integration *model.ChatIntegration) error {
+	if !containsString(chat.Providers, integration.Provider) {
+		return fmt.Errorf("Provider must be one of %v", chat.Providers)
+	}
+	if strings.TrimSpace(integration.Secret) == "" {
+		return errors.New("Secret from the slash command settings is required")
+	}
+	integration.WebhookURL = strings.TrimSpace(integration.WebhookURL)
+	if integration.WebhookURL != "" {
+		target, err := url.Parse(integration.WebhookURL)
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			return errors.New("Webhook URL must be an absolute http(s) URL")
+		}
+	}
+
+	// события те же, что у webhooks; пустой список — все
+	if len(integration.Events) == 0 {
+		integration.Events = append([]string{}, webhookEvents...)
+		return nil
+	}
+	events := make([]string, 0, len(integration.Events))
+	for _, event := range integration.Events {
+		if !containsString(webhookEvents, event) {
+			return fmt.Errorf("Unknown event %q", event)
+		}
+		if !containsString(events, event) {
+			events = append(events, event)
+		}
+	}
+	integration.Events = events
+	return nil
+}
+
+// LinkCode выпускает для пользователя код, которым он привяжет свой аккаунт командой /task link
+func (s *ChatService) LinkCode(integration *model.ChatIntegration, user_id int) (string, time.Time) {
+	ttl := s.LinkTTL
+	if ttl <= 0 {
+		ttl = defaultChatLinkTTL
+	}
+	expires := time.Now().Add(ttl)
+	return chat.LinkCode(integration.Secret, user_id, expires), expires
+}
+
+// HandleCommand проверяет подпись и выполняет slash-команду. Ошибки пользователя (нет привязки,
+// чужая задача, неверные аргументы) возвращаются текстом ответа, а не ошибкой.
+func (s *ChatService) HandleCommand(ctx context.Context, integration_id int, header http.Header, body []byte) (*chat.Response, error) {
+	integration, err := s.Repository.GetIntegrationByID(integration_id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !integration.Active) {
+		return nil, ErrChatIntegrationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !chat.Verify(integration.Provider, integration.Secret, header, body, time.Now()) {
+		return nil, ErrChatSignature
+	}
+	cmd, err := chat.ParseCommand(body)
+	if err != nil {
+		return chat.Ephemeral("%v", err), nil
+	}
+
+	name, args, _ := strings.Cut(cmd.Text, " ")
+	args = strings.TrimSpace(args)
+	if strings.EqualFold(name, "link") {
+		return s.linkAccount(ctx, integration, cmd, args)
+	}
+	if name == "" || strings.EqualFold(name, "help") {
+		return chat.Ephemeral("%s", chatHelp), nil
+	}
+
+	account, err := s.Repository.GetAccount(ctx, integration.ID, cmd.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return chat.Ephemeral("Your chat account is not linked yet. Get a code in the app and run `/task link <code>`."), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(name) {
+	case "create":
+		return s.createTask(integration, account, args)
+	case "list":
+		return s.listTasks(integration, account, args)
+	case "done":
+		return s.closeTask(integration, account, args)
+	}
+	return chat.Ephemeral("Unknown command `%s`.\n%s", name, chatHelp), nil
+}
+
+func (s *ChatService) linkAccount(ctx context.Context, integration *model.ChatIntegration, cmd *chat.Command, code string) (*chat.Response, error) {
+	user_id, ok := chat.ParseLinkCode(integration.Secret, code, time.Now())
+	if !ok {
+		return chat.Ephemeral("The code is invalid or expired. Get a new one in the app."), nil
+	}
+	account := &model.ChatAccount{
+		IntegrationID: integration.ID,
+		ChatUserID:    cmd.UserID,
+		ChatUserName:  cmd.UserName,
+		UserID:        user_id,
+		LinkedAt:      time.Now(),
+	}
+	if err := s.Repository.LinkAccount(ctx, account); err != nil {
+		return nil, err
+	}
+	return chat.Ephemeral("Your chat account is linked. Try `/task list mine`."), nil
+}
+
+func (s *ChatService) createTask(integration *model.ChatIntegration, account *model.ChatAccount, args string) (*chat.Response, error) {
+	priority := "medium"
+	if first, rest, _ := strings.Cut(args, " "); containsString(taskPriorities, strings.ToLower(first)) {
+		priority, args = strings.ToLower(first), strings.TrimSpace(rest)
+	}
+	if args == "" {
+		return chat.Ephemeral("Usage: `/task create [low|medium|high] <title>`"), nil
+	}
+
+	task := &model.Task{
+		Title:      args,
+		Status:     BoardStatuses[0],
+		Priority:   priority,
+		AssignedTo: account.UserID,
+		ProjectID:  integration.ProjectID,
+	}
+	if err := s.Tasks.CreateTask(task); err != nil {
+		return chat.Ephemeral("Failed to create task: %v", err), nil
+	}
+	return chat.InChannel("Task #%d \"%s\" created (%s priority)", task.ID, task.Title, task.Priority), nil
+}
+
+func (s *ChatService) listTasks(integration *model.ChatIntegration, account *model.ChatAccount, args string) (*chat.Response, error) {
+	filter := model.TaskFilter{Query: "status != done", Viewer: account.UserID}
+	title := "Open tasks"
+	switch strings.ToLower(args) {
+	case "":
+	case "mine":
+		filter.Query, title = "assignee = me AND status != done", "Your open tasks"
+	default:
+		return chat.Ephemeral("Usage: `/task list` or `/task list mine`"), nil
+	}
+
+	tasks, err := s.Tasks.ListByProjectTask(integration.ProjectID, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		return chat.Ephemeral("%s: none", title), nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%d):", title, len(tasks))
+	for i, task := range tasks {
+		if i == chatListLimit {
+			fmt.Fprintf(&b, "\n…and %d more", len(tasks)-chatListLimit)
+			break
+		}
+		fmt.Fprintf(&b, "\n• #%d %s — %s, %s", task.ID, task.Title, task.Status, task.Priority)
+	}
+	return chat.Ephemeral("%s", b.String()), nil
+}
+
+func (s *ChatService) closeTask(integration *model.ChatIntegration, account *model.ChatAccount, args string) (*chat.Response, error) {
+	task_id, err := strconv.Atoi(strings.TrimPrefix(args, "#"))
+	if err != nil {
+		return chat.Ephemeral("Usage: `/task done <id>`"), nil
+	}
+	task, err := s.Tasks.Repository.GetByIDTask(task_id)
+	if err != nil || task.ProjectID != integration.ProjectID {
+		return chat.Ephemeral("Task #%d not found in this project", task_id), nil
+	}
+	if task.Status == "done" {
+		return chat.Ephemeral("Task #%d is already done", task_id), nil
+	}
+
+	old := *task
+	task.Status = "done"
+	task.UpdatedAt = time.Now()
+	if err := s.Tasks.saveTask(task, &old, nil, taskUpdatedEvent(&old, task, account.UserID)); err != nil {
+		return chat.Ephemeral("Failed to close task #%d: %v", task_id, err), nil
+	}
+	return chat.InChannel("Task #%d \"%s\" is done", task.ID, task.Title), nil
+}
+
+// HandleEvent — подписчик outbox: публикует событие в каналы интеграций проекта. Канал, куда
+// событие уже отправлено, при повторной доставке пропускается.
+func (s *ChatService) HandleEvent(ctx context.Context, msg *model.OutboxMessage) error {
+	event, err := decodeEvent(msg)
+	if err != nil {
+		return err
+	}
+	integrations, err := s.Repository.ListSubscribed(ctx, event.ProjectID, event.Type)
+	if err != nil || len(integrations) == 0 {
+		return err
+	}
+
+	text, err := s.formatEvent(ctx, event)
+	if err != nil || text == "" {
+		return err
+	}
+
+	var failed error
+	for _, integration := range integrations {
+		sent, err := s.Repository.IsSent(ctx, integration.ID, event.ID)
+		if err != nil {
+			return err
+		}
+		if sent {
+			continue
+		}
+		if err := chat.Post(ctx, s.Client, integration.WebhookURL, text); err != nil {
+			failed = fmt.Errorf("chat integration %d: %w", integration.ID, err)
+			continue
+		}
+		if err := s.Repository.MarkSent(ctx, integration.ID, event.ID); err != nil {
+			return err
+		}
+	}
+	return failed
+}
+
+// formatEvent собирает текст сообщения; пустая строка — задачу уже удалили и писать не о чем
+func (s *ChatService) formatEvent(ctx context.Context, event model.ProjectEvent) (string, error) {
+	task, err := s.Tasks.Repository.GetByIDTask(event.TaskID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	actor := "Someone"
+	if event.ActorID != 0 {
+		if name, err := s.Repository.UserName(ctx, event.ActorID); err == nil && name != "" {
+			actor = name
+		}
+	}
+	subject := fmt.Sprintf("task #%d \"%s\"", task.ID, task.Title)
+
+	switch event.Type {
+	case model.EventTaskCreated:
+		return fmt.Sprintf("%s created %s (%s, %s priority)", actor, subject, task.Status, task.Priority), nil
+	case model.EventTaskUpdated:
+		if change, ok := event.Changes["status"]; ok && len(event.Changes) == 1 {
+			return fmt.Sprintf("%s moved %s to %s", actor, subject, change.New), nil
+		}
+		changes := make([]string, 0, len(event.Changes))
+		for _, field := range []string{"status", "priority", "title", "assignee", "due", "description"} {
+			change, ok := event.Changes[field]
+			if !ok {
+				continue
+			}
+			if field == "description" {
+				changes = append(changes, "description")
+				continue
+			}
+			changes = append(changes, fmt.Sprintf("%s: %s → %s", field, orNone(change.Old), orNone(change.New)))
+		}
+		return fmt.Sprintf("%s updated %s: %s", actor, subject, strings.Join(changes, ", ")), nil
+	case model.EventCommentCreated:
+		text := ""
+		if s.Comments != nil && event.CommentID != 0 {
+			if com, err := s.Comments.GetCommentByID(event.CommentID); err == nil {
+				text = com.Text
+			}
+		}
+		if utf8.RuneCountInString(text) > chatCommentPreview {
+			text = string([]rune(text)[:chatCommentPreview]) + "…"
+		}
+		if text == "" {
+			return fmt.Sprintf("%s commented on %s", actor, subject), nil
+		}
+		return fmt.Sprintf("%s commented on %s:\n> %s", actor, subject, strings.ReplaceAll(text, "\n", "\n> ")), nil
+	}
+	return "", nil
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
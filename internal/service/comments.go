@@ -2,13 +2,19 @@ package service
 
 import (
 	"errors"
+	"log"
+	"pet-project/internal/markdown"
 	"pet-project/internal/repository"
 	"pet-project/pkg/model"
 	"time"
 )
 
+// DeletedCommentText — заглушка, которая показывается в ветке вместо удалённого комментария
+const DeletedCommentText = "This comment was deleted"
+
 type CommentsService struct {
 	Repository repository.CommentsRepository
+	Tasks      *TaskService
 }
 
 func (s *CommentsService) AddComment(com *model.Comments) error {
@@ -21,17 +27,54 @@ func (s *CommentsService) AddComment(com *model.Comments) error {
 	}
 
 	com.CreatedAt = time.Now()
-	com.UpdatedAt = time.Now()
+	com.UpdatedAt = com.CreatedAt
 
-	err := s.Repository.AddComment(com)
+	// Участники задачи, автоматизация и webhooks узнают о комментарии через outbox
+	err := s.Repository.AddComment(com, &model.ProjectEvent{Type: model.EventCommentCreated, ActorID: com.UserID})
 	if err != nil {
 		return err
 	}
+	renderText(com)
+
+	// Автор комментария автоматически подписывается на задачу, если у него есть доступ к проекту:
+	// подписка сама даёт доступ, и раздавать его за комментарий нельзя
+	if s.Tasks != nil && s.Tasks.Members != nil {
+		if err := s.watchAsCommenter(com); err != nil {
+			log.Println("Failed to add comment author to watchers:", err)
+		}
+	}
 	return nil
 }
 
-func (s *CommentsService) DeleteComment(com_id int) error {
-	err := s.Repository.DeleteComment(com_id)
+func (s *CommentsService) watchAsCommenter(com *model.Comments) error {
+	task, err := s.Tasks.Repository.GetByIDTask(com.TaskID)
+	if err != nil {
+		return err
+	}
+	if err := s.Tasks.checkAccess(task, com.UserID); err != nil {
+		if errors.Is(err, ErrNoProjectAccess) {
+			return nil
+		}
+		return err
+	}
+	return s.Tasks.Members.AddWatcher(com.TaskID, com.UserID)
+}
+
+func (s *CommentsService) DeleteComment(com_id int, user_id int) error {
+	com, err := s.Repository.GetCommentByID(com_id)
+	if err != nil {
+		return err
+	}
+
+	if com.Deleted {
+		return errors.New("Comment is already deleted")
+	}
+
+	if com.UserID != user_id {
+		return errors.New("No permission to delete comment")
+	}
+
+	err = s.Repository.DeleteComment(com_id, user_id)
 	if err != nil {
 		return err
 	}
@@ -44,6 +87,13 @@ func (s *CommentsService) GetCommentsByTask(task_id int) ([]*model.Comments, err
 	if err != nil {
 		return nil, err
 	}
+	for _, com := range comments {
+		if com.Deleted {
+			com.Text = DeletedCommentText
+			continue
+		}
+		renderText(com)
+	}
 	return comments, nil
 }
 
@@ -52,13 +102,62 @@ func (s *CommentsService) GetCommentsByUser(user_id int) ([]*model.Comments, err
 	if err != nil {
 		return nil, err
 	}
+	for _, com := range comments {
+		renderText(com)
+	}
 	return comments, nil
 }
 
-func (s *CommentsService) UpdateCommentText(com_id int, new_text string) error {
-	err := s.Repository.UpdateCommentText(com_id, new_text)
+func (s *CommentsService) UpdateCommentText(com_id int, new_text string, editor_id int) (*model.Comments, error) {
+	if new_text == "" {
+		return nil, errors.New("Comment required text")
+	}
+
+	com, err := s.Repository.GetCommentByID(com_id)
 	if err != nil {
-		return errors.New("Invalid update")
+		return nil, err
 	}
-	return nil
+
+	if com.Deleted {
+		return nil, errors.New("Deleted comment can't be edited")
+	}
+
+	if com.UserID != editor_id {
+		return nil, errors.New("No permission to edit comment")
+	}
+
+	if com.Text != new_text {
+		err = s.Repository.UpdateCommentText(com_id, new_text, editor_id)
+		if err != nil {
+			return nil, errors.New("Invalid update")
+		}
+		com, err = s.Repository.GetCommentByID(com_id)
+		if err != nil {
+			return nil, err
+		}
+	}
+	renderText(com)
+	return com, nil
+}
+
+func (s *CommentsService) GetCommentRevisions(com_id int) ([]*model.CommentRevision, error) {
+	com, err := s.Repository.GetCommentByID(com_id)
+	if err != nil {
+		return nil, err
+	}
+
+	if com.Deleted {
+		return nil, errors.New("Comment was deleted")
+	}
+
+	revisions, err := s.Repository.GetCommentRevisions(com_id)
+	if err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// renderText заполняет HTML-представление комментария и найденные в нём ссылки
+func renderText(com *model.Comments) {
+	com.HTML, com.References = markdown.Render(com.Text)
 }
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"pet-project/internal/repository"
+	"pet-project/pkg/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxCustomTextLength = 1000
+
+var customFieldOps = map[string][]string{
+	model.CustomFieldText:        {"eq", "ne", "contains"},
+	model.CustomFieldNumber:      {"eq", "ne", "gt", "gte", "lt", "lte"},
+	model.CustomFieldDate:        {"eq", "ne", "gt", "gte", "lt", "lte"},
+	model.CustomFieldUser:        {"eq", "ne"},
+	model.CustomFieldSelect:      {"eq", "ne"},
+	model.CustomFieldMultiSelect: {"eq", "ne"},
+}
+
+type CustomFieldService struct {
+	Repository repository.CustomFieldRepository
+}
+
+func (s *CustomFieldService) CreateField(field *model.CustomField) error {
+	if err := validateFieldDefinition(field); err != nil {
+		return err
+	}
+	field.CreatedAt = time.Now()
+	if err := s.Repository.CreateField(field); err != nil {
+		return errors.New("Field with this name already exists")
+	}
+	return nil
+}
+
+func (s *CustomFieldService) GetField(field_id int) (*model.CustomField, error) {
+	return s.Repository.GetFieldByID(field_id)
+}
+
+// UpdateField меняет имя, варианты и обязательность; тип поля после создания не меняется
+func (s *CustomFieldService) UpdateField(field *model.CustomField) error {
+	if err := validateFieldDefinition(field); err != nil {
+		return err
+	}
+	if err := s.Repository.UpdateField(field); err != nil {
+		return errors.New("Field with this name already exists")
+	}
+	return nil
+}
+
+func (s *CustomFieldService) DeleteField(field_id int) error {
+	return s.Repository.DeleteField(field_id)
+}
+
+func (s *CustomFieldService) ListByProject(project_id int) ([]*model.CustomField, error) {
+	return s.Repository.ListByProject(project_id)
+}
+
+func validateFieldDefinition(field *model.CustomField) error {
+	field.Name = strings.TrimSpace(field.Name)
+	if field.Name == "" {
+		return errors.New("Field name is required")
+	}
+	if _, ok := customFieldOps[field.Type]; !ok {
+		return errors.New("Invalid field type, use text, number, date, select, multiselect or user")
+	}
+
+	if field.Type != model.CustomFieldSelect && field.Type != model.CustomFieldMultiSelect {
+		field.Options = []string{}
+		return nil
+	}
+
+	seen := map[string]bool{}
+	options := []string{}
+	for _, opt := range field.Options {
+		opt = strings.TrimSpace(opt)
+		if opt == "" || seen[opt] {
+			continue
+		}
+		seen[opt] = true
+		options = append(options, opt)
+	}
+	if len(options) == 0 {
+		return errors.New("Select field requires options")
+	}
+	field.Options = options
+	return nil
+}
+
+func findField(fields []*model.CustomField, name string) *model.CustomField {
+	for _, field := range fields {
+		if strings.EqualFold(field.Name, strings.TrimSpace(name)) {
+			return field
+		}
+	}
+	return nil
+}
+
+// parseCustomFieldValue проверяет значение из JSON (nil очищает поле) и приводит его к типу поля
+func parseCustomFieldValue(field *model.CustomField, raw interface{}) (*model.CustomFieldValue, error) {
+	value := &model.CustomFieldValue{FieldID: field.ID, Name: field.Name, Type: field.Type}
+	if raw == nil {
+		if field.Required {
+			return nil, fmt.Errorf("Field %q is required", field.Name)
+		}
+		return value, nil
+	}
+
+	invalid := fmt.Errorf("Invalid value for %s field %q", field.Type, field.Name)
+
+	switch field.Type {
+	case model.CustomFieldText:
+		str, ok := raw.(string)
+		if !ok || len(str) > maxCustomTextLength {
+			return nil, invalid
+		}
+		value.Text = &str
+	case model.CustomFieldNumber:
+		num, err := toNumber(raw)
+		if err != nil {
+			return nil, invalid
+		}
+		value.Number = &num
+	case model.CustomFieldDate:
+		str, ok := raw.(string)
+		if !ok {
+			return nil, invalid
+		}
+		date, err := parseFieldDate(str)
+		if err != nil {
+			return nil, invalid
+		}
+		value.Date = &date
+	case model.CustomFieldUser:
+		num, err := toNumber(raw)
+		if err != nil || num <= 0 || num != math.Trunc(num) {
+			return nil, invalid
+		}
+		userID := int(num)
+		value.UserID = &userID
+	case model.CustomFieldSelect:
+		str, ok := raw.(string)
+		if !ok || !containsString(field.Options, str) {
+			return nil, fmt.Errorf("Value for field %q must be one of %s", field.Name, strings.Join(field.Options, ", "))
+		}
+		value.Options = []string{str}
+	case model.CustomFieldMultiSelect:
+		items, ok := raw.([]interface{})
+		if !ok {
+			return nil, invalid
+		}
+		value.Options = []string{}
+		for _, item := range items {
+			str, ok := item.(string)
+			if !ok || !containsString(field.Options, str) {
+				return nil, fmt.Errorf("Values for field %q must be from %s", field.Name, strings.Join(field.Options, ", "))
+			}
+			if !containsString(value.Options, str) {
+				value.Options = append(value.Options, str)
+			}
+		}
+		if len(value.Options) == 0 && field.Required {
+			return nil, fmt.Errorf("Field %q is required", field.Name)
+		}
+	}
+	return value, nil
+}
+
+// resolveCondition находит поле условия и приводит строковое значение из запроса к его типу
+func resolveCondition(fields []*model.CustomField, cond *model.CustomFieldCondition) error {
+	field := findField(fields, cond.Field)
+	if field == nil {
+		return fmt.Errorf("Unknown field %q", cond.Field)
+	}
+	if cond.Op == "" {
+		cond.Op = "eq"
+	}
+	if !containsString(customFieldOps[field.Type], cond.Op) {
+		return fmt.Errorf("Operator %s is not supported for %s field %q", cond.Op, field.Type, field.Name)
+	}
+
+	cond.FieldID = field.ID
+	cond.Type = field.Type
+
+	switch field.Type {
+	case model.CustomFieldNumber:
+		num, err := strconv.ParseFloat(cond.Raw, 64)
+		if err != nil {
+			return fmt.Errorf("Invalid number for field %q", field.Name)
+		}
+		cond.Value = num
+	case model.CustomFieldDate:
+		date, err := parseFieldDate(cond.Raw)
+		if err != nil {
+			return fmt.Errorf("Invalid date for field %q", field.Name)
+		}
+		cond.Value = date
+	case model.CustomFieldUser:
+		userID, err := strconv.Atoi(cond.Raw)
+		if err != nil {
+			return fmt.Errorf("Invalid user ID for field %q", field.Name)
+		}
+		cond.Value = userID
+	default:
+		cond.Value = cond.Raw
+	}
+	return nil
+}
+
+func toNumber(raw interface{}) (float64, error) {
+	switch v := raw.(type) {
+	case float64:
+		return v, nil
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(v), 64)
+	}
+	return 0, errors.New("not a number")
+}
+
+func parseFieldDate(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"errors"
+	"pet-project/internal/repository"
+	"pet-project/pkg/model"
+	"time"
+)
+
+type DependencyService struct {
+	Repository repository.DependencyRepository
+	Tasks      repository.TaskRepository
+}
+
+// AddDependency создаёт связь «blocker_id блокирует blocked_id»
+func (s *DependencyService) AddDependency(blocker_id, blocked_id int) (*model.TaskDependency, error) {
+	if blocker_id == blocked_id {
+		return nil, errors.New("Task can't block itself")
+	}
+
+	blocker, err := s.Tasks.GetByIDTask(blocker_id)
+	if err != nil {
+		return nil, errors.New("Blocking task not found")
+	}
+	blocked, err := s.Tasks.GetByIDTask(blocked_id)
+	if err != nil {
+		return nil, errors.New("Blocked task not found")
+	}
+	if blocker.ProjectID != blocked.ProjectID {
+		return nil, errors.New("Dependent tasks must belong to the same project")
+	}
+
+	dep := &model.TaskDependency{
+		BlockerID: blocker_id,
+		BlockedID: blocked_id,
+		CreatedAt: time.Now(),
+	}
+	// Цикл проверяется при вставке: проверка отдельно от неё не защищает от встречных связей
+	if err := s.Repository.AddDependency(blocker.ProjectID, dep); err != nil {
+		return nil, err
+	}
+	return dep, nil
+}
+
+func (s *DependencyService) GetTask(task_id int) (*model.Task, error) {
+	return s.Tasks.GetByIDTask(task_id)
+}
+
+func (s *DependencyService) RemoveDependency(blocker_id, blocked_id int) error {
+	return s.Repository.RemoveDependency(blocker_id, blocked_id)
+}
+
+func (s *DependencyService) ListBlockers(task_id int) ([]*model.Task, error) {
+	return s.Repository.ListBlockers(task_id)
+}
+
+func (s *DependencyService) GetProjectGraph(project_id int) (*model.DependencyGraph, error) {
+	return s.Repository.GetProjectGraph(project_id)
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"pet-project/internal/mailer"
+	"pet-project/internal/repository"
+	"pet-project/pkg/model"
+	"strconv"
+	"time"
+)
+
+// digestLockKey — ключ advisory-блокировки, под которой сводки рассылает только одна реплика
+const digestLockKey int64 = 0x646967657374 // "digest"
+
+// digestLimit — сколько уведомлений показывать в одной сводке, остальные только считаются
+const digestLimit = 50
+
+var emailModes = []string{model.EmailOff, model.EmailInstant, model.EmailHourly, model.EmailDaily}
+
+// EmailService отправляет уведомления на почту: в режиме instant — каждое сразу (подписчик outbox),
+// в режимах hourly и daily — сводкой непрочитанных раз в час или в сутки.
+type EmailService struct {
+	Repository repository.EmailRepository
+	Locker     repository.Locker
+	// Mailer — nil, если почта не настроена: настройки сохраняются, но письма не отправляются
+	Mailer    mailer.Mailer
+	Templates *mailer.Templates
+	From      string
+	// DefaultMode — режим для тех, кто его не выбирал
+	DefaultMode string
+	// ReplyDomain и ReplySecret задают адрес Reply-To в письмах о задачах: ответ станет комментарием.
+	// Пустой ReplyDomain — ответы не принимаются.
+	ReplyDomain string
+	ReplySecret string
+}
+
+type notificationEmail struct {
+	Name         string
+	Notification model.Notification
+}
+
+type digestEmail struct {
+	Name          string
+	Period        string
+	Notifications []model.Notification
+	More          int
+}
+
+// GetPreference возвращает настройки пользователя; если он их не менял — режим по умолчанию
+func (s *EmailService) GetPreference(ctx context.Context, user_id int) (*model.NotificationPreference, error) {
+	pref, err := s.Repository.GetPreference(ctx, user_id)
+	if errors.Is(err, sql.ErrNoRows) {
+		pref, err = &model.NotificationPreference{UserID: user_id}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if pref.EmailMode == "" {
+		pref.EmailMode = s.defaultMode()
+	}
+	return pref, nil
+}
+
+func (s *EmailService) UpdatePreference(ctx context.Context, user_id int, mode string) (*model.NotificationPreference, error) {
+	if !containsString(emailModes, mode) {
+		return nil, fmt.Errorf("Email mode must be one of %v", emailModes)
+	}
+	pref := &model.NotificationPreference{UserID: user_id, EmailMode: mode, UpdatedAt: time.Now()}
+	if err := s.Repository.SavePreference(ctx, pref); err != nil {
+		return nil, err
+	}
+	return pref, nil
+}
+
+func (s *EmailService) defaultMode() string {
+	if s.DefaultMode == "" {
+		return model.EmailDaily
+	}
+	return s.DefaultMode
+}
+
+// HandleNotification — подписчик outbox: отправляет письмо о новом уведомлении тем, кто выбрал
+// режим instant. Отправленное уведомление отмечается и при повторной доставке не дублируется.
+func (s *EmailService) HandleNotification(ctx context.Context, msg *model.OutboxMessage) error {
+	if s.Mailer == nil {
+		return nil
+	}
+	var notif model.Notification
+	if err := json.Unmarshal([]byte(msg.Payload), &notif); err != nil {
+		return fmt.Errorf("Invalid notification payload: %w", err)
+	}
+
+	rcpt, err := s.Repository.GetRecipient(ctx, notif.UserID, s.defaultMode())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if rcpt.Mode != model.EmailInstant || rcpt.Email == "" {
+		return nil
+	}
+
+	emailed, err := s.Repository.IsEmailed(ctx, notif.ID)
+	if errors.Is(err, sql.ErrNoRows) || emailed {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	subject, text, html, err := s.Templates.Render(notif.Type, notificationEmail{Name: displayName(rcpt), Notification: notif})
+	if err != nil {
+		return err
+	}
+	headers := map[string]string{"X-Notification-ID": strconv.Itoa(notif.ID)}
+	if notif.TaskID != nil && s.ReplyDomain != "" {
+		headers["Reply-To"] = mailer.ReplyAddress(s.ReplySecret, s.ReplyDomain, *notif.TaskID, notif.UserID)
+	}
+	err = s.Mailer.Send(ctx, &mailer.Message{
+		From:    s.From,
+		To:      []string{rcpt.Email},
+		Subject: subject,
+		Text:    text,
+		HTML:    html,
+		Headers: headers,
+	})
+	if err != nil {
+		return err
+	}
+	return s.Repository.MarkEmailed(ctx, []int{notif.ID}, time.Now())
+}
+
+// Run рассылает наступившие сводки раз в interval. Блокирует до отмены ctx.
+func (s *EmailService) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 || s.Mailer == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.SendDigests(ctx, time.Now()); err != nil {
+			log.Println("Failed to send email digests:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDigests отправляет сводку каждому, у кого с прошлой сводки прошёл час или сутки и есть
+// непрочитанные неотправленные уведомления. Ошибка с одним адресатом не мешает остальным.
+func (s *EmailService) SendDigests(ctx context.Context, now time.Time) error {
+	unlock, ok, err := s.Locker.TryLock(ctx, digestLockKey)
+	if err != nil || !ok {
+		return err
+	}
+	defer unlock()
+
+	recipients, err := s.Repository.ListDigestRecipients(ctx, s.defaultMode())
+	if err != nil {
+		return err
+	}
+	for _, rcpt := range recipients {
+		period := time.Hour
+		if rcpt.Mode == model.EmailDaily {
+			period = 24 * time.Hour
+		}
+		if rcpt.Email == "" || (rcpt.LastDigestAt != nil && now.Before(rcpt.LastDigestAt.Add(period))) {
+			continue
+		}
+		if err := s.sendDigest(ctx, rcpt, now); err != nil {
+			log.Printf("Failed to send digest to user %d: %v", rcpt.UserID, err)
+		}
+	}
+	return nil
+}
+
+func (s *EmailService) sendDigest(ctx context.Context, rcpt *model.EmailRecipient, now time.Time) error {
+	notifications, total, err := s.Repository.ListUnsent(ctx, rcpt.UserID, digestLimit)
+	if err != nil || len(notifications) == 0 {
+		return err
+	}
+
+	subject, text, html, err := s.Templates.Render("digest", digestEmail{
+		Name:          displayName(rcpt),
+		Period:        rcpt.Mode,
+		Notifications: notifications,
+		More:          total - len(notifications),
+	})
+	if err != nil {
+		return err
+	}
+	err = s.Mailer.Send(ctx, &mailer.Message{
+		From:    s.From,
+		To:      []string{rcpt.Email},
+		Subject: subject,
+		Text:    text,
+		HTML:    html,
+	})
+	if err != nil {
+		return err
+	}
+	return s.Repository.FinishDigest(ctx, rcpt.UserID, notifications[0].CreatedAt, now)
+}
+
+func displayName(rcpt *model.EmailRecipient) string {
+	if rcpt.Name != "" {
+		return rcpt.Name
+	}
+	return rcpt.Email
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"pet-project/pkg/model"
+	"strconv"
+	"time"
+)
+
+// NotifyOnEvent — подписчик outbox: уведомляет исполнителей и наблюдателей задачи об изменении
+// или новом комментарии, кроме автора. Уведомления помечаются номером события, поэтому повторная
+// доставка дублей не создаёт.
+func (s *TaskService) NotifyOnEvent(ctx context.Context, msg *model.OutboxMessage) error {
+	if s.Notifications == nil {
+		return nil
+	}
+	event, err := decodeEvent(msg)
+	if err != nil {
+		return err
+	}
+
+	var notifType, message string
+	switch event.Type {
+	case model.EventTaskUpdated:
+		task, err := s.Repository.GetByIDTask(event.TaskID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		notifType = "task_updated"
+		message = fmt.Sprintf("Task #%d \"%s\" was updated", task.ID, shortTitle(task.Title))
+		if change, ok := event.Changes["status"]; ok && len(event.Changes) == 1 {
+			message = fmt.Sprintf("Task #%d \"%s\" was moved to %s", task.ID, shortTitle(task.Title), change.New)
+		}
+	case model.EventCommentCreated:
+		notifType = "task_commented"
+		message = fmt.Sprintf("New comment on task #%d", event.TaskID)
+	default:
+		return nil
+	}
+
+	recipients, err := s.memberRecipients(event.TaskID, event.ActorID)
+	if err != nil {
+		return err
+	}
+	for _, user_id := range recipients {
+		notif := &model.Notification{
+			UserID:  user_id,
+			Type:    notifType,
+			Message: message,
+			TaskID:  &event.TaskID,
+			Key:     "event:" + strconv.Itoa(event.ID),
+		}
+		if err := s.Notifications.Create(ctx, notif); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// taskUpdatedEvent собирает событие task_updated; nil, если отслеживаемые поля не изменились
+func taskUpdatedEvent(old, updated *model.Task, actor_id int) *model.ProjectEvent {
+	changes := map[string]model.FieldChange{}
+	compare := func(field, before, after string) {
+		if before != after {
+			changes[field] = model.FieldChange{Old: before, New: after}
+		}
+	}
+	compare("status", old.Status, updated.Status)
+	compare("priority", old.Priority, updated.Priority)
+	compare("title", old.Title, updated.Title)
+	compare("description", old.Description, updated.Description)
+	compare("assignee", formatUserID(old.AssignedTo), formatUserID(updated.AssignedTo))
+	compare("due", formatDue(old.DueDate), formatDue(updated.DueDate))
+	if len(changes) == 0 {
+		return nil
+	}
+	return &model.ProjectEvent{
+		Type:      model.EventTaskUpdated,
+		ProjectID: old.ProjectID,
+		TaskID:    old.ID,
+		ActorID:   actor_id,
+		Changes:   changes,
+	}
+}
+
+func formatUserID(id int) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.Itoa(id)
+}
+
+func formatDue(due *time.Time) string {
+	if due == nil {
+		return ""
+	}
+	return due.Format(time.RFC3339)
+}
@@ -0,0 +1,323 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"pet-project/internal/repository"
+	"pet-project/internal/vcs"
+	"pet-project/pkg/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const gitCommitState = "pushed"
+
+var (
+	ErrGitIntegrationNotFound = errors.New("Git integration not found")
+	ErrGitSignature           = errors.New("Invalid signature")
+	ErrGitPayload             = errors.New("Invalid payload")
+)
+
+// GitIntegrationService связывает задачи с коммитами и pull request. Задача упоминается как #123
+// в сообщении коммита, заголовке или описании pull request либо номером в имени ветки; «fixes #123»
+// в основной ветке или в слитом pull request переводит задачу в CloseStatus интеграции.
+type GitIntegrationService struct {
+	Repository repository.GitRepository
+	Tasks      *TaskService
+	Comments   repository.CommentsRepository
+}
+
+// GitDeliveryResult — что сделано по одному запросу провайдера; отдаётся ему в ответе для отладки
+type GitDeliveryResult struct {
+	Event  string   `json:"event"`
+	Linked []int    `json:"linked"`
+	Moved  []int    `json:"moved"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+func (s *GitIntegrationService) CreateIntegration(integration *model.GitIntegration) error {
+	if err := validateGitIntegration(integration); err != nil {
+		return err
+	}
+	if integration.Secret == "" {
+		secret, err := generateSecret()
+		if err != nil {
+			return err
+		}
+		integration.Secret = secret
+	}
+	now := time.Now()
+	integration.CreatedAt = now
+	integration.UpdatedAt = now
+	return s.Repository.CreateIntegration(integration)
+}
+
+func (s *GitIntegrationService) GetIntegration(integration_id int) (*model.GitIntegration, error) {
+	return s.Repository.GetIntegrationByID(integration_id)
+}
+
+func (s *GitIntegrationService) ListIntegrations(project_id int) ([]*model.GitIntegration, error) {
+	return s.Repository.ListIntegrations(project_id)
+}
+
+// UpdateIntegration сохраняет интеграцию; пустой Secret означает выпустить новый ключ
+func (s *GitIntegrationService) UpdateIntegration(integration *model.GitIntegration) error {
+	if err := validateGitIntegration(integration); err != nil {
+		return err
+	}
+	if integration.Secret == "" {
+		secret, err := generateSecret()
+		if err != nil {
+			return err
+		}
+		integration.Secret = secret
+	}
+	integration.UpdatedAt = time.Now()
+	return s.Repository.UpdateIntegration(integration)
+}
+
+func (s *GitIntegrationService) DeleteIntegration(integration_id int) error {
+	return s.Repository.DeleteIntegration(integration_id)
+}
+
+// TaskProjectID возвращает проект задачи, чтобы проверить доступ к её связям
+func (s *GitIntegrationService) TaskProjectID(task_id int) (int, error) {
+	task, err := s.Tasks.Repository.GetByIDTask(task_id)
+	if err != nil {
+		return 0, errors.New("Task not found")
+	}
+	return task.ProjectID, nil
+}
+
+func (s *GitIntegrationService) ListLinks(task_id int) ([]*model.GitLink, error) {
+	return s.Repository.ListLinks(task_id)
+}
+
+func validateGitIntegration(integration *model.GitIntegration) error {
+	if !containsString(vcs.Providers, integration.Provider) {
+		return fmt.Errorf("Provider must be one of %v", vcs.Providers)
+	}
+	if len(integration.Secret) > 0 && len(integration.Secret) < 16 {
+		return errors.New("Git integration secret must be at least 16 characters long")
+	}
+	if integration.CloseStatus == "" {
+		integration.CloseStatus = "done"
+	}
+	if !containsString(BoardStatuses, integration.CloseStatus) {
+		return fmt.Errorf("Close status must be one of %v", BoardStatuses)
+	}
+	if integration.ReviewStatus != "" && !containsString(BoardStatuses, integration.ReviewStatus) {
+		return fmt.Errorf("Review status must be empty or one of %v", BoardStatuses)
+	}
+	return nil
+}
+
+// Receive обрабатывает запрос провайдера: проверяет подпись, связывает упомянутые задачи проекта
+// с коммитами или pull request, пишет о новых связях комментарии и меняет статусы. Повторная доставка
+// того же события ничего не дублирует: комментарий пишется, только когда связь новая или сменила состояние.
+func (s *GitIntegrationService) Receive(ctx context.Context, integration_id int, header http.Header, body []byte) (*GitDeliveryResult, error) {
+	integration, err := s.Repository.GetIntegrationByID(integration_id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !integration.Active) {
+		return nil, ErrGitIntegrationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !vcs.Verify(integration.Provider, integration.Secret, header, body) {
+		return nil, ErrGitSignature
+	}
+
+	event, err := vcs.Parse(integration.Provider, header, body)
+	if errors.Is(err, vcs.ErrUnsupportedEvent) {
+		return &GitDeliveryResult{Event: "ignored", Linked: []int{}, Moved: []int{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrGitPayload, err)
+	}
+
+	result := &GitDeliveryResult{Event: event.Kind, Linked: []int{}, Moved: []int{}}
+	if event.Kind == vcs.KindPush {
+		err = s.handlePush(ctx, integration, event, result)
+	} else {
+		err = s.handlePullRequest(ctx, integration, event, result)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *GitIntegrationService) handlePush(ctx context.Context, integration *model.GitIntegration, event *vcs.Event, result *GitDeliveryResult) error {
+	// закрывают задачу только коммиты, попавшие в основную ветку
+	mainBranch := event.DefaultBranch != "" && event.Branch == event.DefaultBranch
+
+	for _, commit := range event.Commits {
+		actor_id := s.commitAuthor(ctx, integration, commit.AuthorEmail)
+		title := firstLine(commit.Message)
+		for _, ref := range vcs.References(commit.Message) {
+			task := s.projectTask(integration.ProjectID, ref.TaskID)
+			if task == nil {
+				continue
+			}
+			link := &model.GitLink{
+				TaskID:        task.ID,
+				IntegrationID: integration.ID,
+				Kind:          model.GitLinkCommit,
+				Ref:           commit.ID,
+				Title:         title,
+				URL:           commit.URL,
+				Author:        commit.AuthorName,
+				State:         gitCommitState,
+				UpdatedAt:     time.Now(),
+			}
+			previous, err := s.Repository.SaveLink(ctx, link)
+			if err != nil {
+				return err
+			}
+			result.Linked = appendUnique(result.Linked, task.ID)
+			if previous == "" {
+				text := fmt.Sprintf("Commit [%s](%s) by %s in %s: %s", shortSHA(commit.ID), commit.URL, commit.AuthorName, event.Branch, shortTitle(title))
+				s.comment(task, actor_id, text, result)
+			}
+			if ref.Closes && mainBranch {
+				s.moveTask(task, integration.CloseStatus, actor_id, result)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *GitIntegrationService) handlePullRequest(ctx context.Context, integration *model.GitIntegration, event *vcs.Event, result *GitDeliveryResult) error {
+	pr := event.PullRequest
+	refs := vcs.References(pr.Title + "\n" + pr.Body)
+	if id := vcs.BranchTask(event.Branch); id != 0 {
+		found := false
+		for _, ref := range refs {
+			found = found || ref.TaskID == id
+		}
+		if !found {
+			refs = append(refs, vcs.Ref{TaskID: id})
+		}
+	}
+
+	state := pr.Action
+	if state == vcs.ActionUpdated {
+		state = vcs.ActionOpened
+	}
+	number := strconv.Itoa(pr.Number)
+
+	for _, ref := range refs {
+		task := s.projectTask(integration.ProjectID, ref.TaskID)
+		if task == nil {
+			continue
+		}
+		link := &model.GitLink{
+			TaskID:        task.ID,
+			IntegrationID: integration.ID,
+			Kind:          model.GitLinkPullRequest,
+			Ref:           number,
+			Title:         pr.Title,
+			URL:           pr.URL,
+			Author:        pr.Author,
+			State:         state,
+			UpdatedAt:     time.Now(),
+		}
+		previous, err := s.Repository.SaveLink(ctx, link)
+		if err != nil {
+			return err
+		}
+		result.Linked = appendUnique(result.Linked, task.ID)
+		if previous == state {
+			continue
+		}
+
+		var text string
+		switch state {
+		case vcs.ActionOpened:
+			text = fmt.Sprintf("Pull request [#%s](%s) opened by %s: %s", number, pr.URL, pr.Author, shortTitle(pr.Title))
+		case vcs.ActionMerged:
+			text = fmt.Sprintf("Pull request [#%s](%s) was merged", number, pr.URL)
+		case vcs.ActionClosed:
+			text = fmt.Sprintf("Pull request [#%s](%s) was closed without merging", number, pr.URL)
+		}
+		s.comment(task, integration.CreatedBy, text, result)
+
+		switch {
+		case state == vcs.ActionOpened && integration.ReviewStatus != "" && task.Status != integration.CloseStatus:
+			s.moveTask(task, integration.ReviewStatus, integration.CreatedBy, result)
+		case state == vcs.ActionMerged && ref.Closes:
+			s.moveTask(task, integration.CloseStatus, integration.CreatedBy, result)
+		}
+	}
+	return nil
+}
+
+// projectTask возвращает задачу, только если она из проекта интеграции: чужие номера игнорируются
+func (s *GitIntegrationService) projectTask(project_id, task_id int) *model.Task {
+	task, err := s.Tasks.Repository.GetByIDTask(task_id)
+	if err != nil || task.ProjectID != project_id {
+		return nil
+	}
+	return task
+}
+
+// commitAuthor — пользователь с адресом автора коммита, иначе создатель интеграции
+func (s *GitIntegrationService) commitAuthor(ctx context.Context, integration *model.GitIntegration, email string) int {
+	if email != "" {
+		if user_id, err := s.Repository.FindUserID(ctx, email); err == nil {
+			return user_id
+		}
+	}
+	return integration.CreatedBy
+}
+
+// comment оставляет в задаче комментарий о коммите или pull request. Связь к этому моменту уже
+// сохранена, и повторная доставка комментарий не создаст, поэтому ошибка не прерывает обработку,
+// а записывается в результат, как и у moveTask
+func (s *GitIntegrationService) comment(task *model.Task, user_id int, text string, result *GitDeliveryResult) {
+	now := time.Now()
+	com := &model.Comments{TaskID: task.ID, UserID: user_id, Text: text, CreatedAt: now, UpdatedAt: now}
+	err := s.Comments.AddComment(com, &model.ProjectEvent{
+		Type:      model.EventCommentCreated,
+		ProjectID: task.ProjectID,
+		ActorID:   user_id,
+	})
+	if err != nil {
+		log.Printf("Failed to comment on task #%d: %v", task.ID, err)
+		result.Errors = append(result.Errors, fmt.Sprintf("task #%d: %v", task.ID, err))
+	}
+}
+
+// moveTask меняет статус так же, как правка задачи; ошибку (например, открытые блокеры) только
+// записывает в результат, чтобы остальные задачи обработались
+func (s *GitIntegrationService) moveTask(task *model.Task, status string, actor_id int, result *GitDeliveryResult) {
+	if task.Status == status {
+		return
+	}
+	old := *task
+	task.Status = status
+	task.UpdatedAt = time.Now()
+	if err := s.Tasks.saveTask(task, &old, nil, taskUpdatedEvent(&old, task, actor_id)); err != nil {
+		*task = old
+		result.Errors = append(result.Errors, fmt.Sprintf("task #%d: %v", task.ID, err))
+		return
+	}
+	result.Moved = appendUnique(result.Moved, task.ID)
+}
+
+func firstLine(message string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	return strings.TrimSpace(line)
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
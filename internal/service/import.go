@@ -0,0 +1,536 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"pet-project/internal/importer"
+	"pet-project/internal/repository"
+	"pet-project/pkg/model"
+	"sort"
+	"strings"
+	"time"
+)
+
+// importLockKey — ключ advisory-блокировки: задания импорта выполняет одна реплика
+const importLockKey int64 = 0x696d706f7274 // "import"
+
+// DefaultMaxImportSize — предел размера выгрузки, если в конфигурации он не задан
+const DefaultMaxImportSize = 20 << 20
+
+// Размеры колонок tasks.title и labels.name: длинные значения из выгрузки обрезаются
+const (
+	importMaxTitle = 255
+	importMaxLabel = 100
+)
+
+var ErrImportJobNotFound = errors.New("Import job not found")
+
+// ImportService переносит задачи из выгрузок Trello и Jira. Предпросмотр ничего не создаёт;
+// импорт выполняется фоновым заданием, которое после сбоя или перезапуска продолжается
+// с первой необработанной задачи.
+type ImportService struct {
+	Repository repository.ImportRepository
+	Locker     repository.Locker
+	Tasks      *TaskService
+	Projects   *ProjectService
+	Labels     *LabelService
+	Comments   repository.CommentsRepository
+	MaxSize    int64
+}
+
+// importPlan — выгрузка, сопоставленная с пользователями и статусами приложения
+type importPlan struct {
+	board   *importer.Board
+	options model.ImportOptions
+	report  *model.ImportReport
+	// users — email в нижнем регистре → ID пользователя
+	users map[string]int
+}
+
+// Preview разбирает выгрузку и возвращает отчёт о том, что будет импортировано, ничего не создавая
+func (s *ImportService) Preview(ctx context.Context, source string, data []byte, options model.ImportOptions, project_id *int) (*model.ImportReport, error) {
+	if err := s.validate(source, data, options); err != nil {
+		return nil, err
+	}
+	plan, err := s.plan(ctx, source, data, options, project_id)
+	if err != nil {
+		return nil, err
+	}
+	return plan.report, nil
+}
+
+// CreateJob ставит импорт в очередь. Выгрузка разбирается сразу, чтобы ошибка в файле
+// вернулась в ответе, а не в задании.
+func (s *ImportService) CreateJob(ctx context.Context, job *model.ImportJob) error {
+	if err := s.validate(job.Source, job.Payload, job.Options); err != nil {
+		return err
+	}
+	plan, err := s.plan(ctx, job.Source, job.Payload, job.Options, job.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	job.Status = model.ImportQueued
+	job.Total = len(plan.board.Tasks)
+	job.Report = plan.report
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	return s.Repository.CreateJob(job)
+}
+
+func (s *ImportService) GetJob(job_id int, user_id int) (*model.ImportJob, error) {
+	job, err := s.Repository.GetJob(job_id)
+	if err != nil || job.UserID != user_id {
+		return nil, ErrImportJobNotFound
+	}
+	return job, nil
+}
+
+func (s *ImportService) ListJobs(user_id int) ([]*model.ImportJob, error) {
+	return s.Repository.ListJobs(user_id)
+}
+
+// ResumeJob возвращает в очередь задание, завершившееся ошибкой: оно продолжится с того места, где остановилось
+func (s *ImportService) ResumeJob(ctx context.Context, job *model.ImportJob) error {
+	if job.Status != model.ImportFailed {
+		return errors.New("Only failed import jobs can be resumed")
+	}
+	job.Status = model.ImportQueued
+	job.Error = ""
+	job.UpdatedAt = time.Now()
+	return s.Repository.UpdateJob(ctx, job)
+}
+
+// Run выполняет задания из очереди раз в interval. Блокирует до отмены ctx.
+func (s *ImportService) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.ProcessPending(ctx); err != nil {
+			log.Println("Failed to process import jobs:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessPending выполняет задания в очереди и прерванные задания. Задания обрабатывает
+// одна реплика за раз: пока импорт идёт на другой, вызов сразу возвращается.
+func (s *ImportService) ProcessPending(ctx context.Context) error {
+	unlock, ok, err := s.Locker.TryLock(ctx, importLockKey)
+	if err != nil || !ok {
+		return err
+	}
+	defer unlock()
+
+	jobs, err := s.Repository.ListPending(ctx)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err := s.process(ctx, job); err != nil {
+			if ctx.Err() != nil {
+				// Остановка сервиса: задание останется в running и продолжится после запуска
+				return nil
+			}
+			log.Printf("Import job %d failed: %v", job.ID, err)
+			job.Status = model.ImportFailed
+			job.Error = err.Error()
+			job.UpdatedAt = time.Now()
+			if err := s.Repository.UpdateJob(ctx, job); err != nil {
+				log.Println("Failed to save import job:", err)
+			}
+		}
+	}
+	return nil
+}
+
+// process импортирует задачи задания начиная с job.Processed и сохраняет прогресс после каждой
+func (s *ImportService) process(ctx context.Context, job *model.ImportJob) error {
+	payload, err := s.Repository.GetPayload(ctx, job.ID)
+	if err != nil {
+		return err
+	}
+	// Сопоставление пересчитывается: с момента постановки в очередь могли зарегистрироваться участники
+	plan, err := s.plan(ctx, job.Source, payload, job.Options, job.ProjectID)
+	if err != nil {
+		return err
+	}
+	if job.Report != nil {
+		plan.report.CreatedTasks = job.Report.CreatedTasks
+		plan.report.CreatedComments = job.Report.CreatedComments
+		plan.report.Failed = job.Report.Failed
+	}
+	job.Report = plan.report
+	job.Total = len(plan.board.Tasks)
+	job.Status = model.ImportRunning
+
+	if job.ProjectID == nil {
+		name := plan.board.Name
+		if name == "" {
+			name = fmt.Sprintf("Import from %s", job.Source)
+		}
+		project, err := s.Projects.createProject(name, "", job.UserID, func(project *model.Project) error {
+			return s.Repository.CreateProject(ctx, job, project)
+		})
+		if err != nil {
+			return err
+		}
+		job.ProjectID = &project.ID
+	}
+	job.UpdatedAt = time.Now()
+	if err := s.Repository.UpdateJob(ctx, job); err != nil {
+		return err
+	}
+
+	labels, err := s.projectLabels(*job.ProjectID)
+	if err != nil {
+		return err
+	}
+	for i := job.Processed; i < len(plan.board.Tasks); i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.importTask(ctx, job, plan, plan.board.Tasks[i], labels); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			// Ошибка одной задачи не останавливает импорт: иначе продолжение упиралось бы в неё же
+			task := plan.board.Tasks[i]
+			log.Printf("Import job %d: task %s failed: %v", job.ID, task.Key, err)
+			job.Report.Failed = append(job.Report.Failed, model.ImportFailure{Key: task.Key, Title: task.Title, Error: err.Error()})
+		}
+		job.Processed = i + 1
+		job.UpdatedAt = time.Now()
+		if err := s.Repository.UpdateJob(ctx, job); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	job.Status = model.ImportDone
+	job.UpdatedAt = now
+	job.FinishedAt = &now
+	return s.Repository.UpdateJob(ctx, job)
+}
+
+// importTask создаёт задачу с исполнителями, метками и комментариями. Задача и запись о ней
+// сохраняются в одной транзакции, поэтому уже созданная задача (задание прервалось на ней)
+// не дублируется: добавляются только недостающие комментарии.
+func (s *ImportService) importTask(ctx context.Context, job *model.ImportJob, plan *importPlan, source *importer.Task, labels map[string]int) error {
+	var assignees []int
+	for _, person := range source.Assignees {
+		if userID, ok := plan.userID(person); ok {
+			assignees = appendUnique(assignees, userID)
+		}
+	}
+
+	item, err := s.Repository.GetItem(ctx, job.ID, source.ExternalID)
+	if errors.Is(err, sql.ErrNoRows) {
+		title := source.Title
+		if title == "" {
+			title = "Untitled " + source.Key
+		}
+		task := &model.Task{
+			Title:       title,
+			Description: importDescription(job.Source, source),
+			Status:      plan.status(source),
+			Priority:    importPriority(source.Priority),
+			ProjectID:   *job.ProjectID,
+			DueDate:     source.Due,
+		}
+		if len(assignees) > 0 {
+			task.AssignedTo = assignees[0]
+		}
+		item = &model.ImportItem{JobID: job.ID, ExternalID: source.ExternalID}
+		err := s.Tasks.createTask(task, func(values []*model.CustomFieldValue, event *model.ProjectEvent) error {
+			return s.Repository.CreateTask(ctx, task, values, item, event)
+		})
+		if err != nil {
+			return err
+		}
+		job.Report.CreatedTasks++
+	} else if err != nil {
+		return err
+	}
+
+	// Добавление исполнителей и меток идемпотентно, поэтому при продолжении повторяется целиком
+	for _, userID := range assignees {
+		if err := s.Tasks.Members.AddAssignee(item.TaskID, userID); err != nil {
+			return err
+		}
+	}
+	for _, name := range source.Labels {
+		labelID, ok := labels[strings.ToLower(name)]
+		if !ok {
+			label, err := s.Labels.CreateLabel(*job.ProjectID, name, "")
+			if err != nil {
+				return err
+			}
+			labelID = label.ID
+			labels[strings.ToLower(name)] = labelID
+		}
+		if err := s.Labels.AddToTask(item.TaskID, labelID); err != nil {
+			return err
+		}
+	}
+
+	// Комментарии переносятся без событий: история не должна рассылать уведомления
+	for i := item.Comments; i < len(source.Comments); i++ {
+		comment := source.Comments[i]
+		com := &model.Comments{TaskID: item.TaskID, UserID: job.UserID, Text: comment.Text, CreatedAt: comment.CreatedAt}
+		if userID, ok := plan.userID(comment.Author); ok {
+			com.UserID = userID
+		} else {
+			com.Text = fmt.Sprintf("**%s** wrote:\n\n%s", comment.Author, comment.Text)
+		}
+		if com.CreatedAt.IsZero() {
+			com.CreatedAt = time.Now()
+		}
+		com.UpdatedAt = com.CreatedAt
+		if err := s.Comments.AddComment(com); err != nil {
+			return err
+		}
+		item.Comments = i + 1
+		if err := s.Repository.SaveItem(ctx, item); err != nil {
+			return err
+		}
+		job.Report.CreatedComments++
+	}
+	return nil
+}
+
+func (s *ImportService) validate(source string, data []byte, options model.ImportOptions) error {
+	if source != importer.SourceTrello && source != importer.SourceJira {
+		return errors.New("Source must be trello or jira")
+	}
+	if len(data) == 0 {
+		return errors.New("Export file is required")
+	}
+	if int64(len(data)) > s.maxSize() {
+		return errors.New("Export file is too large")
+	}
+	for from, to := range options.StatusMap {
+		if !containsString(BoardStatuses, to) {
+			return fmt.Errorf("Status %q must be mapped to one of: %s", from, strings.Join(BoardStatuses, ", "))
+		}
+	}
+	return nil
+}
+
+func (s *ImportService) maxSize() int64 {
+	if s.MaxSize > 0 {
+		return s.MaxSize
+	}
+	return DefaultMaxImportSize
+}
+
+// plan разбирает выгрузку, находит пользователей участников и составляет отчёт
+func (s *ImportService) plan(ctx context.Context, source string, data []byte, options model.ImportOptions, project_id *int) (*importPlan, error) {
+	board, err := importer.Parse(source, data)
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range board.Tasks {
+		fitImportTask(task)
+	}
+	plan := &importPlan{
+		board:   board,
+		options: options,
+		report: &model.ImportReport{
+			Board:    board.Name,
+			Tasks:    len(board.Tasks),
+			Skipped:  board.Skipped,
+			Statuses: map[string]string{},
+			Members:  map[string]int{},
+		},
+	}
+
+	var people []importer.Person
+	var emails []string
+	for _, task := range board.Tasks {
+		plan.report.Comments += len(task.Comments)
+		if task.Status != "" {
+			plan.report.Statuses[task.Status] = plan.status(&importer.Task{Status: task.Status})
+		}
+		if task.Title == "" {
+			plan.report.Warnings = append(plan.report.Warnings, fmt.Sprintf("Task %s has no title and will be imported as untitled", task.Key))
+		}
+		people = append(people, task.Assignees...)
+		for _, comment := range task.Comments {
+			if len(comment.Author.Keys()) > 0 {
+				people = append(people, comment.Author)
+			}
+		}
+	}
+	for _, person := range people {
+		if email := plan.email(person); email != "" {
+			emails = append(emails, email)
+		}
+	}
+	if plan.users, err = s.Repository.FindUsersByEmail(ctx, emails); err != nil {
+		return nil, err
+	}
+
+	unmapped := map[string]bool{}
+	for _, person := range people {
+		if userID, ok := plan.userID(person); ok {
+			plan.report.Members[person.String()] = userID
+		} else {
+			unmapped[person.String()] = true
+		}
+	}
+	for name := range unmapped {
+		plan.report.UnmappedMembers = append(plan.report.UnmappedMembers, name)
+	}
+	sort.Strings(plan.report.UnmappedMembers)
+
+	existing := map[string]int{}
+	if project_id != nil {
+		if existing, err = s.projectLabels(*project_id); err != nil {
+			return nil, err
+		}
+	}
+	newLabels := map[string]bool{}
+	for _, task := range board.Tasks {
+		for _, name := range task.Labels {
+			key := strings.ToLower(name)
+			if _, ok := existing[key]; !ok && !newLabels[key] {
+				newLabels[key] = true
+				plan.report.Labels = append(plan.report.Labels, name)
+			}
+		}
+	}
+	sort.Strings(plan.report.Labels)
+	return plan, nil
+}
+
+// projectLabels возвращает метки проекта: название в нижнем регистре → ID
+func (s *ImportService) projectLabels(project_id int) (map[string]int, error) {
+	labels, err := s.Labels.ListByProject(project_id)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]int, len(labels))
+	for _, label := range labels {
+		byName[strings.ToLower(label.Name)] = label.ID
+	}
+	return byName, nil
+}
+
+// email возвращает адрес участника из выгрузки или из сопоставления Members
+func (p *importPlan) email(person importer.Person) string {
+	if person.Email != "" {
+		return strings.ToLower(person.Email)
+	}
+	for _, key := range person.Keys() {
+		for from, email := range p.options.Members {
+			if strings.EqualFold(strings.TrimSpace(from), key) {
+				return strings.ToLower(strings.TrimSpace(email))
+			}
+		}
+	}
+	return ""
+}
+
+func (p *importPlan) userID(person importer.Person) (int, bool) {
+	email := p.email(person)
+	if email == "" {
+		return 0, false
+	}
+	userID, ok := p.users[email]
+	return userID, ok
+}
+
+// status сопоставляет статус источника по StatusMap, а если его там нет — по названию.
+// Закрытая в источнике задача всегда done.
+func (p *importPlan) status(task *importer.Task) string {
+	if task.Done {
+		return "done"
+	}
+	for from, to := range p.options.StatusMap {
+		if strings.EqualFold(strings.TrimSpace(from), task.Status) {
+			return to
+		}
+	}
+	return guessStatus(task.Status)
+}
+
+func guessStatus(name string) string {
+	name = strings.ToLower(name)
+	for _, word := range []string{"done", "closed", "resolved", "complete", "готов", "выполн", "закрыт"} {
+		if strings.Contains(name, word) {
+			return "done"
+		}
+	}
+	for _, word := range []string{"progress", "doing", "review", "testing", "в работе", "ревью", "тест"} {
+		if strings.Contains(name, word) {
+			return "in_progress"
+		}
+	}
+	return "pending"
+}
+
+// fitImportTask обрезает название и метки задачи под размеры колонок. Полное название
+// не теряется — оно переносится в начало описания.
+func fitImportTask(task *importer.Task) {
+	task.Title = strings.TrimSpace(task.Title)
+	if title, cut := truncateRunes(task.Title, importMaxTitle); cut {
+		task.Description = task.Title + "\n\n" + task.Description
+		task.Title = title
+	}
+	for i, label := range task.Labels {
+		task.Labels[i], _ = truncateRunes(label, importMaxLabel)
+	}
+}
+
+// truncateRunes обрезает s до max символов, последним ставя многоточие
+func truncateRunes(s string, max int) (string, bool) {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s, false
+	}
+	return strings.TrimSpace(string(runes[:max-1])) + "…", true
+}
+
+// importPriority переводит приоритеты Jira; у карточек Trello приоритета нет
+func importPriority(priority string) string {
+	switch strings.ToLower(priority) {
+	case "highest", "high", "blocker", "critical":
+		return "high"
+	case "low", "lowest", "minor", "trivial":
+		return "low"
+	}
+	return "medium"
+}
+
+// importDescription дописывает к описанию ссылку на исходную задачу
+func importDescription(source string, task *importer.Task) string {
+	origin := task.Key
+	if task.URL != "" {
+		origin = task.URL
+	}
+	if origin == "" {
+		return task.Description
+	}
+	name := map[string]string{importer.SourceTrello: "Trello", importer.SourceJira: "Jira"}[source]
+	footer := fmt.Sprintf("_Imported from %s: %s_", name, origin)
+	if strings.TrimSpace(task.Description) == "" {
+		return footer
+	}
+	return strings.TrimRight(task.Description, "\n") + "\n\n---\n" + footer
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"os"
+	"pet-project/internal/importer"
+	"pet-project/internal/repository"
+	"pet-project/pkg/model"
+	"reflect"
+	"testing"
+)
+
+// usersImportRepository находит пользователей по адресам; остальные методы Preview не вызывает
+type usersImportRepository struct {
+	repository.ImportRepository
+	users map[string]int
+}
+
+func (r *usersImportRepository) FindUsersByEmail(ctx context.Context, emails []string) (map[string]int, error) {
+	found := map[string]int{}
+	for _, email := range emails {
+		if id, ok := r.users[email]; ok {
+			found[email] = id
+		}
+	}
+	return found, nil
+}
+
+func previewFixture(t *testing.T, source, name string, options model.ImportOptions) *model.ImportReport {
+	t.Helper()
+	data, err := os.ReadFile("../importer/testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &ImportService{Repository: &usersImportRepository{users: map[string]int{
+		"anna@example.com": 1,
+		"ivan@example.com": 2,
+	}}}
+	report, err := s.Preview(context.Background(), source, data, options, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
+
+func TestPreviewTrello(t *testing.T) {
+	report := previewFixture(t, importer.SourceTrello, "trello.json", model.ImportOptions{
+		StatusMap: map[string]string{"shipped": "done"},
+		// В выгрузке Trello нет адресов — участников сопоставляют по логину или имени
+		Members: map[string]string{"anna": "Anna@Example.com", "Ivan Sidorov": "ivan@example.com"},
+	})
+
+	if report.Tasks != 3 || report.Skipped != 2 || report.Comments != 2 {
+		t.Errorf("Tasks/Skipped/Comments = %d/%d/%d, want 3/2/2", report.Tasks, report.Skipped, report.Comments)
+	}
+	wantStatuses := map[string]string{"To Do": "pending", "Doing": "in_progress", "Shipped": "done"}
+	if !reflect.DeepEqual(report.Statuses, wantStatuses) {
+		t.Errorf("Statuses = %v, want %v", report.Statuses, wantStatuses)
+	}
+	wantMembers := map[string]int{"Anna Petrova": 1, "Ivan Sidorov": 2}
+	if !reflect.DeepEqual(report.Members, wantMembers) {
+		t.Errorf("Members = %v, want %v", report.Members, wantMembers)
+	}
+	if want := []string{"m9"}; !reflect.DeepEqual(report.UnmappedMembers, want) {
+		t.Errorf("UnmappedMembers = %v, want %v", report.UnmappedMembers, want)
+	}
+	if want := []string{"docs", "red"}; !reflect.DeepEqual(report.Labels, want) {
+		t.Errorf("Labels = %v, want %v", report.Labels, want)
+	}
+}
+
+func TestPreviewJira(t *testing.T) {
+	report := previewFixture(t, importer.SourceJira, "jira.csv", model.ImportOptions{
+		StatusMap: map[string]string{"In Review": "pending"},
+	})
+
+	if report.Board != "Website" || report.Tasks != 3 || report.Comments != 3 {
+		t.Errorf("Board/Tasks/Comments = %q/%d/%d, want Website/3/3", report.Board, report.Tasks, report.Comments)
+	}
+	// StatusMap важнее названия, Closed угадывается как done
+	wantStatuses := map[string]string{"To Do": "pending", "In Review": "pending", "Closed": "done"}
+	if !reflect.DeepEqual(report.Statuses, wantStatuses) {
+		t.Errorf("Statuses = %v, want %v", report.Statuses, wantStatuses)
+	}
+	// Адреса из выгрузки находятся сами; acc-1 и Ivan Sidorov без сопоставления не найдены
+	wantMembers := map[string]int{"anna@example.com": 1, "ivan@example.com": 2}
+	if !reflect.DeepEqual(report.Members, wantMembers) {
+		t.Errorf("Members = %v, want %v", report.Members, wantMembers)
+	}
+	if want := []string{"Ivan Sidorov", "acc-1"}; !reflect.DeepEqual(report.UnmappedMembers, want) {
+		t.Errorf("UnmappedMembers = %v, want %v", report.UnmappedMembers, want)
+	}
+}
+
+func TestImportPlanStatus(t *testing.T) {
+	plan := &importPlan{options: model.ImportOptions{StatusMap: map[string]string{" Backlog ": "in_progress"}}}
+	tests := []struct {
+		task *importer.Task
+		want string
+	}{
+		{task: &importer.Task{Status: "backlog"}, want: "in_progress"},
+		{task: &importer.Task{Status: "Backlog", Done: true}, want: "done"},
+		{task: &importer.Task{Status: "Resolved"}, want: "done"},
+		{task: &importer.Task{Status: "Code review"}, want: "in_progress"},
+		{task: &importer.Task{Status: "В работе"}, want: "in_progress"},
+		{task: &importer.Task{Status: "Готово"}, want: "done"},
+		{task: &importer.Task{Status: "Ideas"}, want: "pending"},
+		{task: &importer.Task{}, want: "pending"},
+	}
+	for _, tt := range tests {
+		if got := plan.status(tt.task); got != tt.want {
+			t.Errorf("status(%q, done=%v) = %q, want %q", tt.task.Status, tt.task.Done, got, tt.want)
+		}
+	}
+}
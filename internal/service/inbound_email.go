@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"pet-project/internal/mailer"
+	"pet-project/internal/repository"
+	"pet-project/pkg/model"
+	"unicode/utf8"
+)
+
+// maxReplyLength — ограничение на текст ответа, из которого получается комментарий
+const maxReplyLength = 10000
+
+// ErrEmailRejected — письмо разобрано, но комментарием стать не может: повторять доставку бессмысленно
+var ErrEmailRejected = errors.New("Email rejected")
+
+// InboundEmailService превращает ответы на письма-уведомления в комментарии. Задача и автор
+// определяются по подписанному адресу Reply-To; адрес отправителя должен принадлежать этому автору.
+type InboundEmailService struct {
+	Repository repository.InboundEmailRepository
+	Comments   *CommentsService
+	// ReplySecret — ключ подписи адресов для ответов, тот же, что у EmailService
+	ReplySecret string
+}
+
+// Process разбирает письмо и добавляет комментарий. Повторно доставленное письмо с тем же
+// Message-ID пропускается: тогда возвращается nil без ошибки.
+func (s *InboundEmailService) Process(ctx context.Context, raw io.Reader) (*model.Comments, error) {
+	in, err := mailer.ParseInbound(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrEmailRejected, err)
+	}
+
+	task_id, user_id := 0, 0
+	for _, rcpt := range in.To {
+		if taskID, userID, ok := mailer.ParseReplyAddress(s.ReplySecret, rcpt); ok {
+			task_id, user_id = taskID, userID
+			break
+		}
+	}
+	if task_id == 0 {
+		return nil, fmt.Errorf("%w: no valid reply address among recipients", ErrEmailRejected)
+	}
+
+	if in.MessageID != "" {
+		processed, err := s.Repository.IsProcessed(ctx, in.MessageID)
+		if err != nil {
+			return nil, err
+		}
+		if processed {
+			return nil, nil
+		}
+	}
+
+	user, err := s.Repository.FindUserByEmail(ctx, in.From)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: unknown sender %s", ErrEmailRejected, in.From)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Адрес для ответов выдан конкретному получателю: чужое письмо не даёт писать от его имени
+	if user.ID != user_id {
+		return nil, fmt.Errorf("%w: sender %s does not match the reply address", ErrEmailRejected, in.From)
+	}
+	ok, err := s.Repository.CanAccessTask(ctx, task_id, user.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: task #%d not found", ErrEmailRejected, task_id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: sender has no access to task #%d", ErrEmailRejected, task_id)
+	}
+
+	if in.Text == "" {
+		return nil, fmt.Errorf("%w: reply has no text", ErrEmailRejected)
+	}
+	if utf8.RuneCountInString(in.Text) > maxReplyLength {
+		return nil, fmt.Errorf("%w: reply is longer than %d characters", ErrEmailRejected, maxReplyLength)
+	}
+
+	com := &model.Comments{TaskID: task_id, UserID: user.ID, Text: in.Text}
+	if err := s.Comments.AddComment(com); err != nil {
+		return nil, err
+	}
+	if in.MessageID != "" {
+		if err := s.Repository.MarkProcessed(ctx, in.MessageID, com.ID); err != nil {
+			return nil, err
+		}
+	}
+	return com, nil
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"errors"
+	"pet-project/internal/repository"
+	"pet-project/pkg/model"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const defaultLabelColor = "#808080"
+
+var labelColorRe = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+type LabelService struct {
+	Repository repository.LabelRepository
+	Tasks      repository.TaskRepository
+}
+
+func (s *LabelService) CreateLabel(project_id int, name, color string) (*model.Label, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("Label name is required")
+	}
+	if color == "" {
+		color = defaultLabelColor
+	}
+	if !labelColorRe.MatchString(color) {
+		return nil, errors.New("Label color must be in #RRGGBB format")
+	}
+
+	label := &model.Label{
+		ProjectID: project_id,
+		Name:      name,
+		Color:     strings.ToLower(color),
+		CreatedAt: time.Now(),
+	}
+	if err := s.Repository.CreateLabel(label); err != nil {
+		return nil, errors.New("Label with this name already exists")
+	}
+	return label, nil
+}
+
+func (s *LabelService) GetLabel(label_id int) (*model.Label, error) {
+	return s.Repository.GetLabelByID(label_id)
+}
+
+func (s *LabelService) UpdateLabel(label *model.Label) error {
+	label.Name = strings.TrimSpace(label.Name)
+	if label.Name == "" {
+		return errors.New("Label name is required")
+	}
+	if !labelColorRe.MatchString(label.Color) {
+		return errors.New("Label color must be in #RRGGBB format")
+	}
+	label.Color = strings.ToLower(label.Color)
+
+	if err := s.Repository.UpdateLabel(label); err != nil {
+		return errors.New("Label with this name already exists")
+	}
+	return nil
+}
+
+func (s *LabelService) DeleteLabel(label_id int) error {
+	return s.Repository.DeleteLabel(label_id)
+}
+
+func (s *LabelService) ListByProject(project_id int) ([]*model.Label, error) {
+	return s.Repository.ListByProject(project_id)
+}
+
+func (s *LabelService) UsageByProject(project_id int) ([]*model.LabelUsage, error) {
+	return s.Repository.UsageByProject(project_id)
+}
+
+func (s *LabelService) AddToTask(task_id, label_id int) error {
+	task, err := s.Tasks.GetByIDTask(task_id)
+	if err != nil {
+		return errors.New("Task not found")
+	}
+	label, err := s.Repository.GetLabelByID(label_id)
+	if err != nil {
+		return errors.New("Label not found")
+	}
+	if label.ProjectID != task.ProjectID {
+		return errors.New("Label belongs to another project")
+	}
+	return s.Repository.AddToTask(task_id, label_id)
+}
+
+func (s *LabelService) RemoveFromTask(task_id, label_id int) error {
+	return s.Repository.RemoveFromTask(task_id, label_id)
+}
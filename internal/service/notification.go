@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"pet-project/internal/realtime"
 	"pet-project/internal/repository"
 	"pet-project/pkg/model"
@@ -26,16 +28,21 @@ func (s *NotificationService) Create(ctx context.Context, notif *model.Notificat
 	notif.CreatedAt = time.Now()
 	notif.IsRead = false
 
-	err := s.Repository.Create(ctx, notif)
-	if err != nil {
-		return err
-	}
+	// По WebSocket уведомление уходит через outbox, см. PushNotification
+	return s.Repository.Create(ctx, notif)
+}
 
-	// Отправляем уведомление через WebSocket если клиент подключен
-	if s.ClientManager != nil {
-		s.ClientManager.Send(notif.UserID, *notif)
+// PushNotification — подписчик outbox: отправляет созданное уведомление по WebSocket, если
+// пользователь подключён. Повтор приходит с тем же id, клиент может отсеять его сам.
+func (s *NotificationService) PushNotification(ctx context.Context, msg *model.OutboxMessage) error {
+	if s.ClientManager == nil {
+		return nil
 	}
-
+	var notif model.Notification
+	if err := json.Unmarshal([]byte(msg.Payload), &notif); err != nil {
+		return fmt.Errorf("Invalid notification payload: %w", err)
+	}
+	s.ClientManager.Send(notif.UserID, notif)
 	return nil
 }
 
@@ -111,3 +118,19 @@ func (s *NotificationService) CreateNotificationForMultipleUsers(ctx context.Con
 	}
 	return nil
 }
+
+// CreateTaskNotifications создает уведомления о задаче: по task_id на письмо можно ответить комментарием
+func (s *NotificationService) CreateTaskNotifications(ctx context.Context, taskID int, userIDs []int, notifType, message string) error {
+	for _, userID := range userIDs {
+		notif := &model.Notification{
+			UserID:  userID,
+			Type:    notifType,
+			Message: message,
+			TaskID:  &taskID,
+		}
+		if err := s.Create(ctx, notif); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"pet-project/internal/repository"
+	"pet-project/pkg/model"
+	"strings"
+	"time"
+)
+
+const (
+	defaultOutboxAttempts = 12
+	defaultOutboxBackoff  = 5 * time.Second
+	defaultOutboxMaxDelay = 30 * time.Minute
+	outboxBatchSize       = 100
+	outboxLease           = 5 * time.Minute
+	outboxCleanupEvery    = time.Hour
+)
+
+// OutboxHandler обрабатывает сообщение outbox. Сообщение может прийти повторно (например, если
+// диспетчер упал после обработки), поэтому обработчик должен отсеивать повторы по msg.ID.
+type OutboxHandler func(ctx context.Context, msg *model.OutboxMessage) error
+
+type outboxConsumer struct {
+	name    string
+	handler OutboxHandler
+}
+
+// OutboxDispatcher доставляет сообщения outbox подписчикам их темы — не меньше одного раза каждому.
+// Успешно отработавший подписчик отмечается и при повторе сообщения пропускается; сообщение
+// опубликовано, когда отработали все. Ошибка любого повторяется с экспоненциальной задержкой.
+type OutboxDispatcher struct {
+	Repository repository.OutboxRepository
+
+	// MaxAttempts — после стольких попыток сообщение помечается failed, 0 — по умолчанию 12
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	// Retention — сколько хранить опубликованные сообщения, 0 — не удалять
+	Retention time.Duration
+
+	consumers map[string][]outboxConsumer
+}
+
+// Subscribe регистрирует подписчика темы. Имя сохраняется в БД как отметка об обработке,
+// поэтому его нельзя менять, пока в outbox есть неопубликованные сообщения.
+func (d *OutboxDispatcher) Subscribe(topic, name string, handler OutboxHandler) {
+	if d.consumers == nil {
+		d.consumers = map[string][]outboxConsumer{}
+	}
+	d.consumers[topic] = append(d.consumers[topic], outboxConsumer{name: name, handler: handler})
+}
+
+// Run разбирает outbox раз в interval. Блокирует до отмены ctx.
+func (d *OutboxDispatcher) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var cleaned time.Time
+	for {
+		for {
+			n, err := d.Dispatch(ctx, time.Now())
+			if err != nil {
+				log.Println("Failed to dispatch outbox:", err)
+			}
+			// полная пачка — в outbox, скорее всего, есть ещё
+			if err != nil || n < outboxBatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+		if d.Retention > 0 && time.Since(cleaned) >= outboxCleanupEvery {
+			cleaned = time.Now()
+			if _, err := d.Repository.DeletePublished(ctx, cleaned.Add(-d.Retention)); err != nil {
+				log.Println("Failed to clean up outbox:", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch забирает пачку наступивших сообщений и по порядку передаёт их подписчикам.
+// Возвращает, сколько сообщений было взято.
+func (d *OutboxDispatcher) Dispatch(ctx context.Context, now time.Time) (int, error) {
+	messages, err := d.Repository.ClaimDue(ctx, now, now.Add(outboxLease), outboxBatchSize)
+	if err != nil || len(messages) == 0 {
+		return 0, err
+	}
+
+	ids := make([]int, 0, len(messages))
+	for _, msg := range messages {
+		ids = append(ids, msg.ID)
+	}
+	consumed, err := d.Repository.ListConsumed(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, msg := range messages {
+		if err := d.publish(ctx, msg, consumed[msg.ID]); err != nil {
+			return len(messages), err
+		}
+	}
+	return len(messages), nil
+}
+
+// publish передаёт сообщение подписчикам, которые его ещё не обработали, и сохраняет итог
+func (d *OutboxDispatcher) publish(ctx context.Context, msg *model.OutboxMessage, done []string) error {
+	var failures []string
+	for _, consumer := range d.consumers[msg.Topic] {
+		if containsString(done, consumer.name) {
+			continue
+		}
+		if err := consumer.handler(ctx, msg); err != nil {
+			failures = append(failures, consumer.name+": "+err.Error())
+			continue
+		}
+		if err := d.Repository.MarkConsumed(ctx, msg.ID, consumer.name); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	msg.LastError = strings.Join(failures, "; ")
+	switch {
+	case len(failures) == 0:
+		msg.Status = model.OutboxPublished
+		msg.PublishedAt = &now
+	case msg.Attempts >= d.maxAttempts():
+		msg.Status = model.OutboxFailed
+		log.Printf("Outbox: message %d (%s) failed after %d attempts: %s", msg.ID, msg.Topic, msg.Attempts, msg.LastError)
+	default:
+		msg.Status = model.OutboxPending
+		msg.NextAttemptAt = now.Add(expBackoff(d.Backoff, d.MaxBackoff, defaultOutboxBackoff, defaultOutboxMaxDelay, msg.Attempts))
+	}
+	return d.Repository.Finish(ctx, msg)
+}
+
+func (d *OutboxDispatcher) maxAttempts() int {
+	if d.MaxAttempts <= 0 {
+		return defaultOutboxAttempts
+	}
+	return d.MaxAttempts
+}
+
+// expBackoff — задержка после attempts неудачных попыток: base, 2×base, 4×base... не больше limit.
+// Нулевые base и limit заменяются значениями по умолчанию.
+func expBackoff(base, limit, defaultBase, defaultLimit time.Duration, attempts int) time.Duration {
+	if base <= 0 {
+		base = defaultBase
+	}
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	delay := base
+	for i := 1; i < attempts && delay < limit; i++ {
+		delay *= 2
+	}
+	if delay > limit {
+		delay = limit
+	}
+	return delay
+}
+
+// decodeEvent достаёт событие проекта из сообщения outbox
+func decodeEvent(msg *model.OutboxMessage) (model.ProjectEvent, error) {
+	var event model.ProjectEvent
+	if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+		return event, fmt.Errorf("Invalid event payload: %w", err)
+	}
+	event.ID = msg.ID
+	return event, nil
+}
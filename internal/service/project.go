@@ -12,6 +12,12 @@ type ProjectService struct {
 }
 
 func (s *ProjectService) CreateProject(name, description string, ownerID int) (*model.Project, error) {
+	return s.createProject(name, description, ownerID, s.Repository.CreateProject)
+}
+
+// createProject проверяет и подготавливает проект, а сохраняет его insert: так импорт записывает
+// проект вместе со ссылкой на него в задании в одной транзакции
+func (s *ProjectService) createProject(name, description string, ownerID int, insert func(project *model.Project) error) (*model.Project, error) {
 	if err := s.validateOwner(ownerID); err != nil {
 		return nil, err
 	}
@@ -27,8 +33,7 @@ func (s *ProjectService) CreateProject(name, description string, ownerID int) (*
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
-	project, err := s.saveProjectToDB(project)
-	if err != nil {
+	if err := insert(project); err != nil {
 		return nil, err
 	}
 	return project, nil
@@ -41,14 +46,6 @@ func (s *ProjectService) validateOwner(ownerID int) error {
 	return nil
 }
 
-func (s *ProjectService) saveProjectToDB(project *model.Project) (*model.Project, error) {
-	err := s.Repository.CreateProject(project)
-	if err != nil {
-		return nil, err
-	}
-	return project, nil
-}
-
 func (s *ProjectService) validatePName(name string) error {
 	if name == "" {
 		return errors.New("Project need a title!")
@@ -73,6 +70,24 @@ func (s *ProjectService) GetByIDProject(projectId int, ownerID int) (*model.Proj
 	return project, nil
 }
 
+// ErrNoProjectAccess — пользователь не владелец проекта и не участвует в его задачах
+var ErrNoProjectAccess = errors.New("You don't have permission to access this project")
+
+// CheckAccess пропускает владельца проекта и участников его задач
+func (s *ProjectService) CheckAccess(projectID, userID int) error {
+	if err := s.validateOwner(userID); err != nil {
+		return err
+	}
+	ok, err := s.Repository.CanAccessProject(projectID, userID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNoProjectAccess
+	}
+	return nil
+}
+
 func (s *ProjectService) UpdateProject(project *model.Project) error {
 	project.UpdatedAt = time.Now()
 	err := s.Repository.UpdateProject(project)
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"pet-project/internal/repository"
+	"pet-project/pkg/model"
+	"sort"
+	"time"
+)
+
+// reminderLockKey — ключ advisory-блокировки, под которой на кластере работает только одна реплика
+const reminderLockKey int64 = 0x72656d696e64 // "remind"
+
+// ReminderService напоминает исполнителям о приближающемся сроке и просрочке задач,
+// а при затянувшейся просрочке эскалирует владельцу проекта.
+type ReminderService struct {
+	Repository    repository.ReminderRepository
+	Locker        repository.Locker
+	Members       repository.TaskMemberRepository
+	Projects      repository.ProjectRepository
+	Notifications *NotificationService
+
+	// Windows — за сколько до срока напоминать, например 24h и 1h
+	Windows []time.Duration
+	// EscalateAfter — через сколько после срока сообщать владельцу проекта, 0 — не эскалировать
+	EscalateAfter time.Duration
+}
+
+// Run проверяет сроки раз в interval. Блокирует до отмены ctx.
+func (s *ReminderService) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Check(ctx, time.Now()); err != nil {
+			log.Println("Failed to check task reminders:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check отправляет все напоминания, наступившие к моменту now. Если блокировку держит
+// другая реплика, ничего не делает.
+func (s *ReminderService) Check(ctx context.Context, now time.Time) error {
+	unlock, ok, err := s.Locker.TryLock(ctx, reminderLockKey)
+	if err != nil || !ok {
+		return err
+	}
+	defer unlock()
+
+	windows := append([]time.Duration{}, s.Windows...)
+	sort.Slice(windows, func(i, j int) bool { return windows[i] < windows[j] })
+	var horizon time.Duration
+	if len(windows) > 0 {
+		horizon = windows[len(windows)-1]
+	}
+
+	tasks, err := s.Repository.ListOpenDueBefore(ctx, now.Add(horizon))
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	assignees := map[int][]int{}
+	if s.Members != nil {
+		assignees, err = s.Members.ListAssigneesByTasks(ids)
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, task := range tasks {
+			if task.AssignedTo > 0 {
+				assignees[task.ID] = []int{task.AssignedTo}
+			}
+		}
+	}
+
+	for _, task := range tasks {
+		if err := s.remind(ctx, task, assignees[task.ID], windows, now); err != nil {
+			log.Printf("Failed to send reminder for task %d: %v", task.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *ReminderService) remind(ctx context.Context, task *model.Task, assignees []int, windows []time.Duration, now time.Time) error {
+	due := *task.DueDate
+	title := shortTitle(task.Title)
+
+	if due.After(now) {
+		// Срабатывает самое узкое подходящее окно; более широкие помечаются заодно,
+		// чтобы задача, созданная за час до срока, не получила ещё и суточное напоминание
+		left := due.Sub(now)
+		for _, window := range windows {
+			if left > window {
+				continue
+			}
+			kind := "due:" + window.String()
+			claimed, err := s.Repository.Claim(ctx, task.ID, kind, due)
+			if err != nil {
+				return err
+			}
+			if claimed {
+				message := fmt.Sprintf("Task #%d \"%s\" is due in %s", task.ID, title, formatLeft(left))
+				if err := s.send(ctx, task.ID, kind, due, assignees, "task_due_soon", message); err != nil {
+					return err
+				}
+			}
+			break
+		}
+		return s.claimWider(ctx, task.ID, windows, left, due)
+	}
+
+	claimed, err := s.Repository.Claim(ctx, task.ID, "overdue", due)
+	if err != nil {
+		return err
+	}
+	if claimed {
+		message := fmt.Sprintf("Task #%d \"%s\" is overdue since %s", task.ID, title, due.Format("2006-01-02 15:04"))
+		if err := s.send(ctx, task.ID, "overdue", due, assignees, "task_overdue", message); err != nil {
+			return err
+		}
+	}
+
+	if s.EscalateAfter <= 0 || now.Sub(due) < s.EscalateAfter {
+		return nil
+	}
+	claimed, err = s.Repository.Claim(ctx, task.ID, "escalation", due)
+	if err != nil || !claimed {
+		return err
+	}
+
+	recipients := append([]int{}, assignees...)
+	if s.Projects != nil {
+		project, err := s.Projects.GetByIDProject(task.ProjectID)
+		if err != nil {
+			s.release(ctx, task.ID, "escalation", due)
+			return err
+		}
+		recipients = appendUnique(recipients, project.OwnerID)
+	}
+	message := fmt.Sprintf("Task #%d \"%s\" is overdue for %s", task.ID, title, formatLeft(now.Sub(due)))
+	return s.send(ctx, task.ID, "escalation", due, recipients, "task_escalated", message)
+}
+
+// claimWider помечает отправленными все окна шире сработавшего
+func (s *ReminderService) claimWider(ctx context.Context, task_id int, windows []time.Duration, left time.Duration, due time.Time) error {
+	matched := false
+	for _, window := range windows {
+		if left > window {
+			continue
+		}
+		if !matched {
+			matched = true
+			continue
+		}
+		if _, err := s.Repository.Claim(ctx, task_id, "due:"+window.String(), due); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// send отправляет занятое через Claim напоминание kind; если отправить не удалось, отметка
+// снимается, и напоминание повторится при следующей проверке
+func (s *ReminderService) send(ctx context.Context, task_id int, kind string, due time.Time, user_ids []int, notifType, message string) error {
+	if s.Notifications == nil || len(user_ids) == 0 {
+		return nil
+	}
+	if err := s.Notifications.CreateTaskNotifications(ctx, task_id, user_ids, notifType, message); err != nil {
+		s.release(ctx, task_id, kind, due)
+		return err
+	}
+	return nil
+}
+
+func (s *ReminderService) release(ctx context.Context, task_id int, kind string, due time.Time) {
+	if err := s.Repository.Release(ctx, task_id, kind, due); err != nil {
+		log.Printf("Failed to release reminder %s for task %d: %v", kind, task_id, err)
+	}
+}
+
+func appendUnique(ids []int, id int) []int {
+	for _, existing := range ids {
+		if existing == id {
+			return ids
+		}
+	}
+	return append(ids, id)
+}
+
+// shortTitle укорачивает заголовок, чтобы сообщение уместилось в колонку notification.message
+func shortTitle(title string) string {
+	runes := []rune(title)
+	if len(runes) <= 100 {
+		return title
+	}
+	return string(runes[:100]) + "…"
+}
+
+func formatLeft(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d >= 48*time.Hour {
+		return fmt.Sprintf("%d days", int(d.Hours()/24))
+	}
+	if d < time.Minute {
+		return "less than a minute"
+	}
+	return d.String()
+}
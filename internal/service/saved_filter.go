@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"pet-project/internal/repository"
+	"pet-project/pkg/model"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type SavedFilterService struct {
+	Repository repository.SavedFilterRepository
+	Tasks      *TaskService
+}
+
+func (s *SavedFilterService) CreateFilter(filter *model.SavedFilter) error {
+	if err := validateSavedFilter(filter); err != nil {
+		return err
+	}
+	if filter.ProjectID != nil {
+		if err := s.checkProjectAccess(*filter.ProjectID, filter.UserID); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	filter.CreatedAt = now
+	filter.UpdatedAt = now
+	if err := s.Repository.CreateFilter(filter); err != nil {
+		return errors.New("Filter with this name already exists")
+	}
+	return nil
+}
+
+// GetFilter возвращает свой фильтр пользователя или общий фильтр доступного ему проекта
+func (s *SavedFilterService) GetFilter(filter_id int, user_id int) (*model.SavedFilter, error) {
+	filter, err := s.Repository.GetFilterByID(filter_id)
+	if err != nil {
+		return nil, errors.New("Filter not found")
+	}
+	if filter.UserID == user_id {
+		return filter, nil
+	}
+	if !filter.Shared || filter.ProjectID == nil {
+		return nil, errors.New("Filter not found")
+	}
+	if err := s.checkProjectAccess(*filter.ProjectID, user_id); err != nil {
+		return nil, errors.New("Filter not found")
+	}
+	return filter, nil
+}
+
+func (s *SavedFilterService) ListFilters(user_id int, project_id *int) ([]*model.SavedFilter, error) {
+	return s.Repository.ListVisible(user_id, project_id)
+}
+
+func (s *SavedFilterService) UpdateFilter(filter *model.SavedFilter, user_id int) error {
+	if filter.UserID != user_id {
+		return errors.New("Only the author can change the filter")
+	}
+	if err := validateSavedFilter(filter); err != nil {
+		return err
+	}
+	filter.UpdatedAt = time.Now()
+	if err := s.Repository.UpdateFilter(filter); err != nil {
+		return errors.New("Filter with this name already exists")
+	}
+	return nil
+}
+
+func (s *SavedFilterService) DeleteFilter(filter *model.SavedFilter, user_id int) error {
+	if filter.UserID != user_id {
+		return errors.New("Only the author can delete the filter")
+	}
+	return s.Repository.DeleteFilter(filter.ID)
+}
+
+// RunFilter выполняет фильтр от имени user_id: me в выражении означает его, а не автора.
+// Условия из extra (например, ?q= из запроса) сужают выборку фильтра.
+func (s *SavedFilterService) RunFilter(filter *model.SavedFilter, user_id int, extra model.TaskFilter) ([]*model.Task, error) {
+	query := filter.Query
+	if extra.Query != "" {
+		query = "(" + filter.Query + ") AND (" + extra.Query + ")"
+	}
+	extra.Query = query
+	extra.Viewer = user_id
+
+	if filter.ProjectID == nil {
+		return s.Tasks.ListAccessibleTasks(user_id, extra)
+	}
+	if err := s.checkProjectAccess(*filter.ProjectID, user_id); err != nil {
+		return nil, err
+	}
+	return s.Tasks.ListByProjectTask(*filter.ProjectID, extra)
+}
+
+func (s *SavedFilterService) checkProjectAccess(project_id int, user_id int) error {
+	ok, err := s.Repository.CanAccessProject(project_id, user_id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("Project not found")
+	}
+	return nil
+}
+
+func validateSavedFilter(filter *model.SavedFilter) error {
+	filter.Name = strings.TrimSpace(filter.Name)
+	if filter.Name == "" {
+		return errors.New("Filter name is required")
+	}
+	if utf8.RuneCountInString(filter.Name) > 255 {
+		return errors.New("Filter name is too long")
+	}
+	filter.Query = strings.TrimSpace(filter.Query)
+	if filter.Query == "" {
+		return errors.New("Filter query is required")
+	}
+	if err := validateTaskQuery(filter.Query); err != nil {
+		return err
+	}
+	if filter.Shared && filter.ProjectID == nil {
+		return errors.New("Only project filters can be shared")
+	}
+	return nil
+}
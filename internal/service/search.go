@@ -0,0 +1,77 @@
+package service
+
+import (
+	"errors"
+	"html"
+	"pet-project/internal/repository"
+	"pet-project/pkg/model"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+var searchTypes = []string{model.SearchTask, model.SearchComment, model.SearchProject}
+
+type SearchService struct {
+	Repository repository.SearchRepository
+}
+
+// Search ищет по задачам, комментариям и проектам, доступным пользователю. Запрос в синтаксисе
+// websearch: слова, "точная фраза", OR, -исключение.
+func (s *SearchService) Search(query model.SearchQuery) ([]*model.SearchResult, error) {
+	query.Query = strings.TrimSpace(query.Query)
+	if query.Query == "" {
+		return nil, errors.New("Search query is required")
+	}
+	if utf8.RuneCountInString(query.Query) > 200 {
+		return nil, errors.New("Search query is too long")
+	}
+	if query.UserID <= 0 {
+		return nil, errors.New("invalid user ID")
+	}
+
+	if len(query.Types) == 0 {
+		query.Types = searchTypes
+	}
+	// Повторы убираем, иначе каждый дал бы в запросе свою ветку UNION ALL и дубли в результатах
+	types := make([]string, 0, len(query.Types))
+	for _, kind := range query.Types {
+		if !containsString(searchTypes, kind) {
+			return nil, errors.New("Invalid search type, use task, comment or project")
+		}
+		if !containsString(types, kind) {
+			types = append(types, kind)
+		}
+	}
+	query.Types = types
+
+	if query.Limit <= 0 {
+		query.Limit = defaultSearchLimit
+	}
+	if query.Limit > maxSearchLimit {
+		query.Limit = maxSearchLimit
+	}
+	if query.Offset < 0 {
+		query.Offset = 0
+	}
+
+	results, err := s.Repository.Search(query)
+	if err != nil {
+		return nil, err
+	}
+	for _, res := range results {
+		res.Snippet = highlight(res.Snippet)
+	}
+	return results, nil
+}
+
+// highlight экранирует фрагмент и заменяет служебные маркеры ts_headline на <mark>
+func highlight(snippet string) string {
+	snippet = html.EscapeString(snippet)
+	snippet = strings.ReplaceAll(snippet, repository.HighlightStart, "<mark>")
+	return strings.ReplaceAll(snippet, repository.HighlightStop, "</mark>")
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"errors"
+	"pet-project/internal/repository"
+	"pet-project/pkg/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type SprintService struct {
+	Repository repository.SprintRepository
+	Tasks      repository.TaskRepository
+}
+
+func (s *SprintService) CreateSprint(sprint *model.Sprint) error {
+	if err := validateSprint(sprint); err != nil {
+		return err
+	}
+	now := time.Now()
+	sprint.Status = model.SprintPlanned
+	sprint.CreatedAt = now
+	sprint.UpdatedAt = now
+	return s.Repository.CreateSprint(sprint)
+}
+
+func (s *SprintService) GetSprint(sprint_id int) (*model.Sprint, error) {
+	return s.Repository.GetSprintByID(sprint_id)
+}
+
+func (s *SprintService) ListByProject(project_id int) ([]*model.Sprint, error) {
+	return s.Repository.ListByProject(project_id)
+}
+
+func (s *SprintService) UpdateSprint(sprint *model.Sprint) error {
+	if sprint.Status == model.SprintCompleted {
+		return errors.New("Completed sprint can't be changed")
+	}
+	if err := validateSprint(sprint); err != nil {
+		return err
+	}
+	sprint.UpdatedAt = time.Now()
+	return s.Repository.UpdateSprint(sprint)
+}
+
+// DeleteSprint удаляет только запланированный спринт, чтобы не терять историю для burndown
+func (s *SprintService) DeleteSprint(sprint *model.Sprint) error {
+	if sprint.Status != model.SprintPlanned {
+		return errors.New("Only planned sprint can be deleted")
+	}
+	return s.Repository.DeleteSprint(sprint.ID)
+}
+
+func validateSprint(sprint *model.Sprint) error {
+	sprint.Name = strings.TrimSpace(sprint.Name)
+	if sprint.Name == "" {
+		return errors.New("Sprint name is required")
+	}
+	if sprint.StartDate.IsZero() || sprint.EndDate.IsZero() {
+		return errors.New("Sprint start and end dates are required")
+	}
+	if sprint.EndDate.Before(sprint.StartDate) {
+		return errors.New("Sprint can't end before it starts")
+	}
+	return nil
+}
+
+func (s *SprintService) StartSprint(sprint *model.Sprint) error {
+	if sprint.Status != model.SprintPlanned {
+		return errors.New("Only planned sprint can be started")
+	}
+	started, err := s.Repository.StartSprint(sprint.ID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !started {
+		return errors.New("Project already has an active sprint")
+	}
+	return nil
+}
+
+// CompleteSprint закрывает активный спринт. Незакрытые задачи переносятся в спринт carry_to
+// того же проекта или, если он не указан, возвращаются в бэклог.
+func (s *SprintService) CompleteSprint(sprint *model.Sprint, carry_to *int) (int64, error) {
+	if sprint.Status != model.SprintActive {
+		return 0, errors.New("Only active sprint can be completed")
+	}
+	if carry_to != nil {
+		target, err := s.Repository.GetSprintByID(*carry_to)
+		if err != nil {
+			return 0, errors.New("Target sprint not found")
+		}
+		if target.ID == sprint.ID || target.ProjectID != sprint.ProjectID || target.Status == model.SprintCompleted {
+			return 0, errors.New("Unfinished tasks can be carried over only to another open sprint of the same project")
+		}
+	}
+	return s.Repository.CompleteSprint(sprint.ID, carry_to, time.Now())
+}
+
+// SetTaskSprint добавляет задачу в спринт или, при sprint_id == nil, возвращает её в бэклог
+func (s *SprintService) SetTaskSprint(task_id int, sprint_id *int) error {
+	task, err := s.Tasks.GetByIDTask(task_id)
+	if err != nil {
+		return errors.New("Task not found")
+	}
+	if sprint_id != nil {
+		sprint, err := s.Repository.GetSprintByID(*sprint_id)
+		if err != nil {
+			return errors.New("Sprint not found")
+		}
+		if sprint.ProjectID != task.ProjectID {
+			return errors.New("Task and sprint must belong to the same project")
+		}
+		if sprint.Status == model.SprintCompleted {
+			return errors.New("Can't add tasks to a completed sprint")
+		}
+	}
+	return s.Repository.SetTaskSprint(task_id, sprint_id, time.Now())
+}
+
+// GetTask загружает задачу для проверки доступа к её проекту
+func (s *SprintService) GetTask(task_id int) (*model.Task, error) {
+	return s.Tasks.GetByIDTask(task_id)
+}
+
+func (s *SprintService) ListTasks(sprint_id int) ([]*model.Task, error) {
+	return s.Repository.ListTasks(sprint_id)
+}
+
+// Burndown восстанавливает по истории задач состав спринта, статусы и оценки на конец каждого дня
+// спринта (до сегодняшнего включительно).
+func (s *SprintService) Burndown(sprint *model.Sprint) (*model.Burndown, error) {
+	tasks, err := s.Repository.ListScopeTasks(sprint.ID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	events, err := s.Repository.ListHistory(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	histories := map[int]map[string][]*model.TaskEvent{}
+	for _, event := range events {
+		if histories[event.TaskID] == nil {
+			histories[event.TaskID] = map[string][]*model.TaskEvent{}
+		}
+		histories[event.TaskID][event.Field] = append(histories[event.TaskID][event.Field], event)
+	}
+
+	sprintKey := strconv.Itoa(sprint.ID)
+	start := dateOnly(sprint.StartDate)
+	end := dateOnly(sprint.EndDate)
+	last := end
+	if sprint.CompletedAt != nil && dateOnly(*sprint.CompletedAt).Before(last) {
+		last = dateOnly(*sprint.CompletedAt)
+	}
+	if today := dateOnly(time.Now()); today.Before(last) {
+		last = today
+	}
+
+	burndown := &model.Burndown{
+		SprintID:  sprint.ID,
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Format("2006-01-02"),
+		Days:      []*model.BurndownDay{},
+	}
+	totalDays := int(end.Sub(start).Hours()/24) + 1
+
+	for day := start; !day.After(last); day = day.AddDate(0, 0, 1) {
+		// состояние на конец дня — последнее изменение строго до полуночи следующего
+		moment := day.AddDate(0, 0, 1)
+		point := &model.BurndownDay{Date: day.Format("2006-01-02")}
+
+		for _, task := range tasks {
+			history := histories[task.ID]
+			if !existedAt(history["status"], moment) {
+				continue
+			}
+			sprintValue := valueAt(history["sprint_id"], moment, optionalInt(task.SprintID))
+			if sprintValue == nil || *sprintValue != sprintKey {
+				continue
+			}
+			status := valueAt(history["status"], moment, &task.Status)
+			estimate := valueAt(history["original_estimate"], moment, optionalInt(task.OriginalEstimate))
+			minutes := 0
+			if estimate != nil {
+				minutes, _ = strconv.Atoi(*estimate)
+			}
+
+			point.ScopeTasks++
+			point.ScopeMinutes += minutes
+			if status != nil && *status == "done" {
+				point.DoneTasks++
+				point.DoneMinutes += minutes
+			}
+		}
+		point.RemainingTasks = point.ScopeTasks - point.DoneTasks
+		point.RemainingMinutes = point.ScopeMinutes - point.DoneMinutes
+		burndown.Days = append(burndown.Days, point)
+	}
+
+	if len(burndown.Days) > 0 {
+		initial := float64(burndown.Days[0].ScopeTasks)
+		for i, point := range burndown.Days {
+			if totalDays > 1 {
+				point.IdealRemainingTasks = initial * float64(totalDays-1-i) / float64(totalDays-1)
+			}
+		}
+	}
+	return burndown, nil
+}
+
+// valueAt возвращает значение поля на момент moment по отсортированной истории. Если изменений
+// до moment нет, значением считается старое значение первого изменения, а без истории — текущее.
+func valueAt(history []*model.TaskEvent, moment time.Time, current *string) *string {
+	if len(history) == 0 {
+		return current
+	}
+	value := history[0].OldValue
+	for _, event := range history {
+		if !event.ChangedAt.Before(moment) {
+			break
+		}
+		value = event.NewValue
+	}
+	return value
+}
+
+// existedAt проверяет, что задача была создана до moment. Создание — это первое событие
+// истории статуса со старым значением NULL; у задач без истории считаем, что были всегда.
+func existedAt(statusHistory []*model.TaskEvent, moment time.Time) bool {
+	if len(statusHistory) == 0 || statusHistory[0].OldValue != nil {
+		return true
+	}
+	return statusHistory[0].ChangedAt.Before(moment)
+}
+
+func optionalInt(value *int) *string {
+	if value == nil {
+		return nil
+	}
+	str := strconv.Itoa(*value)
+	return &str
+}
+
+// dateOnly отбрасывает время, оставляя настенную дату: TIMESTAMP без зоны читается как UTC
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
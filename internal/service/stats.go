@@ -0,0 +1,35 @@
+package service
+
+import (
+	"errors"
+	"pet-project/internal/repository"
+	"pet-project/pkg/model"
+	"time"
+)
+
+const (
+	defaultThroughputWeeks = 12
+	maxThroughputWeeks     = 104
+)
+
+type StatsService struct {
+	Repository repository.StatsRepository
+}
+
+// ProjectStats считает сводку проекта; weeks — глубина ряда throughput, 0 — по умолчанию 12 недель
+func (s *StatsService) ProjectStats(project_id int, weeks int) (*model.ProjectStats, error) {
+	if weeks == 0 {
+		weeks = defaultThroughputWeeks
+	}
+	if weeks < 0 || weeks > maxThroughputWeeks {
+		return nil, errors.New("weeks must be between 1 and 104")
+	}
+	return s.Repository.ProjectStats(project_id, weeks, time.Now())
+}
+
+func (s *StatsService) UserSummary(user_id int) (*model.UserSummary, error) {
+	if user_id <= 0 {
+		return nil, errors.New("invalid user ID")
+	}
+	return s.Repository.UserSummary(user_id, time.Now())
+}
@@ -1,17 +1,39 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"pet-project/internal/markdown"
 	"pet-project/internal/repository"
+	"pet-project/internal/taskquery"
 	"pet-project/pkg/model"
 	"time"
 )
 
 type TaskService struct {
-	Repository repository.TaskRepository
+	Repository   repository.TaskRepository
+	Attachments  *AttachmentService
+	Dependencies repository.DependencyRepository
+	Labels       repository.LabelRepository
+	CustomFields repository.CustomFieldRepository
+
+	Members       repository.TaskMemberRepository
+	Notifications *NotificationService
+	Recurrences   repository.RecurrenceRepository
+	// Projects проверяет доступ перед назначением и подпиской
+	Projects *ProjectService
 }
 
 func (s *TaskService) CreateTask(task *model.Task) error {
+	return s.createTask(task, func(values []*model.CustomFieldValue, event *model.ProjectEvent) error {
+		return s.Repository.CreateTask(task, values, event)
+	})
+}
+
+// createTask проверяет и подготавливает задачу, а сохраняет её insert вместе со значениями полей: так импорт
+// записывает задачу вместе со своими данными в одной транзакции
+func (s *TaskService) createTask(task *model.Task, insert func(values []*model.CustomFieldValue, event *model.ProjectEvent) error) error {
 
 	if task.Title == "" {
 		return errors.New("Title is required")
@@ -23,14 +45,40 @@ func (s *TaskService) CreateTask(task *model.Task) error {
 		return errors.New("Status is required")
 	}
 
+	if task.ParentID != nil {
+		parent, err := s.Repository.GetByIDTask(*task.ParentID)
+		if err != nil {
+			return errors.New("Parent task not found")
+		}
+		if task.ProjectID == 0 {
+			task.ProjectID = parent.ProjectID
+		}
+		if parent.ProjectID != task.ProjectID {
+			return errors.New("Subtask must belong to the same project as its parent")
+		}
+	}
+
+	values, err := s.prepareCustomFields(task.ProjectID, task.CustomFields, true)
+	if err != nil {
+		return err
+	}
+
 	now := time.Now()
 	task.CreatedAt = now
 	task.UpdatedAt = now
 
-	err := s.Repository.CreateTask(task)
+	task.Rank, err = s.endRank(task.ProjectID, task.Status)
 	if err != nil {
 		return err
 	}
+
+	if err := insert(values, &model.ProjectEvent{Type: model.EventTaskCreated}); err != nil {
+		return err
+	}
+	if err := s.reloadCustomFields(task, values); err != nil {
+		return err
+	}
+	renderDescription(task)
 	return nil
 }
 
@@ -49,15 +97,75 @@ func (s *TaskService) UpdateTask(task *model.Task, user_id int) error {
 		return errors.New("Status is required")
 	}
 
-	if task.AssignedTo != user_id {
+	allowed, err := s.isAssignee(task, user_id)
+	if err != nil {
+		return err
+	}
+	if !allowed {
 		return errors.New("No permission to update task")
 	}
 
-	err := s.Repository.UpdateTask(task)
+	existing, err := s.Repository.GetByIDTask(task.ID)
 	if err != nil {
 		return err
 	}
+	// Срок в запросе на обновление не передаётся — сохраняем текущий, иначе вхождения серий теряют дату
+	if task.DueDate == nil {
+		task.DueDate = existing.DueDate
+	}
+
+	var values []*model.CustomFieldValue
+	if task.CustomFields != nil {
+		values, err = s.prepareCustomFields(existing.ProjectID, task.CustomFields, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Уведомления участникам, автоматизация и webhooks получат изменение через outbox
+	return s.saveTask(task, existing, values, taskUpdatedEvent(existing, task, user_id))
+}
+
+// saveTask сохраняет изменения задачи без проверки прав: при смене статуса карточка встаёт в конец
+// новой колонки, закрытие проверяет блокировки, закрывает подзадачи и создаёт следующее вхождение серии.
+// event (может быть nil) пишется в outbox вместе с изменением.
+func (s *TaskService) saveTask(task, existing *model.Task, values []*model.CustomFieldValue, event *model.ProjectEvent) error {
+	var err error
+	task.Rank = existing.Rank
+	if task.Status != existing.Status {
+		task.Rank, err = s.endRank(existing.ProjectID, task.Status)
+		if err != nil {
+			return err
+		}
+	}
 
+	if task.Status == "done" && s.Dependencies != nil {
+		// Закрытие закроет и подзадачи, поэтому их блокировки проверяются вместе с задачей
+		open, err := s.Dependencies.CountOpenSubtreeBlockers(task.ID)
+		if err != nil {
+			return err
+		}
+		if open > 0 {
+			return errors.New("Task or its subtasks are blocked by open tasks and can't be closed")
+		}
+	}
+
+	if err := s.Repository.UpdateTask(task, values, event); err != nil {
+		return err
+	}
+	if err := s.reloadCustomFields(task, values); err != nil {
+		return err
+	}
+
+	// Закрытие родителя закрывает и все его подзадачи
+	if task.Status == "done" {
+		if err := s.Repository.CloseDescendants(task.ID, task.UpdatedAt); err != nil {
+			return err
+		}
+	}
+	if task.Status == "done" && existing.Status != "done" && existing.SeriesID != nil && s.Recurrences != nil {
+		s.onOccurrenceClosed(*existing.SeriesID)
+	}
 	return nil
 }
 
@@ -67,7 +175,11 @@ func (s *TaskService) DeleteTask(task_id int, user_id int) error {
 		return err
 	}
 
-	if task.AssignedTo != user_id {
+	allowed, err := s.isAssignee(task, user_id)
+	if err != nil {
+		return err
+	}
+	if !allowed {
 		return errors.New("No permission to delete task")
 	}
 
@@ -75,25 +187,89 @@ func (s *TaskService) DeleteTask(task_id int, user_id int) error {
 		return errors.New("can't delete complete task")
 	}
 
-	return s.Repository.DeleteTask(task_id)
+	// Записи о вложениях удалятся каскадно, а сами файлы нужно убрать из хранилища отдельно
+	var attachments []*model.Attachment
+	if s.Attachments != nil {
+		attachments, err = s.Attachments.Repository.ListByTask(context.Background(), task_id)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := s.Repository.DeleteTask(task_id); err != nil {
+		return err
+	}
+
+	if s.Attachments != nil {
+		s.Attachments.RemoveFiles(context.Background(), attachments)
+	}
+	return nil
 }
 
-func (s *TaskService) ListByProjectTask(project_id int) ([]*model.Task, error) {
-	tasks, err := s.Repository.ListByProjectTask(project_id)
+func (s *TaskService) ListByProjectTask(project_id int, filter model.TaskFilter) ([]*model.Task, error) {
+	if err := s.resolveFilter(project_id, &filter); err != nil {
+		return nil, err
+	}
+
+	tasks, err := s.Repository.ListByProjectFiltered(project_id, filter)
 	if err != nil {
 		return nil, err
 	}
+	return s.prepareList(tasks)
+}
+
+// ListAccessibleTasks выбирает задачи по выражению из всех доступных пользователю проектов.
+// Пользовательские поля у проектов разные, поэтому условия и сортировка по ним здесь недоступны.
+func (s *TaskService) ListAccessibleTasks(user_id int, filter model.TaskFilter) ([]*model.Task, error) {
+	if len(filter.CustomFields) > 0 || (filter.Sort != nil && filter.Sort.Custom) {
+		return nil, errors.New("Custom fields can be used only within a project")
+	}
+	if err := validateTaskQuery(filter.Query); err != nil {
+		return nil, err
+	}
+
+	tasks, err := s.Repository.ListAccessibleFiltered(user_id, filter)
+	if err != nil {
+		return nil, err
+	}
+	return s.prepareList(tasks)
+}
+
+// prepareList дополняет выборку метками, пользовательскими полями и участниками
+func (s *TaskService) prepareList(tasks []*model.Task) ([]*model.Task, error) {
+	if err := s.attachLabels(tasks...); err != nil {
+		return nil, err
+	}
+	if err := s.attachCustomFields(tasks...); err != nil {
+		return nil, err
+	}
+	if err := s.attachMembers(tasks...); err != nil {
+		return nil, err
+	}
+	for _, task := range tasks {
+		renderDescription(task)
+	}
 	return tasks, nil
 }
 
 func (s *TaskService) GetByIDTask(task_id int, user_id int) (*model.Task, error) {
 	task, err := s.Repository.GetByIDTask(task_id)
+	if err != nil {
+		return nil, err
+	}
 	if task.Status == "done" {
 		return nil, errors.New("Task just is already")
 	}
-	if err != nil {
+	if err := s.attachLabels(task); err != nil {
+		return nil, err
+	}
+	if err := s.attachCustomFields(task); err != nil {
+		return nil, err
+	}
+	if err := s.attachMembers(task); err != nil {
 		return nil, err
 	}
+	renderDescription(task)
 	return task, nil
 
 }
@@ -106,3 +282,281 @@ func (s *TaskService) MarkTaskFinished(task *model.Task) error {
 	task.UpdatedAt = time.Now()
 	return nil
 }
+
+// GetTask загружает задачу как есть, без меток и полей — для проверки доступа
+func (s *TaskService) GetTask(task_id int) (*model.Task, error) {
+	return s.Repository.GetByIDTask(task_id)
+}
+
+func (s *TaskService) CreateSubtask(parent_id int, task *model.Task) error {
+	task.ParentID = &parent_id
+	return s.CreateTask(task)
+}
+
+// SetParent переносит задачу в иерархии; parent_id == nil делает её корневой
+func (s *TaskService) SetParent(task_id int, parent_id *int, user_id int) (*model.Task, error) {
+	task, err := s.Repository.GetByIDTask(task_id)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed, err := s.isAssignee(task, user_id)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, errors.New("No permission to update task")
+	}
+
+	if parent_id != nil {
+		if *parent_id == task_id {
+			return nil, errors.New("Task can't be its own parent")
+		}
+
+		parent, err := s.Repository.GetByIDTask(*parent_id)
+		if err != nil {
+			return nil, errors.New("Parent task not found")
+		}
+		if parent.ProjectID != task.ProjectID {
+			return nil, errors.New("Subtask must belong to the same project as its parent")
+		}
+
+		ancestors, err := s.Repository.ListAncestorIDs(*parent_id)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ancestors {
+			if id == task_id {
+				return nil, errors.New("Task hierarchy can't contain cycles")
+			}
+		}
+	}
+
+	task.ParentID = parent_id
+	task.UpdatedAt = time.Now()
+	if err := s.Repository.SetParent(task_id, parent_id, task.UpdatedAt); err != nil {
+		return nil, err
+	}
+	renderDescription(task)
+	return task, nil
+}
+
+// GetTaskTree возвращает задачу со всеми подзадачами и прогрессом по каждому узлу
+func (s *TaskService) GetTaskTree(task_id int) (*model.TaskNode, error) {
+	tasks, err := s.Repository.ListSubtree(task_id)
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		return nil, errors.New("Task not found")
+	}
+
+	nodes := make(map[int]*model.TaskNode, len(tasks))
+	for _, task := range tasks {
+		renderDescription(task)
+		nodes[task.ID] = &model.TaskNode{Task: task, Children: []*model.TaskNode{}}
+	}
+
+	var root *model.TaskNode
+	for _, task := range tasks {
+		node := nodes[task.ID]
+		if task.ID == task_id {
+			root = node
+			continue
+		}
+		if parent, ok := nodes[*task.ParentID]; ok {
+			parent.Children = append(parent.Children, node)
+		}
+	}
+
+	calcProgress(root)
+	return root, nil
+}
+
+// calcProgress считает долю закрытых прямых подзадач; у листа это 0 или 100 в зависимости от статуса
+func calcProgress(node *model.TaskNode) {
+	if len(node.Children) == 0 {
+		if node.Task.Status == "done" {
+			node.Progress = 100
+		}
+		return
+	}
+
+	closed := 0
+	for _, child := range node.Children {
+		calcProgress(child)
+		if child.Task.Status == "done" {
+			closed++
+		}
+	}
+	node.Progress = float64(closed) * 100 / float64(len(node.Children))
+}
+
+// SetEstimates задаёт исходную и оставшуюся оценку задачи в минутах; nil очищает оценку
+func (s *TaskService) SetEstimates(task_id int, original, remaining *int, user_id int) (*model.Task, error) {
+	task, err := s.Repository.GetByIDTask(task_id)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed, err := s.isAssignee(task, user_id)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, errors.New("No permission to update task")
+	}
+
+	if (original != nil && *original < 0) || (remaining != nil && *remaining < 0) {
+		return nil, errors.New("Estimate can't be negative")
+	}
+
+	task.OriginalEstimate = original
+	task.RemainingEstimate = remaining
+	task.UpdatedAt = time.Now()
+	if err := s.Repository.SetEstimates(task_id, original, remaining, task.UpdatedAt); err != nil {
+		return nil, err
+	}
+	renderDescription(task)
+	return task, nil
+}
+
+// attachLabels подгружает метки для списка задач одним запросом
+func (s *TaskService) attachLabels(tasks ...*model.Task) error {
+	if s.Labels == nil || len(tasks) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	labels, err := s.Labels.ListByTasks(ids)
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		task.Labels = labels[task.ID]
+		if task.Labels == nil {
+			task.Labels = []*model.Label{}
+		}
+	}
+	return nil
+}
+
+// prepareCustomFields проверяет значения пользовательских полей по определениям проекта.
+// При создании задачи дополнительно требует заполнить все обязательные поля.
+func (s *TaskService) prepareCustomFields(project_id int, raw map[string]interface{}, creating bool) ([]*model.CustomFieldValue, error) {
+	if s.CustomFields == nil || (len(raw) == 0 && !creating) {
+		return nil, nil
+	}
+
+	fields, err := s.CustomFields.ListByProject(project_id)
+	if err != nil {
+		return nil, err
+	}
+
+	values := []*model.CustomFieldValue{}
+	provided := map[int]bool{}
+	for name, rawValue := range raw {
+		field := findField(fields, name)
+		if field == nil {
+			return nil, fmt.Errorf("Unknown field %q", name)
+		}
+		value, err := parseCustomFieldValue(field, rawValue)
+		if err != nil {
+			return nil, err
+		}
+		provided[field.ID] = true
+		values = append(values, value)
+	}
+
+	if creating {
+		for _, field := range fields {
+			if field.Required && !provided[field.ID] {
+				return nil, fmt.Errorf("Field %q is required", field.Name)
+			}
+		}
+	}
+	return values, nil
+}
+
+// reloadCustomFields подгружает в задачу сохранённые значения полей, если они менялись
+func (s *TaskService) reloadCustomFields(task *model.Task, values []*model.CustomFieldValue) error {
+	if len(values) == 0 {
+		return nil
+	}
+	return s.attachCustomFields(task)
+}
+
+// attachCustomFields подгружает значения пользовательских полей для списка задач одним запросом
+func (s *TaskService) attachCustomFields(tasks ...*model.Task) error {
+	if s.CustomFields == nil || len(tasks) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	values, err := s.CustomFields.ListValuesByTasks(ids)
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		task.CustomFields = map[string]interface{}{}
+		for _, v := range values[task.ID] {
+			task.CustomFields[v.Name] = v.Value()
+		}
+	}
+	return nil
+}
+
+// resolveFilter проверяет выражение запроса и сопоставляет условия и сортировку по пользовательским полям с определениями проекта
+func (s *TaskService) resolveFilter(project_id int, filter *model.TaskFilter) error {
+	if err := validateTaskQuery(filter.Query); err != nil {
+		return err
+	}
+
+	needFields := len(filter.CustomFields) > 0 || (filter.Sort != nil && filter.Sort.Custom)
+	if !needFields {
+		return nil
+	}
+	if s.CustomFields == nil {
+		return errors.New("Custom fields are not supported")
+	}
+
+	fields, err := s.CustomFields.ListByProject(project_id)
+	if err != nil {
+		return err
+	}
+
+	for i := range filter.CustomFields {
+		if err := resolveCondition(fields, &filter.CustomFields[i]); err != nil {
+			return err
+		}
+	}
+
+	if filter.Sort != nil && filter.Sort.Custom {
+		field := findField(fields, filter.Sort.Field)
+		if field == nil {
+			return fmt.Errorf("Unknown field %q", filter.Sort.Field)
+		}
+		filter.Sort.FieldID = field.ID
+		filter.Sort.Type = field.Type
+	}
+	return nil
+}
+
+func validateTaskQuery(query string) error {
+	if query == "" {
+		return nil
+	}
+	_, err := taskquery.Parse(query)
+	return err
+}
+
+// renderDescription заполняет HTML-представление описания и найденные в нём ссылки
+func renderDescription(task *model.Task) {
+	task.DescriptionHTML, task.References = markdown.Render(task.Description)
+}
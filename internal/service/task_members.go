@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"pet-project/pkg/model"
+)
+
+// isAssignee проверяет, что пользователь — один из исполнителей задачи.
+// Без репозитория участников работает по-старому, сравнивая с AssignedTo.
+func (s *TaskService) isAssignee(task *model.Task, user_id int) (bool, error) {
+	if s.Members == nil {
+		return task.AssignedTo == user_id, nil
+	}
+	return s.Members.IsAssignee(task.ID, user_id)
+}
+
+// checkAccess проверяет, что пользователь — владелец или участник проекта задачи. Назначение и
+// подписка сами делают пользователя участником, поэтому без этой проверки их не выполнить.
+func (s *TaskService) checkAccess(task *model.Task, user_id int) error {
+	if s.Projects == nil {
+		return nil
+	}
+	return s.Projects.CheckAccess(task.ProjectID, user_id)
+}
+
+func (s *TaskService) AddAssignee(task_id, user_id, actor_id int) error {
+	task, err := s.Repository.GetByIDTask(task_id)
+	if err != nil {
+		return err
+	}
+	if user_id <= 0 {
+		return errors.New("invalid user ID")
+	}
+	if err := s.checkAccess(task, actor_id); err != nil {
+		return err
+	}
+
+	// Задачу без исполнителей может взять любой, иначе назначать могут только текущие исполнители
+	if err := s.attachMembers(task); err != nil {
+		return err
+	}
+	if len(task.Assignees) > 0 {
+		allowed, err := s.isAssignee(task, actor_id)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return errors.New("No permission to assign task")
+		}
+	}
+
+	if err := s.Members.AddAssignee(task_id, user_id); err != nil {
+		return err
+	}
+
+	if user_id != actor_id {
+		s.notify(task.ID, []int{user_id}, "task_assigned", fmt.Sprintf("You were assigned to task #%d \"%s\"", task.ID, shortTitle(task.Title)))
+	}
+	return nil
+}
+
+func (s *TaskService) RemoveAssignee(task_id, user_id, actor_id int) error {
+	task, err := s.Repository.GetByIDTask(task_id)
+	if err != nil {
+		return err
+	}
+
+	allowed, err := s.isAssignee(task, actor_id)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return errors.New("No permission to unassign task")
+	}
+
+	return s.Members.RemoveAssignee(task_id, user_id)
+}
+
+// AddWatcher подписывает пользователя на задачу: себя может подписать любой, других — только исполнитель
+func (s *TaskService) AddWatcher(task_id, user_id, actor_id int) error {
+	task, err := s.Repository.GetByIDTask(task_id)
+	if err != nil {
+		return err
+	}
+	if user_id <= 0 {
+		return errors.New("invalid user ID")
+	}
+	if err := s.checkAccess(task, actor_id); err != nil {
+		return err
+	}
+
+	if user_id != actor_id {
+		allowed, err := s.isAssignee(task, actor_id)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return errors.New("No permission to manage task watchers")
+		}
+	}
+	return s.Members.AddWatcher(task_id, user_id)
+}
+
+func (s *TaskService) RemoveWatcher(task_id, user_id, actor_id int) error {
+	task, err := s.Repository.GetByIDTask(task_id)
+	if err != nil {
+		return err
+	}
+
+	if user_id != actor_id {
+		allowed, err := s.isAssignee(task, actor_id)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return errors.New("No permission to manage task watchers")
+		}
+	}
+	return s.Members.RemoveWatcher(task_id, user_id)
+}
+
+// NotifyMembers рассылает уведомление исполнителям и наблюдателям задачи, кроме автора изменения
+func (s *TaskService) NotifyMembers(task_id, actor_id int, notifType, message string) {
+	if s.Notifications == nil {
+		return
+	}
+	recipients, err := s.memberRecipients(task_id, actor_id)
+	if err != nil {
+		log.Println("Failed to load task members:", err)
+		return
+	}
+	s.notify(task_id, recipients, notifType, message)
+}
+
+// memberRecipients возвращает исполнителей и наблюдателей задачи без повторов, кроме actor_id
+func (s *TaskService) memberRecipients(task_id, actor_id int) ([]int, error) {
+	if s.Members == nil {
+		return nil, nil
+	}
+	assignees, err := s.Members.ListAssigneesByTasks([]int{task_id})
+	if err != nil {
+		return nil, err
+	}
+	watchers, err := s.Members.ListWatchersByTasks([]int{task_id})
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[int]bool{actor_id: true}
+	recipients := []int{}
+	for _, id := range append(assignees[task_id], watchers[task_id]...) {
+		if !seen[id] {
+			seen[id] = true
+			recipients = append(recipients, id)
+		}
+	}
+	return recipients, nil
+}
+
+func (s *TaskService) notify(task_id int, user_ids []int, notifType, message string) {
+	if s.Notifications == nil || len(user_ids) == 0 {
+		return
+	}
+	err := s.Notifications.CreateTaskNotifications(context.Background(), task_id, user_ids, notifType, message)
+	if err != nil {
+		log.Println("Failed to send task notification:", err)
+	}
+}
+
+// attachMembers подгружает исполнителей и наблюдателей для списка задач
+func (s *TaskService) attachMembers(tasks ...*model.Task) error {
+	if s.Members == nil || len(tasks) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	assignees, err := s.Members.ListAssigneesByTasks(ids)
+	if err != nil {
+		return err
+	}
+	watchers, err := s.Members.ListWatchersByTasks(ids)
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		task.Assignees = assignees[task.ID]
+		if task.Assignees == nil {
+			task.Assignees = []int{}
+		}
+		task.Watchers = watchers[task.ID]
+		if task.Watchers == nil {
+			task.Watchers = []int{}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"pet-project/internal/recurrence"
+	"pet-project/pkg/model"
+	"time"
+)
+
+// SetRecurrence делает задачу первым вхождением новой серии или меняет правило существующей
+func (s *TaskService) SetRecurrence(task_id int, rule string, user_id int) (*model.Recurrence, error) {
+	if s.Recurrences == nil {
+		return nil, errors.New("Recurring tasks are not configured")
+	}
+
+	task, err := s.Repository.GetByIDTask(task_id)
+	if err != nil {
+		return nil, errors.New("Task not found")
+	}
+	allowed, err := s.isAssignee(task, user_id)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, errors.New("No permission to change task recurrence")
+	}
+
+	parsed, err := recurrence.Parse(rule)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	if task.SeriesID != nil {
+		rec, err := s.Recurrences.GetSeries(*task.SeriesID)
+		if err != nil {
+			return nil, err
+		}
+		// Новое правило отсчитывается от последнего созданного вхождения
+		last, err := s.Recurrences.LastOccurrence(rec.ID)
+		if err != nil {
+			return nil, err
+		}
+		if last != nil {
+			rec.StartAt = *last
+		}
+		rec.Rule = parsed.String()
+		rec.NextAt = nextOccurrence(parsed, rec.StartAt, rec.StartAt, rec.Generated)
+		rec.UpdatedAt = now
+		if err := s.Recurrences.UpdateSeries(rec); err != nil {
+			return nil, err
+		}
+		return rec, nil
+	}
+
+	start := now.Truncate(time.Minute)
+	if task.DueDate != nil {
+		start = *task.DueDate
+	}
+	rec := &model.Recurrence{
+		ProjectID:   task.ProjectID,
+		Rule:        parsed.String(),
+		Title:       task.Title,
+		Description: task.Description,
+		Priority:    task.Priority,
+		AssignedTo:  task.AssignedTo,
+		StartAt:     start,
+		Generated:   1,
+		CreatedBy:   user_id,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	rec.NextAt = nextOccurrence(parsed, start, start, rec.Generated)
+
+	if err := s.Recurrences.CreateSeries(rec, task.ID); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+func (s *TaskService) GetRecurrence(task_id int) (*model.Recurrence, error) {
+	if s.Recurrences == nil {
+		return nil, errors.New("Recurring tasks are not configured")
+	}
+	task, err := s.Repository.GetByIDTask(task_id)
+	if err != nil {
+		return nil, errors.New("Task not found")
+	}
+	if task.SeriesID == nil {
+		return nil, errors.New("Task is not recurring")
+	}
+	return s.Recurrences.GetSeries(*task.SeriesID)
+}
+
+// StopRecurrence удаляет серию; созданные вхождения остаются обычными задачами
+func (s *TaskService) StopRecurrence(task_id int, user_id int) error {
+	if s.Recurrences == nil {
+		return errors.New("Recurring tasks are not configured")
+	}
+	task, err := s.Repository.GetByIDTask(task_id)
+	if err != nil {
+		return errors.New("Task not found")
+	}
+	if task.SeriesID == nil {
+		return errors.New("Task is not recurring")
+	}
+	allowed, err := s.isAssignee(task, user_id)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return errors.New("No permission to change task recurrence")
+	}
+	return s.Recurrences.DeleteSeries(*task.SeriesID)
+}
+
+// UpdateTaskSeries обновляет вхождение и переносит изменения в шаблон серии
+// и во все незакрытые вхождения не раньше этого
+func (s *TaskService) UpdateTaskSeries(task *model.Task, user_id int) error {
+	if s.Recurrences == nil {
+		return errors.New("Recurring tasks are not configured")
+	}
+	existing, err := s.Repository.GetByIDTask(task.ID)
+	if err != nil {
+		return errors.New("Task not found")
+	}
+	if existing.SeriesID == nil {
+		return errors.New("Task is not recurring")
+	}
+
+	if err := s.UpdateTask(task, user_id); err != nil {
+		return err
+	}
+
+	rec, err := s.Recurrences.GetSeries(*existing.SeriesID)
+	if err != nil {
+		return err
+	}
+	rec.Title = task.Title
+	rec.Description = task.Description
+	rec.Priority = task.Priority
+	rec.AssignedTo = task.AssignedTo
+	rec.UpdatedAt = task.UpdatedAt
+	if err := s.Recurrences.UpdateSeries(rec); err != nil {
+		return err
+	}
+
+	var from time.Time
+	if existing.DueDate != nil {
+		from = *existing.DueDate
+	}
+	_, err = s.Recurrences.ApplyTemplate(rec, from, task.UpdatedAt)
+	return err
+}
+
+// onOccurrenceClosed создаёт следующее вхождение досрочно, если в серии не осталось открытых задач
+func (s *TaskService) onOccurrenceClosed(series_id int) {
+	open, err := s.Recurrences.CountOpen(series_id)
+	if err != nil {
+		log.Println("Failed to count open occurrences:", err)
+		return
+	}
+	if open > 0 {
+		return
+	}
+
+	rec, err := s.Recurrences.GetSeries(series_id)
+	if err != nil {
+		log.Println("Failed to load recurrence:", err)
+		return
+	}
+	if _, err := s.materialize(rec, time.Now(), true); err != nil {
+		log.Println("Failed to create next occurrence:", err)
+	}
+}
+
+// materialize создаёт вхождение на дату rec.NextAt. Без force — только если эта дата уже наступила;
+// пропущенные за время простоя даты схлопываются в одно вхождение на последнюю из них.
+func (s *TaskService) materialize(rec *model.Recurrence, now time.Time, force bool) (*model.Task, error) {
+	if rec.NextAt == nil {
+		return nil, nil
+	}
+	rule, err := recurrence.Parse(rec.Rule)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := *rec.NextAt
+	occurrence := expected
+	if !force {
+		if occurrence.After(now) {
+			return nil, nil
+		}
+		for {
+			next, ok := rule.Next(rec.StartAt, occurrence)
+			if !ok || next.After(now) {
+				break
+			}
+			occurrence = next
+		}
+	}
+	rec.NextAt = nextOccurrence(rule, rec.StartAt, occurrence, rec.Generated+1)
+
+	task := &model.Task{
+		Title:       rec.Title,
+		Description: rec.Description,
+		Status:      "pending",
+		Priority:    rec.Priority,
+		AssignedTo:  rec.AssignedTo,
+		ProjectID:   rec.ProjectID,
+		SeriesID:    &rec.ID,
+		DueDate:     &occurrence,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	task.Rank, err = s.endRank(task.ProjectID, task.Status)
+	if err != nil {
+		return nil, err
+	}
+	created, err := s.Recurrences.Materialize(rec, expected, task, &model.ProjectEvent{Type: model.EventTaskCreated})
+	if err != nil || !created {
+		return nil, err
+	}
+
+	s.NotifyMembers(task.ID, 0, "task_recurred", fmt.Sprintf("New occurrence of task \"%s\" is due %s", shortTitle(task.Title), occurrence.Format("2006-01-02")))
+	return task, nil
+}
+
+// nextOccurrence учитывает COUNT: после generated созданных вхождений серия может быть исчерпана
+func nextOccurrence(rule *recurrence.Rule, start, after time.Time, generated int) *time.Time {
+	if rule.Count > 0 && generated >= rule.Count {
+		return nil
+	}
+	next, ok := rule.Next(start, after)
+	if !ok {
+		return nil
+	}
+	return &next
+}
+
+// RunRecurrenceScheduler периодически создаёт вхождения, дата которых наступила. Блокирует до отмены ctx.
+func (s *TaskService) RunRecurrenceScheduler(ctx context.Context, interval time.Duration) {
+	if s.Recurrences == nil || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.materializeDue(time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *TaskService) materializeDue(now time.Time) {
+	ids, err := s.Recurrences.ListDue(now)
+	if err != nil {
+		log.Println("Failed to list due recurrences:", err)
+		return
+	}
+	for _, id := range ids {
+		rec, err := s.Recurrences.GetSeries(id)
+		if err != nil {
+			log.Println("Failed to load recurrence:", err)
+			continue
+		}
+		if _, err := s.materialize(rec, now, false); err != nil {
+			log.Printf("Failed to materialize recurrence %d: %v", id, err)
+		}
+	}
+}
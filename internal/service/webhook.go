@@ -0,0 +1,423 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"pet-project/internal/repository"
+	"pet-project/pkg/model"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultWebhookAttempts = 8
+	defaultWebhookBackoff  = 30 * time.Second
+	defaultWebhookMaxDelay = time.Hour
+	webhookBatchSize       = 20
+	webhookLease           = 2 * time.Minute
+	webhookResponseLimit   = 1024
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 200
+)
+
+// Заголовки запроса webhook. Подпись — "sha256=" и hex от HMAC-SHA256 тела с ключом подписки.
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature-256"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// webhookEvents — события, на которые можно подписаться
+var webhookEvents = []string{model.EventTaskCreated, model.EventTaskUpdated, model.EventCommentCreated}
+
+// WebhookService хранит подписки проектов и доставляет им события. HandleEvent только ставит отправки
+// в очередь в БД, сами запросы делает Run: неудачные повторяются с экспоненциальной задержкой,
+// пока не кончатся MaxAttempts.
+type WebhookService struct {
+	Repository repository.WebhookRepository
+	Tasks      repository.TaskRepository
+	Comments   repository.CommentsRepository
+	Client     *http.Client
+
+	// MaxAttempts — сколько раз пытаться доставить событие, 0 — по умолчанию 8
+	MaxAttempts int
+	// Backoff — задержка перед первым повтором, дальше она удваивается до MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// WebhookPayload — тело запроса webhook
+type WebhookPayload struct {
+	// EventID одинаков у всех отправок одного события, в том числе повторных, — по нему получатель отсеивает дубли
+	EventID    int                          `json:"eventID,omitempty"`
+	Event      string                       `json:"event"`
+	ProjectID  int                          `json:"projectID"`
+	TaskID     int                          `json:"taskID,omitempty"`
+	CommentID  int                          `json:"commentID,omitempty"`
+	ActorID    int                          `json:"actorID,omitempty"`
+	Changes    map[string]model.FieldChange `json:"changes,omitempty"`
+	Task       *model.Task                  `json:"task,omitempty"`
+	Comment    *model.Comments              `json:"comment,omitempty"`
+	OccurredAt time.Time                    `json:"occurredAt"`
+}
+
+// SignPayload возвращает значение заголовка подписи для тела body
+func SignPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *WebhookService) CreateWebhook(webhook *model.Webhook) error {
+	if err := validateWebhook(webhook); err != nil {
+		return err
+	}
+	if webhook.Secret == "" {
+		secret, err := generateSecret()
+		if err != nil {
+			return err
+		}
+		webhook.Secret = secret
+	}
+	now := time.Now()
+	webhook.CreatedAt = now
+	webhook.UpdatedAt = now
+	return s.Repository.CreateWebhook(webhook)
+}
+
+func (s *WebhookService) GetWebhook(webhook_id int) (*model.Webhook, error) {
+	return s.Repository.GetWebhookByID(webhook_id)
+}
+
+func (s *WebhookService) ListWebhooks(project_id int) ([]*model.Webhook, error) {
+	return s.Repository.ListByProject(project_id)
+}
+
+// UpdateWebhook сохраняет подписку; пустой Secret означает выпустить новый ключ
+func (s *WebhookService) UpdateWebhook(webhook *model.Webhook) error {
+	if err := validateWebhook(webhook); err != nil {
+		return err
+	}
+	if webhook.Secret == "" {
+		secret, err := generateSecret()
+		if err != nil {
+			return err
+		}
+		webhook.Secret = secret
+	}
+	webhook.UpdatedAt = time.Now()
+	return s.Repository.UpdateWebhook(webhook)
+}
+
+func (s *WebhookService) DeleteWebhook(webhook_id int) error {
+	return s.Repository.DeleteWebhook(webhook_id)
+}
+
+func validateWebhook(webhook *model.Webhook) error {
+	webhook.URL = strings.TrimSpace(webhook.URL)
+	target, err := url.Parse(webhook.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return errors.New("Webhook URL must be an absolute http(s) URL")
+	}
+	if len(webhook.Secret) > 0 && len(webhook.Secret) < 16 {
+		return errors.New("Webhook secret must be at least 16 characters long")
+	}
+
+	if len(webhook.Events) == 0 {
+		webhook.Events = append([]string{}, webhookEvents...)
+		return nil
+	}
+	events := make([]string, 0, len(webhook.Events))
+	for _, event := range webhook.Events {
+		if !containsString(webhookEvents, event) {
+			return fmt.Errorf("Unknown webhook event %q", event)
+		}
+		if !containsString(events, event) {
+			events = append(events, event)
+		}
+	}
+	webhook.Events = events
+	return nil
+}
+
+func generateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// HandleEvent — подписчик outbox: ставит событие в очередь каждому активному webhook проекта,
+// подписанному на него. Тело фиксируется сейчас, чтобы повторы отправляли то же самое; повторная
+// доставка того же сообщения outbox новых отправок не создаёт.
+func (s *WebhookService) HandleEvent(ctx context.Context, msg *model.OutboxMessage) error {
+	event, err := decodeEvent(msg)
+	if err != nil {
+		return err
+	}
+	payload := WebhookPayload{
+		EventID:    event.ID,
+		Event:      event.Type,
+		ProjectID:  event.ProjectID,
+		TaskID:     event.TaskID,
+		CommentID:  event.CommentID,
+		ActorID:    event.ActorID,
+		Changes:    event.Changes,
+		OccurredAt: msg.CreatedAt,
+	}
+
+	webhooks, err := s.Repository.ListSubscribed(payload.ProjectID, event.Type)
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+
+	// задачу или комментарий могли удалить, пока событие ждало в outbox, — тогда шлём без снимка
+	if event.TaskID != 0 {
+		task, err := s.Tasks.GetByIDTask(event.TaskID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		payload.Task = task
+	}
+	if event.CommentID != 0 && s.Comments != nil {
+		com, err := s.Comments.GetCommentByID(event.CommentID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		payload.Comment = com
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	for _, webhook := range webhooks {
+		if _, err := s.enqueue(webhook.ID, event.ID, event.Type, string(body)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *WebhookService) enqueue(webhook_id, event_id int, event, payload string) (*model.WebhookDelivery, error) {
+	now := time.Now()
+	delivery := &model.WebhookDelivery{
+		WebhookID:     webhook_id,
+		Event:         event,
+		EventID:       event_id,
+		Payload:       payload,
+		Status:        model.DeliveryPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+	if err := s.Repository.EnqueueDelivery(delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Ping ставит в очередь тестовое событие, чтобы проверить адрес и подпись
+func (s *WebhookService) Ping(webhook *model.Webhook) (*model.WebhookDelivery, error) {
+	body, err := json.Marshal(WebhookPayload{
+		Event:      model.EventPing,
+		ProjectID:  webhook.ProjectID,
+		OccurredAt: time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.enqueue(webhook.ID, 0, model.EventPing, string(body))
+}
+
+func (s *WebhookService) ListDeliveries(webhook_id int, status string, limit int) ([]*model.WebhookDelivery, error) {
+	if status != "" && status != model.DeliveryPending && status != model.DeliveryDelivered && status != model.DeliveryFailed {
+		return nil, errors.New("Invalid delivery status")
+	}
+	if limit <= 0 {
+		limit = defaultDeliveriesLimit
+	}
+	if limit > maxDeliveriesLimit {
+		limit = maxDeliveriesLimit
+	}
+	return s.Repository.ListDeliveries(webhook_id, status, limit)
+}
+
+// GetDelivery возвращает отправку вместе с журналом попыток
+func (s *WebhookService) GetDelivery(webhook_id, delivery_id int) (*model.WebhookDelivery, error) {
+	delivery, err := s.Repository.GetDelivery(delivery_id)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.WebhookID != webhook_id {
+		return nil, errors.New("Delivery not found")
+	}
+	delivery.Log, err = s.Repository.ListAttempts(delivery.ID)
+	if err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Redeliver ставит в очередь новую отправку с тем же телом; исходная остаётся в журнале как есть
+func (s *WebhookService) Redeliver(webhook_id, delivery_id int) (*model.WebhookDelivery, error) {
+	delivery, err := s.Repository.GetDelivery(delivery_id)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.WebhookID != webhook_id {
+		return nil, errors.New("Delivery not found")
+	}
+	return s.enqueue(webhook_id, 0, delivery.Event, delivery.Payload)
+}
+
+// Run отправляет наступившие доставки раз в interval. Блокирует до отмены ctx.
+func (s *WebhookService) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := s.ProcessDue(ctx, time.Now())
+			if err != nil {
+				log.Println("Failed to deliver webhooks:", err)
+			}
+			// полная пачка — в очереди, скорее всего, есть ещё
+			if err != nil || n < webhookBatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessDue забирает пачку отправок, наступивших к now, и отправляет их параллельно.
+// Возвращает, сколько отправок было взято.
+func (s *WebhookService) ProcessDue(ctx context.Context, now time.Time) (int, error) {
+	deliveries, err := s.Repository.ClaimDue(ctx, now, now.Add(webhookLease), webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	webhooks := map[int]*model.Webhook{}
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		webhook, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			webhook, err = s.Repository.GetWebhookByID(delivery.WebhookID)
+			if err != nil {
+				// подписку удалили между захватом и отправкой; отправки удалятся вместе с ней
+				log.Printf("Webhooks: webhook %d not found: %v", delivery.WebhookID, err)
+				continue
+			}
+			webhooks[delivery.WebhookID] = webhook
+		}
+
+		wg.Add(1)
+		go func(delivery *model.WebhookDelivery, webhook *model.Webhook) {
+			defer wg.Done()
+			if err := s.deliver(ctx, delivery, webhook); err != nil {
+				log.Printf("Webhooks: failed to save delivery %d: %v", delivery.ID, err)
+			}
+		}(delivery, webhook)
+	}
+	wg.Wait()
+	return len(deliveries), nil
+}
+
+// deliver делает одну попытку и решает судьбу отправки: доставлена, повтор позже или отказ
+func (s *WebhookService) deliver(ctx context.Context, delivery *model.WebhookDelivery, webhook *model.Webhook) error {
+	attempt := &model.WebhookAttempt{
+		DeliveryID: delivery.ID,
+		Attempt:    delivery.Attempts,
+		CreatedAt:  time.Now(),
+	}
+
+	if !webhook.Active {
+		attempt.Error = "Webhook is disabled"
+	} else {
+		s.send(ctx, delivery, webhook, attempt)
+	}
+
+	now := time.Now()
+	delivery.LastStatusCode = attempt.StatusCode
+	delivery.LastError = attempt.Error
+	switch {
+	case attempt.Error == "":
+		delivery.Status = model.DeliveryDelivered
+		delivery.DeliveredAt = &now
+	case !webhook.Active || delivery.Attempts >= s.maxAttempts():
+		delivery.Status = model.DeliveryFailed
+	default:
+		delivery.Status = model.DeliveryPending
+		delivery.NextAttemptAt = now.Add(expBackoff(s.Backoff, s.MaxBackoff, defaultWebhookBackoff, defaultWebhookMaxDelay, delivery.Attempts))
+	}
+	return s.Repository.FinishAttempt(ctx, delivery, attempt)
+}
+
+// send выполняет запрос и записывает в attempt код ответа, начало тела и время; любой ответ
+// кроме 2xx считается ошибкой
+func (s *WebhookService) send(ctx context.Context, delivery *model.WebhookDelivery, webhook *model.Webhook, attempt *model.WebhookAttempt) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "pet-project-webhooks")
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookDeliveryHeader, strconv.Itoa(delivery.ID))
+	req.Header.Set(WebhookSignatureHeader, SignPayload(webhook.Secret, body))
+
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	started := time.Now()
+	resp, err := client.Do(req)
+	attempt.DurationMs = int(time.Since(started).Milliseconds())
+	if err != nil {
+		attempt.Error = err.Error()
+		return
+	}
+	defer resp.Body.Close()
+
+	code := resp.StatusCode
+	attempt.StatusCode = &code
+	head, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	attempt.Response = strings.ToValidUTF8(string(head), "")
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if code < 200 || code >= 300 {
+		attempt.Error = fmt.Sprintf("Webhook responded with %s", resp.Status)
+	}
+}
+
+func (s *WebhookService) maxAttempts() int {
+	if s.MaxAttempts <= 0 {
+		return defaultWebhookAttempts
+	}
+	return s.MaxAttempts
+}
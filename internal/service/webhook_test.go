@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"pet-project/pkg/model"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryWebhookRepository — WebhookRepository в памяти с той же семантикой захвата, что у Postgres
+type memoryWebhookRepository struct {
+	mu         sync.Mutex
+	webhooks   map[int]*model.Webhook
+	deliveries map[int]*model.WebhookDelivery
+	attempts   []*model.WebhookAttempt
+}
+
+func newMemoryWebhookRepository() *memoryWebhookRepository {
+	return &memoryWebhookRepository{webhooks: map[int]*model.Webhook{}, deliveries: map[int]*model.WebhookDelivery{}}
+}
+
+func (r *memoryWebhookRepository) CreateWebhook(webhook *model.Webhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	webhook.ID = len(r.webhooks) + 1
+	copied := *webhook
+	r.webhooks[webhook.ID] = &copied
+	return nil
+}
+
+func (r *memoryWebhookRepository) UpdateWebhook(webhook *model.Webhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *webhook
+	r.webhooks[webhook.ID] = &copied
+	return nil
+}
+
+func (r *memoryWebhookRepository) GetWebhookByID(id int) (*model.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	webhook, ok := r.webhooks[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *webhook
+	return &copied, nil
+}
+
+func (r *memoryWebhookRepository) DeleteWebhook(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.webhooks, id)
+	return nil
+}
+
+func (r *memoryWebhookRepository) ListByProject(projectID int) ([]*model.Webhook, error) {
+	return nil, nil
+}
+
+func (r *memoryWebhookRepository) ListSubscribed(projectID int, event string) ([]*model.Webhook, error) {
+	return nil, nil
+}
+
+func (r *memoryWebhookRepository) EnqueueDelivery(delivery *model.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delivery.ID = len(r.deliveries) + 1
+	copied := *delivery
+	r.deliveries[delivery.ID] = &copied
+	return nil
+}
+
+func (r *memoryWebhookRepository) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var claimed []*model.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if delivery.Status != model.DeliveryPending || delivery.NextAttemptAt.After(now) || len(claimed) == limit {
+			continue
+		}
+		delivery.NextAttemptAt = leaseUntil
+		delivery.Attempts++
+		copied := *delivery
+		claimed = append(claimed, &copied)
+	}
+	return claimed, nil
+}
+
+func (r *memoryWebhookRepository) FinishAttempt(ctx context.Context, delivery *model.WebhookDelivery, attempt *model.WebhookAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	attempt.ID = len(r.attempts) + 1
+	r.attempts = append(r.attempts, attempt)
+	copied := *delivery
+	r.deliveries[delivery.ID] = &copied
+	return nil
+}
+
+func (r *memoryWebhookRepository) GetDelivery(id int) (*model.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delivery, ok := r.deliveries[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *delivery
+	return &copied, nil
+}
+
+func (r *memoryWebhookRepository) ListDeliveries(webhookID int, status string, limit int) ([]*model.WebhookDelivery, error) {
+	return nil, nil
+}
+
+func (r *memoryWebhookRepository) ListAttempts(deliveryID int) ([]*model.WebhookAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var attempts []*model.WebhookAttempt
+	for _, attempt := range r.attempts {
+		if attempt.DeliveryID == deliveryID {
+			attempts = append(attempts, attempt)
+		}
+	}
+	sort.Slice(attempts, func(i, j int) bool { return attempts[i].Attempt < attempts[j].Attempt })
+	return attempts, nil
+}
+
+// receivedRequest — то, что получил тестовый сервер
+type receivedRequest struct {
+	body      string
+	signature string
+	event     string
+	delivery  string
+}
+
+// webhookReceiver отвечает кодами из statuses по очереди, последний повторяется
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []receivedRequest
+}
+
+func (rcv *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rcv.mu.Lock()
+	rcv.requests = append(rcv.requests, receivedRequest{
+		body:      string(body),
+		signature: r.Header.Get(WebhookSignatureHeader),
+		event:     r.Header.Get(WebhookEventHeader),
+		delivery:  r.Header.Get(WebhookDeliveryHeader),
+	})
+	status := rcv.statuses[0]
+	if len(rcv.statuses) > 1 {
+		rcv.statuses = rcv.statuses[1:]
+	}
+	rcv.mu.Unlock()
+	w.WriteHeader(status)
+	io.WriteString(w, http.StatusText(status))
+}
+
+func newWebhookTest(t *testing.T, statuses ...int) (*WebhookService, *memoryWebhookRepository, *webhookReceiver, *model.Webhook) {
+	receiver := &webhookReceiver{statuses: statuses}
+	srv := httptest.NewServer(receiver)
+	t.Cleanup(srv.Close)
+
+	repo := newMemoryWebhookRepository()
+	s := &WebhookService{
+		Repository:  repo,
+		Client:      srv.Client(),
+		MaxAttempts: 3,
+		Backoff:     time.Minute,
+		MaxBackoff:  time.Hour,
+	}
+	webhook := &model.Webhook{ProjectID: 1, URL: srv.URL + "/hook", Secret: "0123456789abcdef", Active: true}
+	if err := s.CreateWebhook(webhook); err != nil {
+		t.Fatal(err)
+	}
+	return s, repo, receiver, webhook
+}
+
+func TestSignPayload(t *testing.T) {
+	// echo -n '{"event":"ping"}' | openssl dgst -sha256 -hmac secret
+	want := "sha256=4f4bb3a54e99c4a20e243485229f9b08c66e09104ba6f79c23ce647242a4ce84"
+	if got := SignPayload("secret", []byte(`{"event":"ping"}`)); got != want {
+		t.Errorf("SignPayload = %q, want %q", got, want)
+	}
+}
+
+func TestWebhookRetriesWithBackoff(t *testing.T) {
+	ctx := context.Background()
+	s, repo, receiver, webhook := newWebhookTest(t, http.StatusInternalServerError, http.StatusOK)
+
+	delivery, err := s.Ping(webhook)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	if n, err := s.ProcessDue(ctx, now); err != nil || n != 1 {
+		t.Fatalf("first ProcessDue = %d, %v, want 1", n, err)
+	}
+	got, _ := repo.GetDelivery(delivery.ID)
+	if got.Status != model.DeliveryPending || got.Attempts != 1 {
+		t.Fatalf("after 500: status %s, attempts %d, want pending, 1", got.Status, got.Attempts)
+	}
+	if got.LastStatusCode == nil || *got.LastStatusCode != http.StatusInternalServerError || got.LastError == "" {
+		t.Errorf("after 500: last status %v, error %q", got.LastStatusCode, got.LastError)
+	}
+	if delay := got.NextAttemptAt.Sub(now); delay < time.Minute || delay > time.Minute+time.Second*5 {
+		t.Errorf("retry scheduled in %v, want about 1m", delay)
+	}
+
+	// до наступления повтора отправка не берётся
+	if n, _ := s.ProcessDue(ctx, now.Add(30*time.Second)); n != 0 {
+		t.Errorf("ProcessDue before backoff took %d deliveries", n)
+	}
+
+	if n, err := s.ProcessDue(ctx, now.Add(2*time.Minute)); err != nil || n != 1 {
+		t.Fatalf("second ProcessDue = %d, %v, want 1", n, err)
+	}
+	got, _ = repo.GetDelivery(delivery.ID)
+	if got.Status != model.DeliveryDelivered || got.DeliveredAt == nil {
+		t.Errorf("after 200: status %s, delivered at %v", got.Status, got.DeliveredAt)
+	}
+
+	if len(receiver.requests) != 2 {
+		t.Fatalf("receiver got %d requests, want 2", len(receiver.requests))
+	}
+	for _, req := range receiver.requests {
+		if want := SignPayload(webhook.Secret, []byte(req.body)); req.signature != want {
+			t.Errorf("signature = %q, want %q", req.signature, want)
+		}
+		if req.event != model.EventPing || req.delivery != strconv.Itoa(delivery.ID) {
+			t.Errorf("event %q, delivery %q", req.event, req.delivery)
+		}
+		if req.body != delivery.Payload {
+			t.Errorf("body = %q, want %q", req.body, delivery.Payload)
+		}
+	}
+
+	withLog, err := s.GetDelivery(webhook.ID, delivery.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(withLog.Log) != 2 {
+		t.Fatalf("log has %d attempts, want 2", len(withLog.Log))
+	}
+	for i, want := range []int{http.StatusInternalServerError, http.StatusOK} {
+		attempt := withLog.Log[i]
+		if attempt.Attempt != i+1 || attempt.StatusCode == nil || *attempt.StatusCode != want {
+			t.Errorf("log[%d] = attempt %d, status %v, want attempt %d, status %d", i, attempt.Attempt, attempt.StatusCode, i+1, want)
+		}
+		if attempt.Response != http.StatusText(want) {
+			t.Errorf("log[%d] response = %q", i, attempt.Response)
+		}
+	}
+	if withLog.Log[0].Error == "" || withLog.Log[1].Error != "" {
+		t.Errorf("log errors = %q, %q", withLog.Log[0].Error, withLog.Log[1].Error)
+	}
+}
+
+func TestWebhookGivesUpAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	s, repo, receiver, webhook := newWebhookTest(t, http.StatusBadGateway)
+
+	delivery, err := s.Ping(webhook)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	var delays []time.Duration
+	for i := 0; i < s.MaxAttempts; i++ {
+		if n, err := s.ProcessDue(ctx, now); err != nil || n != 1 {
+			t.Fatalf("attempt %d: ProcessDue = %d, %v", i+1, n, err)
+		}
+		got, _ := repo.GetDelivery(delivery.ID)
+		if got.Status == model.DeliveryPending {
+			delays = append(delays, time.Until(got.NextAttemptAt).Round(time.Minute))
+			now = got.NextAttemptAt
+		}
+	}
+
+	got, _ := repo.GetDelivery(delivery.ID)
+	if got.Status != model.DeliveryFailed || got.Attempts != s.MaxAttempts {
+		t.Errorf("status %s after %d attempts, want failed after %d", got.Status, got.Attempts, s.MaxAttempts)
+	}
+	if len(delays) != 2 || delays[0] != time.Minute || delays[1] != 2*time.Minute {
+		t.Errorf("backoff delays = %v, want [1m 2m]", delays)
+	}
+	if n, _ := s.ProcessDue(ctx, now.Add(24*time.Hour)); n != 0 {
+		t.Errorf("failed delivery was claimed again")
+	}
+	if len(receiver.requests) != s.MaxAttempts {
+		t.Errorf("receiver got %d requests, want %d", len(receiver.requests), s.MaxAttempts)
+	}
+}
+
+func TestWebhookRedeliver(t *testing.T) {
+	ctx := context.Background()
+	s, repo, receiver, webhook := newWebhookTest(t, http.StatusOK)
+
+	original, err := s.Ping(webhook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ProcessDue(ctx, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Redeliver(webhook.ID+1, original.ID); err == nil {
+		t.Error("Redeliver through another webhook succeeded")
+	}
+	redelivery, err := s.Redeliver(webhook.ID, original.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redelivery.ID == original.ID || redelivery.Payload != original.Payload || redelivery.EventID != 0 ||
+		redelivery.Status != model.DeliveryPending {
+		t.Errorf("redelivery = %+v", redelivery)
+	}
+
+	if n, err := s.ProcessDue(ctx, time.Now()); err != nil || n != 1 {
+		t.Fatalf("ProcessDue = %d, %v, want 1", n, err)
+	}
+	if len(receiver.requests) != 2 || receiver.requests[1].body != receiver.requests[0].body {
+		t.Fatalf("receiver requests = %+v", receiver.requests)
+	}
+	if receiver.requests[1].delivery != strconv.Itoa(redelivery.ID) {
+		t.Errorf("delivery header = %q, want %d", receiver.requests[1].delivery, redelivery.ID)
+	}
+
+	got, _ := repo.GetDelivery(original.ID)
+	if got.Status != model.DeliveryDelivered || got.Attempts != 1 {
+		t.Errorf("original changed: status %s, attempts %d", got.Status, got.Attempts)
+	}
+}
+
+func TestWebhookDisabled(t *testing.T) {
+	ctx := context.Background()
+	s, repo, receiver, webhook := newWebhookTest(t, http.StatusOK)
+
+	delivery, err := s.Ping(webhook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	webhook.Active = false
+	if err := repo.UpdateWebhook(webhook); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ProcessDue(ctx, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := repo.GetDelivery(delivery.ID)
+	if got.Status != model.DeliveryFailed || len(receiver.requests) != 0 {
+		t.Errorf("disabled webhook: status %s, %d requests", got.Status, len(receiver.requests))
+	}
+}
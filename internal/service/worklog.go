@@ -0,0 +1,164 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"pet-project/internal/repository"
+	"pet-project/pkg/model"
+	"time"
+)
+
+const maxWorklogMinutes = 24 * 60
+
+type WorklogService struct {
+	Repository repository.WorklogRepository
+	Tasks      repository.TaskRepository
+}
+
+func (s *WorklogService) LogWork(wl *model.Worklog) error {
+	if err := s.prepareWorklog(wl); err != nil {
+		return err
+	}
+	return s.Repository.CreateWorklog(wl)
+}
+
+// prepareWorklog проверяет новую запись и проставляет даты
+func (s *WorklogService) prepareWorklog(wl *model.Worklog) error {
+	if err := validateWorklog(wl); err != nil {
+		return err
+	}
+	if _, err := s.Tasks.GetByIDTask(wl.TaskID); err != nil {
+		return errors.New("Task not found")
+	}
+
+	wl.CreatedAt = time.Now()
+	wl.UpdatedAt = wl.CreatedAt
+	if wl.WorkDate.IsZero() {
+		wl.WorkDate = truncateDay(wl.CreatedAt)
+	}
+	return nil
+}
+
+func validateWorklog(wl *model.Worklog) error {
+	if wl.UserID <= 0 {
+		return errors.New("invalid user ID")
+	}
+	if wl.Minutes <= 0 {
+		return errors.New("Duration must be positive")
+	}
+	if wl.Minutes > maxWorklogMinutes {
+		return errors.New("Duration can't exceed 24 hours per entry")
+	}
+	return nil
+}
+
+// GetTask загружает задачу для проверки доступа к её проекту
+func (s *WorklogService) GetTask(task_id int) (*model.Task, error) {
+	return s.Tasks.GetByIDTask(task_id)
+}
+
+func (s *WorklogService) GetWorklog(worklog_id int) (*model.Worklog, error) {
+	return s.Repository.GetWorklogByID(worklog_id)
+}
+
+// UpdateWorklog сохраняет изменённую запись; менять её может только автор
+func (s *WorklogService) UpdateWorklog(wl *model.Worklog, user_id int) error {
+	if wl.UserID != user_id {
+		return errors.New("No permission to edit worklog")
+	}
+	if err := validateWorklog(wl); err != nil {
+		return err
+	}
+	wl.UpdatedAt = time.Now()
+	return s.Repository.UpdateWorklog(wl)
+}
+
+func (s *WorklogService) DeleteWorklog(worklog_id, user_id int) error {
+	wl, err := s.Repository.GetWorklogByID(worklog_id)
+	if err != nil {
+		return err
+	}
+	if wl.UserID != user_id {
+		return errors.New("No permission to delete worklog")
+	}
+	return s.Repository.DeleteWorklog(worklog_id)
+}
+
+func (s *WorklogService) ListByTask(task_id int) ([]*model.Worklog, error) {
+	return s.Repository.ListByTask(task_id)
+}
+
+func (s *WorklogService) StartTimer(task_id, user_id int) (*model.Timer, error) {
+	if _, err := s.Tasks.GetByIDTask(task_id); err != nil {
+		return nil, errors.New("Task not found")
+	}
+
+	if running, err := s.Repository.GetTimer(user_id); err == nil {
+		return nil, fmt.Errorf("Timer is already running for task #%d", running.TaskID)
+	}
+
+	timer := &model.Timer{UserID: user_id, TaskID: task_id, StartedAt: time.Now()}
+	if err := s.Repository.StartTimer(timer); err != nil {
+		return nil, err
+	}
+	return timer, nil
+}
+
+// StopTimer останавливает таймер пользователя и записывает прошедшее время (с округлением вверх до минуты)
+func (s *WorklogService) StopTimer(task_id, user_id int, note string) (*model.Worklog, error) {
+	timer, err := s.Repository.GetTimer(user_id)
+	if err != nil {
+		return nil, errors.New("No running timer")
+	}
+	if timer.TaskID != task_id {
+		return nil, fmt.Errorf("Timer is running for task #%d", timer.TaskID)
+	}
+
+	minutes := int(math.Ceil(time.Since(timer.StartedAt).Minutes()))
+	if minutes < 1 {
+		minutes = 1
+	}
+	if minutes > maxWorklogMinutes {
+		minutes = maxWorklogMinutes
+	}
+
+	wl := &model.Worklog{
+		TaskID:   timer.TaskID,
+		UserID:   user_id,
+		Minutes:  minutes,
+		WorkDate: truncateDay(timer.StartedAt),
+		Note:     note,
+	}
+	if err := s.prepareWorklog(wl); err != nil {
+		return nil, err
+	}
+	// Таймер удаляется вместе с записью времени: параллельная остановка не запишет время дважды
+	if err := s.Repository.StopTimer(wl, timer.StartedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("No running timer")
+		}
+		return nil, err
+	}
+	return wl, nil
+}
+
+func (s *WorklogService) GetTimer(user_id int) (*model.Timer, error) {
+	timer, err := s.Repository.GetTimer(user_id)
+	if err != nil {
+		return nil, errors.New("No running timer")
+	}
+	return timer, nil
+}
+
+func (s *WorklogService) Summary(scope model.WorklogScope) (*model.TimeSummary, error) {
+	if scope.From != nil && scope.To != nil && scope.To.Before(*scope.From) {
+		return nil, errors.New("Invalid date range")
+	}
+	return s.Repository.Summary(scope)
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
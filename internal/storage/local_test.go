@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStoragePath(t *testing.T) {
+	s := &LocalStorage{Root: "/data"}
+	tests := []struct {
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{key: "attachments/1/file.txt", want: "/data/attachments/1/file.txt"},
+		{key: "/attachments/1/file.txt", want: "/data/attachments/1/file.txt"},
+		{key: "a//b/./c", want: "/data/a/b/c"},
+		{key: "", wantErr: true},
+		{key: "/", wantErr: true},
+		{key: "../etc/passwd", wantErr: true},
+		{key: "attachments/../../etc/passwd", wantErr: true},
+		{key: "attachments/..", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := s.path(tt.key)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("path(%q) = %q, want error", tt.key, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("path(%q): %v", tt.key, err)
+			continue
+		}
+		if got != filepath.FromSlash(tt.want) {
+			t.Errorf("path(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestLocalStoragePutOpenDelete(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewLocalStorage(filepath.Join(t.TempDir(), "files"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		content string
+	}{
+		{name: "nested key", key: "tasks/1/report.txt", content: "hello, world"},
+		{name: "empty file", key: "tasks/2/empty", content: ""},
+		{name: "overwrite", key: "tasks/1/report.txt", content: "second version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.Put(ctx, tt.key, strings.NewReader(tt.content), int64(len(tt.content)), "text/plain"); err != nil {
+				t.Fatalf("Put: %v", err)
+			}
+			f, err := s.Open(ctx, tt.key)
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			defer f.Close()
+			got, err := io.ReadAll(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.content {
+				t.Errorf("content = %q, want %q", got, tt.content)
+			}
+		})
+	}
+
+	// Временные файлы после Put не остаются
+	entries, err := os.ReadDir(filepath.Join(s.Root, "tasks", "1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("tasks/1 has %d entries, want 1", len(entries))
+	}
+
+	if err := s.Delete(ctx, "tasks/1/report.txt"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Open(ctx, "tasks/1/report.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open after Delete: err = %v, want ErrNotFound", err)
+	}
+	if err := s.Delete(ctx, "tasks/1/report.txt"); err != nil {
+		t.Errorf("second Delete: %v", err)
+	}
+}
+
+func TestLocalStorageRejectsTraversal(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewLocalStorage(filepath.Join(dir, "files"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := "../outside.txt"
+	if err := s.Put(ctx, key, bytes.NewReader([]byte("x")), 1, ""); err == nil {
+		t.Error("Put with traversal key succeeded")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "outside.txt")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("file outside root was created: %v", err)
+	}
+	if _, err := s.Open(ctx, key); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Open with traversal key: err = %v, want invalid key", err)
+	}
+	if err := s.Delete(ctx, key); err == nil {
+		t.Error("Delete with traversal key succeeded")
+	}
+}
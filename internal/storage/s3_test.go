@@ -0,0 +1,241 @@
+package storage
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var authorizationRe = regexp.MustCompile(`^AWS4-HMAC-SHA256 Credential=([^/]+)/(\d{8})/([^/]+)/s3/aws4_request, ` +
+	`SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=([0-9a-f]{64})$`)
+
+// fakeS3 — минимальный S3: хранит объекты в памяти и проверяет подпись SigV4 каждого запроса
+type fakeS3 struct {
+	t         *testing.T
+	accessKey string
+	secretKey string
+	region    string
+	// quiet — неверная подпись ожидаема и не считается ошибкой теста
+	quiet bool
+
+	mu      sync.Mutex
+	objects map[string][]byte
+	ranges  []string
+}
+
+func (f *fakeS3) verify(r *http.Request) error {
+	m := authorizationRe.FindStringSubmatch(r.Header.Get("Authorization"))
+	if m == nil {
+		return fmt.Errorf("malformed Authorization %q", r.Header.Get("Authorization"))
+	}
+	if m[1] != f.accessKey || m[3] != f.region {
+		return fmt.Errorf("unexpected credential %s/%s", m[1], m[3])
+	}
+	amzDate := r.Header.Get("X-Amz-Date")
+	if _, err := time.Parse("20060102T150405Z", amzDate); err != nil || !strings.HasPrefix(amzDate, m[2]) {
+		return fmt.Errorf("X-Amz-Date %q doesn't match scope date %s", amzDate, m[2])
+	}
+	if r.Header.Get("X-Amz-Content-Sha256") != unsignedPayload {
+		return errors.New("missing X-Amz-Content-Sha256")
+	}
+
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		r.URL.EscapedPath(),
+		r.URL.RawQuery,
+		"host:" + r.Host + "\nx-amz-content-sha256:" + unsignedPayload + "\nx-amz-date:" + amzDate + "\n",
+		"host;x-amz-content-sha256;x-amz-date",
+		unsignedPayload,
+	}, "\n")
+	scope := m[2] + "/" + f.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256(canonicalRequest)
+	key := hmacSHA256([]byte("AWS4"+f.secretKey), m[2])
+	key = hmacSHA256(key, f.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	if want := hex.EncodeToString(hmacSHA256(key, stringToSign)); m[4] != want {
+		return fmt.Errorf("signature mismatch for %s %s", r.Method, r.URL.Path)
+	}
+	return nil
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := f.verify(r); err != nil {
+		if !f.quiet {
+			f.t.Error(err)
+		}
+		http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = data
+	case http.MethodHead, http.MethodGet:
+		data, ok := f.objects[r.URL.Path]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		rng := r.Header.Get("Range")
+		if r.Method == http.MethodGet {
+			f.ranges = append(f.ranges, rng)
+		}
+		if rng == "" {
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			w.Write(data)
+			return
+		}
+		start, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rng, "bytes="), "-"))
+		if err != nil || start >= len(data) {
+			http.Error(w, "InvalidRange", http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(data)-1, len(data)))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(data[start:])
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func newTestS3(t *testing.T) (*S3Storage, *fakeS3) {
+	fake := &fakeS3{t: t, accessKey: "AKIDEXAMPLE", secretKey: "secret", region: "eu-central-1", objects: map[string][]byte{}}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	s, err := NewS3Storage(srv.URL+"/", "attachments", fake.region, fake.accessKey, fake.secretKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Client = srv.Client()
+	return s, fake
+}
+
+func TestS3StoragePutOpenDelete(t *testing.T) {
+	ctx := context.Background()
+	s, fake := newTestS3(t)
+
+	tests := []struct {
+		name    string
+		key     string
+		path    string
+		content string
+	}{
+		{name: "plain key", key: "tasks/1/report.txt", path: "/attachments/tasks/1/report.txt", content: "hello, world"},
+		{name: "escaped key", key: "tasks/2/отчёт 1.txt", path: "/attachments/tasks/2/отчёт 1.txt", content: "квартальный отчёт"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.Put(ctx, tt.key, strings.NewReader(tt.content), int64(len(tt.content)), "text/plain"); err != nil {
+				t.Fatalf("Put: %v", err)
+			}
+			if got := string(fake.objects[tt.path]); got != tt.content {
+				t.Errorf("stored %q at %s, want %q", got, tt.path, tt.content)
+			}
+
+			obj, err := s.Open(ctx, tt.key)
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			defer obj.Close()
+			got, err := io.ReadAll(obj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.content {
+				t.Errorf("content = %q, want %q", got, tt.content)
+			}
+
+			if err := s.Delete(ctx, tt.key); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if _, err := s.Open(ctx, tt.key); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Open after Delete: err = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestS3StorageRange(t *testing.T) {
+	ctx := context.Background()
+	s, fake := newTestS3(t)
+	content := "0123456789abcdef"
+	if err := s.Put(ctx, "range.bin", strings.NewReader(content), int64(len(content)), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	obj, err := s.Open(ctx, "range.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer obj.Close()
+
+	tests := []struct {
+		offset    int64
+		whence    int
+		want      string
+		wantRange string
+	}{
+		{offset: 10, whence: io.SeekStart, want: "abcdef", wantRange: "bytes=10-"},
+		{offset: -4, whence: io.SeekEnd, want: "cdef", wantRange: "bytes=12-"},
+		{offset: 0, whence: io.SeekStart, want: content, wantRange: "bytes=0-"},
+	}
+	for _, tt := range tests {
+		if _, err := obj.Seek(tt.offset, tt.whence); err != nil {
+			t.Fatalf("Seek(%d, %d): %v", tt.offset, tt.whence, err)
+		}
+		got, err := io.ReadAll(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("after Seek(%d, %d) read %q, want %q", tt.offset, tt.whence, got, tt.want)
+		}
+		if last := fake.ranges[len(fake.ranges)-1]; last != tt.wantRange {
+			t.Errorf("Range = %q, want %q", last, tt.wantRange)
+		}
+	}
+
+	// Чтение за концом объекта не делает запроса
+	requests := len(fake.ranges)
+	if _, err := obj.Seek(0, io.SeekEnd); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := obj.Read(make([]byte, 8)); n != 0 || err != io.EOF {
+		t.Errorf("Read at end = %d, %v, want 0, EOF", n, err)
+	}
+	if len(fake.ranges) != requests {
+		t.Error("Read at end sent a request")
+	}
+	if _, err := obj.Seek(-1, io.SeekStart); err == nil {
+		t.Error("Seek to negative position succeeded")
+	}
+}
+
+func TestS3StorageSignatureRejected(t *testing.T) {
+	s, fake := newTestS3(t)
+	fake.quiet = true
+	s.SecretKey = "wrong"
+
+	err := s.Put(context.Background(), "key", strings.NewReader("x"), 1, "")
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Put with wrong key: err = %v, want 403", err)
+	}
+}
@@ -0,0 +1,180 @@
+package taskquery
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Env — контекст выполнения: пользователь, которого означает me, и момент, от которого считаются now и today
+type Env struct {
+	UserID int
+	Now    time.Time
+}
+
+type compiler struct {
+	env  Env
+	args []any
+}
+
+// Compile превращает выражение в условие WHERE для таблицы tasks. Значения передаются только
+// через плейсхолдеры, нумерация продолжает args; имена колонок берутся из белого списка полей.
+func Compile(expr Expr, env Env, args []any) (string, []any, error) {
+	c := &compiler{env: env, args: args}
+	sql, err := c.compile(expr)
+	if err != nil {
+		return "", nil, err
+	}
+	return sql, c.args, nil
+}
+
+func (c *compiler) compile(expr Expr) (string, error) {
+	switch e := expr.(type) {
+	case *Logical:
+		left, err := c.compile(e.Left)
+		if err != nil {
+			return "", err
+		}
+		right, err := c.compile(e.Right)
+		if err != nil {
+			return "", err
+		}
+		return "(" + left + " " + e.Op + " " + right + ")", nil
+	case *Not:
+		inner, err := c.compile(e.Expr)
+		if err != nil {
+			return "", err
+		}
+		return "NOT (" + inner + ")", nil
+	case *Condition:
+		return c.condition(e)
+	}
+	return "", fmt.Errorf("Unsupported expression %T", expr)
+}
+
+func (c *compiler) arg(value any) string {
+	c.args = append(c.args, value)
+	return "$" + strconv.Itoa(len(c.args))
+}
+
+func (c *compiler) list(values []any) string {
+	placeholders := make([]string, 0, len(values))
+	for _, value := range values {
+		placeholders = append(placeholders, c.arg(value))
+	}
+	return strings.Join(placeholders, ", ")
+}
+
+func (c *compiler) condition(cond *Condition) (string, error) {
+	f := fields[cond.Field]
+
+	if cond.Op == OpEmpty || cond.Op == OpNotEmpty {
+		empty := f.none
+		if f.member == "" {
+			empty = f.column + " IS NULL"
+			if f.kind == kindText {
+				empty = fmt.Sprintf("COALESCE(%s, '') = ''", f.column)
+			}
+		}
+		if cond.Op == OpNotEmpty {
+			return "NOT (" + empty + ")", nil
+		}
+		return empty, nil
+	}
+
+	if f.kind == kindTime {
+		return c.timeCondition(f, cond)
+	}
+
+	values := make([]any, 0, len(cond.Values))
+	for _, value := range cond.Values {
+		resolved, err := c.resolve(f, value)
+		if err != nil {
+			return "", err
+		}
+		values = append(values, resolved)
+	}
+
+	if f.member != "" {
+		member := fmt.Sprintf(f.member, c.list(values))
+		if cond.Op == "!=" || cond.Op == OpNotIn {
+			return "NOT " + member, nil
+		}
+		return member, nil
+	}
+
+	switch cond.Op {
+	case "=", "<", "<=", ">", ">=":
+		return fmt.Sprintf("%s %s %s", f.column, cond.Op, c.arg(values[0])), nil
+	case "!=":
+		return fmt.Sprintf("%s IS DISTINCT FROM %s", f.column, c.arg(values[0])), nil
+	case OpIn:
+		return fmt.Sprintf("%s IN (%s)", f.column, c.list(values)), nil
+	case OpNotIn:
+		return fmt.Sprintf("(%s IS NULL OR %s NOT IN (%s))", f.column, f.column, c.list(values)), nil
+	case "~":
+		return fmt.Sprintf("%s ILIKE '%%' || %s || '%%'", f.column, c.arg(EscapeLike(values[0].(string)))), nil
+	case "!~":
+		return fmt.Sprintf("COALESCE(%s, '') NOT ILIKE '%%' || %s || '%%'", f.column, c.arg(EscapeLike(values[0].(string)))), nil
+	}
+	return "", fmt.Errorf("Operator %s is not supported for field %s", cond.Op, cond.Field)
+}
+
+// timeCondition сравнивает с датой как с целыми сутками: due = 2024-07-01 — любой момент этого дня,
+// due <= today — до конца сегодняшнего дня
+func (c *compiler) timeCondition(f field, cond *Condition) (string, error) {
+	value := cond.Values[0]
+	t, day, err := resolveTime(value.Text, c.env.Now)
+	if err != nil {
+		return "", fmt.Errorf("Invalid value %q at position %d", value.Text, value.Pos+1)
+	}
+
+	if !day {
+		if cond.Op == "!=" {
+			return fmt.Sprintf("%s IS DISTINCT FROM %s", f.column, c.arg(t)), nil
+		}
+		return fmt.Sprintf("%s %s %s", f.column, cond.Op, c.arg(t)), nil
+	}
+
+	start, end := t, t.AddDate(0, 0, 1)
+	switch cond.Op {
+	case "=":
+		return fmt.Sprintf("(%s >= %s AND %s < %s)", f.column, c.arg(start), f.column, c.arg(end)), nil
+	case "!=":
+		return fmt.Sprintf("(%s IS NULL OR %s < %s OR %s >= %s)", f.column, f.column, c.arg(start), f.column, c.arg(end)), nil
+	case "<":
+		return fmt.Sprintf("%s < %s", f.column, c.arg(start)), nil
+	case "<=":
+		return fmt.Sprintf("%s < %s", f.column, c.arg(end)), nil
+	case ">":
+		return fmt.Sprintf("%s >= %s", f.column, c.arg(end)), nil
+	case ">=":
+		return fmt.Sprintf("%s >= %s", f.column, c.arg(start)), nil
+	}
+	return "", fmt.Errorf("Operator %s is not supported for field %s", cond.Op, cond.Field)
+}
+
+func (c *compiler) resolve(f field, value Value) (any, error) {
+	switch f.kind {
+	case kindInt:
+		return strconv.Atoi(value.Text)
+	case kindUser:
+		if strings.EqualFold(value.Text, "me") {
+			if c.env.UserID == 0 {
+				return nil, errors.New("\"me\" can't be used without a current user")
+			}
+			return c.env.UserID, nil
+		}
+		return strconv.Atoi(value.Text)
+	case kindLabel:
+		return strings.ToLower(strings.TrimSpace(value.Text)), nil
+	}
+	return value.Text, nil
+}
+
+// EscapeLike экранирует спецсимволы ILIKE, чтобы поиск подстроки был буквальным
+func EscapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
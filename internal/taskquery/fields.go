@@ -0,0 +1,159 @@
+package taskquery
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type fieldKind int
+
+const (
+	kindString fieldKind = iota // точное совпадение: статус, приоритет
+	kindText                    // свободный текст с поиском подстроки
+	kindInt
+	kindTime
+	kindUser  // id пользователя или me
+	kindLabel // имя метки без учёта регистра
+)
+
+// field описывает поле языка. Обычные поля сравниваются с колонкой tasks, связи (исполнители,
+// наблюдатели, метки) — через EXISTS: member получает список плейсхолдеров, none — «связей нет».
+type field struct {
+	kind     fieldKind
+	column   string
+	nullable bool
+	member   string
+	none     string
+}
+
+var fields = map[string]field{
+	"id":          {kind: kindInt, column: "tasks.id"},
+	"status":      {kind: kindString, column: "tasks.status"},
+	"priority":    {kind: kindString, column: "tasks.priority"},
+	"title":       {kind: kindText, column: "tasks.title"},
+	"description": {kind: kindText, column: "tasks.description", nullable: true},
+	"parent":      {kind: kindInt, column: "tasks.parent_id", nullable: true},
+	"sprint":      {kind: kindInt, column: "tasks.sprint_id", nullable: true},
+	"estimate":    {kind: kindInt, column: "tasks.original_estimate", nullable: true},
+	"remaining":   {kind: kindInt, column: "tasks.remaining_estimate", nullable: true},
+	"due":         {kind: kindTime, column: "tasks.due_date", nullable: true},
+	"created":     {kind: kindTime, column: "tasks.created_at"},
+	"updated":     {kind: kindTime, column: "tasks.updated_at"},
+	"assignee": {
+		kind: kindUser,
+		member: `(tasks.assigned_to IN (%[1]s) OR EXISTS (SELECT 1 FROM task_assignees ta
+				WHERE ta.task_id = tasks.id AND ta.user_id IN (%[1]s)))`,
+		none: `(tasks.assigned_to IS NULL AND NOT EXISTS (SELECT 1 FROM task_assignees ta WHERE ta.task_id = tasks.id))`,
+	},
+	"watcher": {
+		kind:   kindUser,
+		member: `EXISTS (SELECT 1 FROM task_watchers tw WHERE tw.task_id = tasks.id AND tw.user_id IN (%[1]s))`,
+		none:   `NOT EXISTS (SELECT 1 FROM task_watchers tw WHERE tw.task_id = tasks.id)`,
+	},
+	"label": {
+		kind: kindLabel,
+		member: `EXISTS (SELECT 1 FROM task_labels tl JOIN labels l ON l.id = tl.label_id
+				WHERE tl.task_id = tasks.id AND lower(l.name) IN (%[1]s))`,
+		none: `NOT EXISTS (SELECT 1 FROM task_labels tl WHERE tl.task_id = tasks.id)`,
+	},
+}
+
+func (f field) allows(op string) bool {
+	switch op {
+	case "=", "!=":
+		return true
+	case "<", "<=", ">", ">=":
+		return f.kind == kindInt || f.kind == kindTime
+	case "~", "!~":
+		return f.kind == kindText
+	case OpIn, OpNotIn:
+		return f.kind != kindText && f.kind != kindTime
+	case OpEmpty, OpNotEmpty:
+		return f.nullable || f.member != ""
+	}
+	return false
+}
+
+// check проверяет запись значения; относительные даты проверяются без привязки к текущему времени
+func (f field) check(value Value) error {
+	var err error
+	switch f.kind {
+	case kindInt:
+		_, err = strconv.Atoi(value.Text)
+	case kindUser:
+		if !strings.EqualFold(value.Text, "me") {
+			_, err = strconv.Atoi(value.Text)
+		}
+	case kindTime:
+		_, _, err = resolveTime(value.Text, time.Time{})
+	case kindLabel:
+		if strings.TrimSpace(value.Text) == "" {
+			err = errors.New("empty label")
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("Invalid value %q at position %d", value.Text, value.Pos+1)
+	}
+	return nil
+}
+
+// ParseTime разбирает значение времени в синтаксисе языка: now+7d, today, 2024-07-01, RFC 3339
+func ParseTime(text string, now time.Time) (time.Time, error) {
+	t, _, err := resolveTime(text, now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid time %q", text)
+	}
+	return t, nil
+}
+
+var relativeOffset = regexp.MustCompile(`^([+-])(\d{1,5})([mhdw])$`)
+
+// resolveTime понимает now и today со сдвигом (now-2h, today+7d, единицы m, h, d, w),
+// даты 2024-07-01 и моменты в RFC 3339. day сообщает, что значение — целые сутки.
+func resolveTime(text string, now time.Time) (t time.Time, day bool, err error) {
+	lower := strings.ToLower(text)
+	rest := ""
+	switch {
+	case strings.HasPrefix(lower, "now"):
+		t, rest = now, lower[len("now"):]
+	case strings.HasPrefix(lower, "today"):
+		t, rest = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()), lower[len("today"):]
+		day = true
+	default:
+		location := now.Location()
+		if parsed, err := time.ParseInLocation("2006-01-02", text, location); err == nil {
+			return parsed, true, nil
+		}
+		if parsed, err := time.Parse(time.RFC3339, text); err == nil {
+			return parsed, false, nil
+		}
+		return time.Time{}, false, fmt.Errorf("invalid time %q", text)
+	}
+
+	if rest == "" {
+		return t, day, nil
+	}
+	m := relativeOffset.FindStringSubmatch(rest)
+	if m == nil {
+		return time.Time{}, false, fmt.Errorf("invalid time offset %q", rest)
+	}
+	n, _ := strconv.Atoi(m[2])
+	if m[1] == "-" {
+		n = -n
+	}
+	switch m[3] {
+	case "m":
+		t, day = t.Add(time.Duration(n)*time.Minute), false
+	case "h":
+		t, day = t.Add(time.Duration(n)*time.Hour), false
+	case "d":
+		t = t.AddDate(0, 0, n)
+	case "w":
+		t = t.AddDate(0, 0, 7*n)
+	}
+	return t, day, nil
+}
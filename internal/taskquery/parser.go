@@ -0,0 +1,269 @@
+// Package taskquery разбирает выражения для выборки задач вида
+//
+//	status != done AND priority = high AND due < now+7d AND label = bug
+//
+// и превращает их в параметризованное условие SQL для таблицы tasks.
+package taskquery
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// MaxLength — максимальная длина выражения в символах
+	MaxLength = 2000
+	// maxConditions и maxDepth не дают собрать запрос, который слишком дорого выполнять
+	maxConditions = 50
+	maxDepth      = 20
+	maxListValues = 100
+)
+
+// Expr — узел разобранного выражения: *Logical, *Not или *Condition
+type Expr interface {
+	expr()
+}
+
+// Logical — AND или OR двух выражений
+type Logical struct {
+	Op          string
+	Left, Right Expr
+}
+
+type Not struct {
+	Expr Expr
+}
+
+// Condition — сравнение поля со значениями. Op: = != < <= > >= ~ !~ IN, NOT IN, EMPTY, NOT EMPTY
+type Condition struct {
+	Field  string
+	Op     string
+	Values []Value
+}
+
+// Value — значение как оно записано в выражении; тип определяется полем
+type Value struct {
+	Text string
+	Pos  int
+}
+
+func (*Logical) expr()   {}
+func (*Not) expr()       {}
+func (*Condition) expr() {}
+
+const (
+	OpIn       = "IN"
+	OpNotIn    = "NOT IN"
+	OpEmpty    = "EMPTY"
+	OpNotEmpty = "NOT EMPTY"
+)
+
+type parser struct {
+	tokens     []token
+	pos        int
+	depth      int
+	conditions int
+}
+
+// Parse разбирает выражение и проверяет поля, операторы и значения
+func Parse(src string) (Expr, error) {
+	if len([]rune(src)) > MaxLength {
+		return nil, fmt.Errorf("Query is too long, max %d characters", MaxLength)
+	}
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 1 {
+		return nil, errors.New("Query is empty")
+	}
+
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("Unexpected %q at position %d", tok.text, tok.pos+1)
+	}
+	return expr, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) keyword(word string) bool {
+	tok := p.peek()
+	if tok.kind == tokenWord && tok.upper == word {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &Logical{Op: "OR", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("AND") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &Logical{Op: "AND", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return nil, errors.New("Query is nested too deeply")
+	}
+
+	if p.keyword("NOT") {
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Expr: expr}, nil
+	}
+
+	if p.peek().kind == tokenLParen {
+		p.next()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if tok := p.next(); tok.kind != tokenRParen {
+			return nil, fmt.Errorf("Expected \")\" at position %d", tok.pos+1)
+		}
+		return expr, nil
+	}
+	return p.parseCondition()
+}
+
+func (p *parser) parseCondition() (Expr, error) {
+	tok := p.next()
+	if tok.kind != tokenWord {
+		return nil, fmt.Errorf("Expected field name at position %d", tok.pos+1)
+	}
+	name := strings.ToLower(tok.text)
+	field, ok := fields[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown field %q at position %d", tok.text, tok.pos+1)
+	}
+
+	p.conditions++
+	if p.conditions > maxConditions {
+		return nil, fmt.Errorf("Query has too many conditions, max %d", maxConditions)
+	}
+
+	cond := &Condition{Field: name}
+	opTok := p.peek()
+	switch {
+	case opTok.kind == tokenOp:
+		p.next()
+		cond.Op = opTok.text
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		cond.Values = []Value{value}
+	case p.keyword("IS"):
+		cond.Op = OpEmpty
+		if p.keyword("NOT") {
+			cond.Op = OpNotEmpty
+		}
+		if !p.keyword("EMPTY") && !p.keyword("NULL") {
+			return nil, fmt.Errorf("Expected EMPTY at position %d", p.peek().pos+1)
+		}
+	case p.keyword("IN"):
+		cond.Op = OpIn
+	case p.keyword("NOT"):
+		if !p.keyword("IN") {
+			return nil, fmt.Errorf("Expected IN at position %d", p.peek().pos+1)
+		}
+		cond.Op = OpNotIn
+	default:
+		return nil, fmt.Errorf("Expected operator after %q at position %d", tok.text, opTok.pos+1)
+	}
+
+	if cond.Op == OpIn || cond.Op == OpNotIn {
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		cond.Values = values
+	}
+
+	if !field.allows(cond.Op) {
+		return nil, fmt.Errorf("Operator %s is not supported for field %s", cond.Op, name)
+	}
+	for _, value := range cond.Values {
+		if err := field.check(value); err != nil {
+			return nil, err
+		}
+	}
+	return cond, nil
+}
+
+func (p *parser) parseValue() (Value, error) {
+	tok := p.next()
+	if tok.kind != tokenWord && tok.kind != tokenString {
+		return Value{}, fmt.Errorf("Expected value at position %d", tok.pos+1)
+	}
+	return Value{Text: tok.text, Pos: tok.pos}, nil
+}
+
+func (p *parser) parseList() ([]Value, error) {
+	if tok := p.next(); tok.kind != tokenLParen {
+		return nil, fmt.Errorf("Expected \"(\" at position %d", tok.pos+1)
+	}
+	values := []Value{}
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		if len(values) > maxListValues {
+			return nil, fmt.Errorf("IN list is too long, max %d values", maxListValues)
+		}
+
+		tok := p.next()
+		if tok.kind == tokenRParen {
+			return values, nil
+		}
+		if tok.kind != tokenComma {
+			return nil, fmt.Errorf("Expected \",\" or \")\" at position %d", tok.pos+1)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package taskquery
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2024, 7, 10, 15, 30, 0, 0, time.UTC)
+
+func day(month time.Month, d int) time.Time {
+	return time.Date(2024, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		query string
+		sql   string
+		args  []any
+	}{
+		{
+			query: `status != done AND priority = high`,
+			sql:   `(tasks.status IS DISTINCT FROM $2 AND tasks.priority = $3)`,
+			args:  []any{"done", "high"},
+		},
+		{
+			// AND связывает сильнее OR
+			query: `status = todo OR status = done and priority = low`,
+			sql:   `(tasks.status = $2 OR (tasks.status = $3 AND tasks.priority = $4))`,
+			args:  []any{"todo", "done", "low"},
+		},
+		{
+			query: `(status = todo OR status = done) AND NOT priority = low`,
+			sql:   `((tasks.status = $2 OR tasks.status = $3) AND NOT (tasks.priority = $4))`,
+			args:  []any{"todo", "done", "low"},
+		},
+		{
+			query: `assignee = me`,
+			sql: `(tasks.assigned_to IN ($2) OR EXISTS (SELECT 1 FROM task_assignees ta
+				WHERE ta.task_id = tasks.id AND ta.user_id IN ($2)))`,
+			args: []any{7},
+		},
+		{
+			query: `assignee NOT IN (me, 3)`,
+			sql: `NOT (tasks.assigned_to IN ($2, $3) OR EXISTS (SELECT 1 FROM task_assignees ta
+				WHERE ta.task_id = tasks.id AND ta.user_id IN ($2, $3)))`,
+			args: []any{7, 3},
+		},
+		{
+			query: `label IN (Bug, " UI ")`,
+			sql: `EXISTS (SELECT 1 FROM task_labels tl JOIN labels l ON l.id = tl.label_id
+				WHERE tl.task_id = tasks.id AND lower(l.name) IN ($2, $3))`,
+			args: []any{"bug", "ui"},
+		},
+		{
+			query: `label IS EMPTY`,
+			sql:   `NOT EXISTS (SELECT 1 FROM task_labels tl WHERE tl.task_id = tasks.id)`,
+		},
+		{
+			query: `due IS NOT EMPTY`,
+			sql:   `NOT (tasks.due_date IS NULL)`,
+		},
+		{
+			query: `description is null`,
+			sql:   `COALESCE(tasks.description, '') = ''`,
+		},
+		{
+			query: `title ~ "50%_off"`,
+			sql:   `tasks.title ILIKE '%' || $2 || '%'`,
+			args:  []any{`50\%\_off`},
+		},
+		{
+			query: `title !~ 'it\'s'`,
+			sql:   `COALESCE(tasks.title, '') NOT ILIKE '%' || $2 || '%'`,
+			args:  []any{"it's"},
+		},
+		{
+			query: `due = 2024-07-01`,
+			sql:   `(tasks.due_date >= $2 AND tasks.due_date < $3)`,
+			args:  []any{day(7, 1), day(7, 2)},
+		},
+		{
+			query: `due != 2024-07-01`,
+			sql:   `(tasks.due_date IS NULL OR tasks.due_date < $2 OR tasks.due_date >= $3)`,
+			args:  []any{day(7, 1), day(7, 2)},
+		},
+		{
+			// today — целые сутки, поэтому <= включает весь сегодняшний день
+			query: `due <= today`,
+			sql:   `tasks.due_date < $2`,
+			args:  []any{day(7, 11)},
+		},
+		{
+			query: `due > today+1w`,
+			sql:   `tasks.due_date >= $2`,
+			args:  []any{day(7, 18)},
+		},
+		{
+			query: `due < now+7d`,
+			sql:   `tasks.due_date < $2`,
+			args:  []any{time.Date(2024, 7, 17, 15, 30, 0, 0, time.UTC)},
+		},
+		{
+			query: `updated != now-2h`,
+			sql:   `tasks.updated_at IS DISTINCT FROM $2`,
+			args:  []any{time.Date(2024, 7, 10, 13, 30, 0, 0, time.UTC)},
+		},
+		{
+			query: `id IN (1, 2)`,
+			sql:   `tasks.id IN ($2, $3)`,
+			args:  []any{1, 2},
+		},
+		{
+			query: `parent NOT IN (5)`,
+			sql:   `(tasks.parent_id IS NULL OR tasks.parent_id NOT IN ($2))`,
+			args:  []any{5},
+		},
+	}
+	env := Env{UserID: 7, Now: testNow}
+	for _, tt := range tests {
+		expr, err := Parse(tt.query)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.query, err)
+			continue
+		}
+		// нумерация плейсхолдеров продолжает уже переданные аргументы
+		sql, args, err := Compile(expr, env, []any{"project"})
+		if err != nil {
+			t.Errorf("Compile(%q): %v", tt.query, err)
+			continue
+		}
+		if strings.Join(strings.Fields(sql), " ") != strings.Join(strings.Fields(tt.sql), " ") {
+			t.Errorf("Compile(%q) sql:\n got %s\nwant %s", tt.query, sql, tt.sql)
+		}
+		want := append([]any{"project"}, tt.args...)
+		if !reflect.DeepEqual(args, want) {
+			t.Errorf("Compile(%q) args = %#v, want %#v", tt.query, args, want)
+		}
+	}
+}
+
+func TestCompileMeWithoutUser(t *testing.T) {
+	expr, err := Parse(`watcher = me`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Compile(expr, Env{Now: testNow}, nil); err == nil {
+		t.Error("Compile with me and no user succeeded")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		err   string
+	}{
+		{query: ``, err: "Query is empty"},
+		{query: `   `, err: "Query is empty"},
+		{query: `owner = 1`, err: `Unknown field "owner" at position 1`},
+		{query: `status`, err: `Expected operator after "status" at position 7`},
+		{query: `status = `, err: "Expected value at position 10"},
+		{query: `status = done AND`, err: "Expected field name at position 18"},
+		{query: `status = done priority = high`, err: `Unexpected "priority" at position 15`},
+		{query: `(status = done`, err: `Expected ")" at position 15`},
+		{query: `status = "done`, err: "Unterminated string at position 10"},
+		{query: `status < done`, err: "Operator < is not supported for field status"},
+		{query: `title IN (a)`, err: "Operator IN is not supported for field title"},
+		{query: `status ~ do`, err: "Operator ~ is not supported for field status"},
+		{query: `status IS EMPTY`, err: "Operator EMPTY is not supported for field status"},
+		{query: `status IS done`, err: "Expected EMPTY at position 11"},
+		{query: `status NOT done`, err: "Expected IN at position 12"},
+		{query: `status IN done`, err: `Expected "(" at position 11`},
+		{query: `status IN (a b)`, err: `Expected "," or ")" at position 14`},
+		{query: `id = abc`, err: `Invalid value "abc" at position 6`},
+		{query: `assignee = bob`, err: `Invalid value "bob" at position 12`},
+		{query: `due < tomorrow`, err: `Invalid value "tomorrow" at position 7`},
+		{query: `due < now+7y`, err: `Invalid value "now+7y" at position 7`},
+		{query: `label = " "`, err: `Invalid value " " at position 9`},
+		{query: strings.Repeat("x", MaxLength+1), err: "Query is too long, max 2000 characters"},
+		{query: strings.Repeat("NOT ", maxDepth) + "id = 1", err: "Query is nested too deeply"},
+		{query: strings.Repeat("id = 1 AND ", maxConditions) + "id = 1", err: "Query has too many conditions, max 50"},
+		{query: "id IN (" + strings.Repeat("1, ", maxListValues) + "1)", err: "IN list is too long, max 100 values"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.query)
+		if err == nil {
+			t.Errorf("Parse(%q) succeeded, want %q", tt.query, tt.err)
+			continue
+		}
+		if err.Error() != tt.err {
+			t.Errorf("Parse(%q) error = %q, want %q", tt.query, err, tt.err)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    time.Time
+		wantErr bool
+	}{
+		{text: "now", want: testNow},
+		{text: "NOW-30m", want: testNow.Add(-30 * time.Minute)},
+		{text: "now+7d", want: testNow.AddDate(0, 0, 7)},
+		{text: "today", want: day(7, 10)},
+		{text: "today-2w", want: day(6, 26)},
+		{text: "today+3h", want: day(7, 10).Add(3 * time.Hour)},
+		{text: "2024-02-29", want: day(2, 29)},
+		{text: "2024-07-01T10:00:00+03:00", want: time.Date(2024, 7, 1, 7, 0, 0, 0, time.UTC)},
+		{text: "yesterday", wantErr: true},
+		{text: "now+", wantErr: true},
+		{text: "now+123456d", wantErr: true},
+		{text: "2024-13-01", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTime(tt.text, testNow)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseTime(%q) = %v, want error", tt.text, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseTime(%q): %v", tt.text, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseTime(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package vcs
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+	ProviderGitea  = "gitea"
+)
+
+var Providers = []string{ProviderGitHub, ProviderGitLab, ProviderGitea}
+
+const (
+	KindPush        = "push"
+	KindPullRequest = "pull_request"
+)
+
+// Действия с pull request (в GitLab — merge request), приведённые к общему виду
+const (
+	ActionOpened  = "opened"
+	ActionUpdated = "updated"
+	ActionClosed  = "closed"
+	ActionMerged  = "merged"
+)
+
+// ErrUnsupportedEvent — событие разобрано, но интеграции не нужно: ping, теги, комментарии и т.п.
+var ErrUnsupportedEvent = errors.New("vcs: unsupported event")
+
+// Event — push или pull request в общем для всех провайдеров виде
+type Event struct {
+	Kind       string
+	Repository string
+	// Branch — ветка push или исходная ветка pull request, без refs/heads/
+	Branch        string
+	DefaultBranch string
+	Commits       []Commit
+	PullRequest   *PullRequest
+}
+
+type Commit struct {
+	ID          string
+	Message     string
+	URL         string
+	AuthorName  string
+	AuthorEmail string
+}
+
+type PullRequest struct {
+	Number int
+	Title  string
+	Body   string
+	URL    string
+	Author string
+	Action string
+}
+
+// Verify проверяет подпись запроса: у GitHub и Gitea — HMAC-SHA256 тела, у GitLab — секретный токен
+func Verify(provider, secret string, header http.Header, body []byte) bool {
+	if secret == "" {
+		return false
+	}
+	switch provider {
+	case ProviderGitLab:
+		token := header.Get("X-Gitlab-Token")
+		return subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+	case ProviderGitea:
+		if signature := header.Get("X-Gitea-Signature"); signature != "" {
+			return hmac.Equal([]byte(strings.ToLower(signature)), []byte(sign(secret, body)))
+		}
+		fallthrough
+	case ProviderGitHub:
+		signature := strings.TrimPrefix(header.Get("X-Hub-Signature-256"), "sha256=")
+		return hmac.Equal([]byte(strings.ToLower(signature)), []byte(sign(secret, body)))
+	}
+	return false
+}
+
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Parse разбирает тело запроса провайдера. Событие берётся из заголовка X-GitHub-Event,
+// X-Gitea-Event или X-Gitlab-Event; неинтересные события возвращают ErrUnsupportedEvent.
+func Parse(provider string, header http.Header, body []byte) (*Event, error) {
+	var name string
+	switch provider {
+	case ProviderGitHub:
+		name = header.Get("X-GitHub-Event")
+	case ProviderGitea:
+		name = header.Get("X-Gitea-Event")
+	case ProviderGitLab:
+		name = header.Get("X-Gitlab-Event")
+	default:
+		return nil, fmt.Errorf("vcs: unknown provider %q", provider)
+	}
+
+	switch {
+	case provider == ProviderGitLab && name == "Push Hook":
+		return parseGitLabPush(body)
+	case provider == ProviderGitLab && name == "Merge Request Hook":
+		return parseGitLabMergeRequest(body)
+	case provider != ProviderGitLab && name == "push":
+		return parseHubPush(body)
+	case provider != ProviderGitLab && name == "pull_request":
+		return parseHubPullRequest(body)
+	}
+	return nil, ErrUnsupportedEvent
+}
+
+type payloadCommit struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+	URL     string `json:"url"`
+	Author  struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	} `json:"author"`
+}
+
+func (c payloadCommit) commit() Commit {
+	return Commit{ID: c.ID, Message: c.Message, URL: c.URL, AuthorName: c.Author.Name, AuthorEmail: c.Author.Email}
+}
+
+func branchFromRef(ref string) (string, bool) {
+	branch := strings.TrimPrefix(ref, "refs/heads/")
+	return branch, branch != ref
+}
+
+// parseHubPush разбирает push GitHub и Gitea: у них одинаковый формат
+func parseHubPush(body []byte) (*Event, error) {
+	var payload struct {
+		Ref        string          `json:"ref"`
+		Commits    []payloadCommit `json:"commits"`
+		Repository struct {
+			FullName      string `json:"full_name"`
+			DefaultBranch string `json:"default_branch"`
+		} `json:"repository"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("vcs: invalid push payload: %w", err)
+	}
+	branch, ok := branchFromRef(payload.Ref)
+	if !ok {
+		return nil, ErrUnsupportedEvent
+	}
+	event := &Event{
+		Kind:          KindPush,
+		Repository:    payload.Repository.FullName,
+		Branch:        branch,
+		DefaultBranch: payload.Repository.DefaultBranch,
+	}
+	for _, c := range payload.Commits {
+		event.Commits = append(event.Commits, c.commit())
+	}
+	return event, nil
+}
+
+func parseHubPullRequest(body []byte) (*Event, error) {
+	var payload struct {
+		Action      string `json:"action"`
+		PullRequest struct {
+			Number  int    `json:"number"`
+			Title   string `json:"title"`
+			Body    string `json:"body"`
+			HTMLURL string `json:"html_url"`
+			Merged  bool   `json:"merged"`
+			Head    struct {
+				Ref string `json:"ref"`
+			} `json:"head"`
+			User struct {
+				Login string `json:"login"`
+			} `json:"user"`
+		} `json:"pull_request"`
+		Repository struct {
+			FullName      string `json:"full_name"`
+			DefaultBranch string `json:"default_branch"`
+		} `json:"repository"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("vcs: invalid pull request payload: %w", err)
+	}
+
+	var action string
+	switch payload.Action {
+	case "opened", "reopened":
+		action = ActionOpened
+	case "edited", "synchronize", "synchronized":
+		action = ActionUpdated
+	case "closed":
+		action = ActionClosed
+		if payload.PullRequest.Merged {
+			action = ActionMerged
+		}
+	default:
+		return nil, ErrUnsupportedEvent
+	}
+
+	pr := payload.PullRequest
+	return &Event{
+		Kind:          KindPullRequest,
+		Repository:    payload.Repository.FullName,
+		Branch:        pr.Head.Ref,
+		DefaultBranch: payload.Repository.DefaultBranch,
+		PullRequest: &PullRequest{
+			Number: pr.Number,
+			Title:  pr.Title,
+			Body:   pr.Body,
+			URL:    pr.HTMLURL,
+			Author: pr.User.Login,
+			Action: action,
+		},
+	}, nil
+}
+
+func parseGitLabPush(body []byte) (*Event, error) {
+	var payload struct {
+		Ref     string          `json:"ref"`
+		Commits []payloadCommit `json:"commits"`
+		Project struct {
+			PathWithNamespace string `json:"path_with_namespace"`
+			DefaultBranch     string `json:"default_branch"`
+		} `json:"project"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("vcs: invalid push payload: %w", err)
+	}
+	branch, ok := branchFromRef(payload.Ref)
+	if !ok {
+		return nil, ErrUnsupportedEvent
+	}
+	event := &Event{
+		Kind:          KindPush,
+		Repository:    payload.Project.PathWithNamespace,
+		Branch:        branch,
+		DefaultBranch: payload.Project.DefaultBranch,
+	}
+	for _, c := range payload.Commits {
+		event.Commits = append(event.Commits, c.commit())
+	}
+	return event, nil
+}
+
+func parseGitLabMergeRequest(body []byte) (*Event, error) {
+	var payload struct {
+		User struct {
+			Username string `json:"username"`
+		} `json:"user"`
+		Project struct {
+			PathWithNamespace string `json:"path_with_namespace"`
+			DefaultBranch     string `json:"default_branch"`
+		} `json:"project"`
+		Attributes struct {
+			IID          int    `json:"iid"`
+			Title        string `json:"title"`
+			Description  string `json:"description"`
+			URL          string `json:"url"`
+			Action       string `json:"action"`
+			SourceBranch string `json:"source_branch"`
+		} `json:"object_attributes"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("vcs: invalid merge request payload: %w", err)
+	}
+
+	var action string
+	switch payload.Attributes.Action {
+	case "open", "reopen":
+		action = ActionOpened
+	case "update":
+		action = ActionUpdated
+	case "close":
+		action = ActionClosed
+	case "merge":
+		action = ActionMerged
+	default:
+		return nil, ErrUnsupportedEvent
+	}
+
+	mr := payload.Attributes
+	return &Event{
+		Kind:          KindPullRequest,
+		Repository:    payload.Project.PathWithNamespace,
+		Branch:        mr.SourceBranch,
+		DefaultBranch: payload.Project.DefaultBranch,
+		PullRequest: &PullRequest{
+			Number: mr.IID,
+			Title:  mr.Title,
+			Body:   mr.Description,
+			URL:    mr.URL,
+			Author: payload.User.Username,
+			Action: action,
+		},
+	}, nil
+}
@@ -0,0 +1,64 @@
+package vcs
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Ref — упоминание задачи в коммите или pull request. Closes — перед номером стоит закрывающее
+// слово: «fixes #12», «closes #3, #4», «resolves #7 and #8».
+type Ref struct {
+	TaskID int
+	Closes bool
+}
+
+var (
+	closingRe = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?)\b:?\s+(#\d+(?:(?:\s*,\s*|\s+and\s+|\s+)#\d+)*)`)
+	taskRefRe = regexp.MustCompile(`(?:^|[^\w&#])#(\d+)\b`)
+	// номер задачи в начале последней части имени ветки: 123-login, feature/task-123, fix/123_typo
+	branchRe = regexp.MustCompile(`(?i)(?:^|/)(?:task-?)?(\d+)(?:[-_].*)?$`)
+)
+
+// References находит упоминания задач в тексте без повторов; закрывающее упоминание главнее обычного
+func References(text string) []Ref {
+	closing := map[int]bool{}
+	for _, m := range closingRe.FindAllStringSubmatch(text, -1) {
+		for _, id := range taskIDs(m[1]) {
+			closing[id] = true
+		}
+	}
+
+	refs := []Ref{}
+	seen := map[int]bool{}
+	for _, id := range taskIDs(text) {
+		if !seen[id] {
+			seen[id] = true
+			refs = append(refs, Ref{TaskID: id, Closes: closing[id]})
+		}
+	}
+	return refs
+}
+
+func taskIDs(text string) []int {
+	ids := []int{}
+	for _, m := range taskRefRe.FindAllStringSubmatch(text, -1) {
+		if id, err := strconv.Atoi(m[1]); err == nil && id > 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// BranchTask возвращает задачу из имени ветки или 0, если номера в имени нет
+func BranchTask(branch string) int {
+	m := branchRe.FindStringSubmatch(strings.TrimPrefix(branch, "refs/heads/"))
+	if m == nil {
+		return 0
+	}
+	id, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0
+	}
+	return id
+}
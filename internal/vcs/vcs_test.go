@@ -0,0 +1,110 @@
+package vcs
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReferences(t *testing.T) {
+	tests := []struct {
+		text string
+		want []Ref
+	}{
+		{text: "Refactor parser", want: []Ref{}},
+		{text: "Update docs for #12", want: []Ref{{TaskID: 12}}},
+		{text: "Fixes #12", want: []Ref{{TaskID: 12, Closes: true}}},
+		{text: "fix: closes #3, #4", want: []Ref{{TaskID: 3, Closes: true}, {TaskID: 4, Closes: true}}},
+		{text: "Resolves #7 and #8", want: []Ref{{TaskID: 7, Closes: true}, {TaskID: 8, Closes: true}}},
+		{text: "Closed: #5", want: []Ref{{TaskID: 5, Closes: true}}},
+		{text: "See #1, fixes #2", want: []Ref{{TaskID: 1}, {TaskID: 2, Closes: true}}},
+		// упоминание и закрытие одной задачи — закрытие главнее, повторов нет
+		{text: "Touch #9 again\n\nFixed #9", want: []Ref{{TaskID: 9, Closes: true}}},
+		{text: "(#4) and [#5]", want: []Ref{{TaskID: 4}, {TaskID: 5}}},
+		{text: "color: #fff; entity &#38; issue##3 word#4", want: []Ref{}},
+		{text: "Bump version to 1.2 #0", want: []Ref{}},
+		{text: "prefix fixes the #6 bug", want: []Ref{{TaskID: 6}}},
+	}
+	for _, tt := range tests {
+		if got := References(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("References(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestBranchTask(t *testing.T) {
+	tests := []struct {
+		branch string
+		want   int
+	}{
+		{branch: "123-login", want: 123},
+		{branch: "refs/heads/45-fix-typo", want: 45},
+		{branch: "feature/task-77", want: 77},
+		{branch: "feature/TASK-78-search", want: 78},
+		{branch: "fix/123_typo", want: 123},
+		{branch: "12", want: 12},
+		{branch: "main", want: 0},
+		{branch: "release/v1.2", want: 0},
+		{branch: "feature/login-123", want: 0},
+		{branch: "123/feature", want: 0},
+	}
+	for _, tt := range tests {
+		if got := BranchTask(tt.branch); got != tt.want {
+			t.Errorf("BranchTask(%q) = %d, want %d", tt.branch, got, tt.want)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	const secret = "webhook-secret"
+	body := []byte(`{"ref":"refs/heads/main"}`)
+	signature := sign(secret, body)
+
+	tests := []struct {
+		name     string
+		provider string
+		secret   string
+		header   http.Header
+		want     bool
+	}{
+		{name: "github", provider: ProviderGitHub, secret: secret,
+			header: http.Header{"X-Hub-Signature-256": {"sha256=" + signature}}, want: true},
+		{name: "github upper case hex", provider: ProviderGitHub, secret: secret,
+			header: http.Header{"X-Hub-Signature-256": {"sha256=" + strings.ToUpper(signature)}}, want: true},
+		{name: "github wrong secret", provider: ProviderGitHub, secret: "other",
+			header: http.Header{"X-Hub-Signature-256": {"sha256=" + signature}}},
+		{name: "github missing header", provider: ProviderGitHub, secret: secret, header: http.Header{}},
+		{name: "github legacy sha1 header", provider: ProviderGitHub, secret: secret,
+			header: http.Header{"X-Hub-Signature": {"sha1=" + signature}}},
+		{name: "gitea", provider: ProviderGitea, secret: secret,
+			header: http.Header{"X-Gitea-Signature": {signature}}, want: true},
+		{name: "gitea github-style header", provider: ProviderGitea, secret: secret,
+			header: http.Header{"X-Hub-Signature-256": {"sha256=" + signature}}, want: true},
+		{name: "gitea wrong signature", provider: ProviderGitea, secret: secret,
+			header: http.Header{"X-Gitea-Signature": {sign("other", body)}}},
+		{name: "gitlab", provider: ProviderGitLab, secret: secret,
+			header: http.Header{"X-Gitlab-Token": {secret}}, want: true},
+		{name: "gitlab wrong token", provider: ProviderGitLab, secret: secret,
+			header: http.Header{"X-Gitlab-Token": {secret + "x"}}},
+		{name: "gitlab signature is not a token", provider: ProviderGitLab, secret: secret,
+			header: http.Header{"X-Hub-Signature-256": {"sha256=" + signature}}},
+		{name: "empty secret", provider: ProviderGitLab, secret: "",
+			header: http.Header{"X-Gitlab-Token": {""}}},
+		{name: "unknown provider", provider: "bitbucket", secret: secret,
+			header: http.Header{"X-Hub-Signature-256": {"sha256=" + signature}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verify(tt.provider, tt.secret, tt.header, body); got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// подпись относится к телу целиком
+	header := http.Header{"X-Hub-Signature-256": {"sha256=" + signature}}
+	if Verify(ProviderGitHub, secret, header, append(body, ' ')) {
+		t.Error("Verify accepted a modified body")
+	}
+}
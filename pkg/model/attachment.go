@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+type Attachment struct {
+	ID          int
+	TaskID      int
+	CommentID   *int
+	UserID      int
+	FileName    string
+	ContentType string
+	Size        int64
+	StorageKey  string `json:"-"`
+	CreatedAt   time.Time
+}
@@ -0,0 +1,73 @@
+package model
+
+import "time"
+
+// Действия правил
+const (
+	ActionSetField   = "set_field"
+	ActionAssign     = "assign"
+	ActionAddComment = "add_comment"
+	ActionNotify     = "notify"
+	ActionWebhook    = "webhook"
+)
+
+// Итог выполнения правила в журнале
+const (
+	RunSuccess = "success"
+	RunFailed  = "failed"
+	RunSkipped = "skipped"
+)
+
+// AutomationRule — правило проекта: при событии Trigger, если задача подходит под Condition
+// (выражение языка запросов задач), по порядку выполняются Actions. Действия выполняются
+// от имени автора правила CreatedBy.
+type AutomationRule struct {
+	ID        int
+	ProjectID int
+	Name      string
+	Enabled   bool
+	Trigger   AutomationTrigger
+	Condition string
+	Actions   []AutomationAction
+	CreatedBy int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// AutomationTrigger — событие и, для task_updated, изменившееся поле. From и To ограничивают
+// старое и новое значение поля, например status → done.
+type AutomationTrigger struct {
+	Event string
+	Field string
+	From  *string
+	To    *string
+}
+
+// AutomationAction — одно действие правила. Используемые параметры зависят от Type:
+// set_field — Field и Value, assign — UserID, add_comment — Text, notify — To (owner, assignees,
+// watchers, actor или user с UserID) и Text, webhook — URL. В Text и Value доступны подстановки
+// {task.id}, {task.title}, {task.status}, {task.priority}.
+type AutomationAction struct {
+	Type   string
+	Field  string
+	Value  string
+	UserID int
+	Text   string
+	To     string
+	URL    string
+}
+
+// AutomationRun — запись журнала выполнения правила
+type AutomationRun struct {
+	ID        int
+	RuleID    int
+	ProjectID int
+	TaskID    int
+	Event     string
+	Status    string
+	Message   string
+	Depth     int
+	// EventID — сообщение outbox, на которое сработало правило
+	EventID   int
+	CreatedAt time.Time
+}
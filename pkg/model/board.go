@@ -0,0 +1,12 @@
+package model
+
+// Board — канбан-доска проекта: колонки по статусам, карточки упорядочены по Rank
+type Board struct {
+	ProjectID int
+	Columns   []*BoardColumn
+}
+
+type BoardColumn struct {
+	Status string
+	Tasks  []*Task
+}
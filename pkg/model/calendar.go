@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// CalendarFeed — подписка на календарь сроков задач. Без ProjectID в ленте задачи, назначенные
+// пользователю, во всех проектах; с ProjectID — все задачи проекта. В БД хранится только хеш токена,
+// сам токен и адрес ленты возвращаются один раз, при создании.
+type CalendarFeed struct {
+	ID        int
+	UserID    int
+	ProjectID *int
+	TokenHash string `json:"-"`
+	Token     string `json:",omitempty"`
+	URL       string `json:",omitempty"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// ChatIntegration — подключение канала Slack или Mattermost к проекту. Secret проверяет slash-команды
+// (Signing Secret в Slack, токен команды в Mattermost); в WebhookURL, если он задан, приходят события Events.
+type ChatIntegration struct {
+	ID         int
+	ProjectID  int
+	Provider   string
+	Secret     string `json:"-"`
+	WebhookURL string
+	Events     []string
+	Active     bool
+	CreatedBy  int
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// ChatAccount — аккаунт мессенджера, привязанный к пользователю: от его имени выполняются команды
+type ChatAccount struct {
+	IntegrationID int
+	ChatUserID    string
+	ChatUserName  string
+	UserID        int
+	LinkedAt      time.Time
+}
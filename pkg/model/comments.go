@@ -7,6 +7,21 @@ type Comments struct {
 	TaskID    int
 	UserID    int
 	Text      string
+	Edited    bool
+	Deleted   bool
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	DeletedAt *time.Time
+
+	// Вычисляются из Text при выдаче, в БД не хранятся
+	HTML       string
+	References References
+}
+
+type CommentRevision struct {
+	ID        int
+	CommentID int
+	Text      string
+	EditedBy  int
+	EditedAt  time.Time
 }
@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+const (
+	CustomFieldText        = "text"
+	CustomFieldNumber      = "number"
+	CustomFieldDate        = "date"
+	CustomFieldSelect      = "select"
+	CustomFieldMultiSelect = "multiselect"
+	CustomFieldUser        = "user"
+)
+
+type CustomField struct {
+	ID        int
+	ProjectID int
+	Name      string
+	Type      string
+	Options   []string
+	Required  bool
+	CreatedAt time.Time
+}
+
+// CustomFieldValue — значение поля задачи; заполнено только поле, соответствующее типу
+type CustomFieldValue struct {
+	TaskID  int
+	FieldID int
+	Name    string
+	Type    string
+	Text    *string
+	Number  *float64
+	Date    *time.Time
+	UserID  *int
+	Options []string
+}
+
+// Value возвращает значение в виде, пригодном для JSON-ответа
+func (v *CustomFieldValue) Value() interface{} {
+	switch v.Type {
+	case CustomFieldText:
+		if v.Text != nil {
+			return *v.Text
+		}
+	case CustomFieldNumber:
+		if v.Number != nil {
+			return *v.Number
+		}
+	case CustomFieldDate:
+		if v.Date != nil {
+			return v.Date.Format("2006-01-02")
+		}
+	case CustomFieldUser:
+		if v.UserID != nil {
+			return *v.UserID
+		}
+	case CustomFieldSelect:
+		if len(v.Options) > 0 {
+			return v.Options[0]
+		}
+	case CustomFieldMultiSelect:
+		return v.Options
+	}
+	return nil
+}
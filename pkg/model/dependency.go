@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// TaskDependency — связь «BlockerID блокирует BlockedID»
+type TaskDependency struct {
+	BlockerID int
+	BlockedID int
+	CreatedAt time.Time
+}
+
+type DependencyNode struct {
+	ID       int
+	Title    string
+	Status   string
+	Priority string
+}
+
+type DependencyGraph struct {
+	Nodes []*DependencyNode
+	Edges []*TaskDependency
+}
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// Режимы email-уведомлений: не отправлять, каждое сразу или сводкой непрочитанных раз в час/день
+const (
+	EmailOff     = "off"
+	EmailInstant = "instant"
+	EmailHourly  = "hourly"
+	EmailDaily   = "daily"
+)
+
+// NotificationPreference — настройки уведомлений пользователя. Пустой EmailMode — режим по умолчанию.
+type NotificationPreference struct {
+	UserID       int        `json:"user_id"`
+	EmailMode    string     `json:"email_mode"`
+	LastDigestAt *time.Time `json:"last_digest_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// EmailRecipient — адрес пользователя вместе с действующим режимом рассылки
+type EmailRecipient struct {
+	UserID       int
+	Name         string
+	Email        string
+	Mode         string
+	LastDigestAt *time.Time
+}
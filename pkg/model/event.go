@@ -0,0 +1,28 @@
+package model
+
+// События проекта, на которые реагируют правила автоматизации и webhooks
+const (
+	EventTaskCreated    = "task_created"
+	EventTaskUpdated    = "task_updated"
+	EventCommentCreated = "comment_created"
+)
+
+// ProjectEvent — изменение задачи или новый комментарий в проекте
+type ProjectEvent struct {
+	// ID — номер сообщения outbox, заполняется при доставке подписчикам; по нему они отсеивают повторы
+	ID        int
+	Type      string
+	ProjectID int
+	TaskID    int
+	CommentID int
+	ActorID   int
+	// Changes — изменённые поля задачи: ключ — имя поля языка запросов (status, priority, assignee, ...)
+	Changes map[string]FieldChange
+	// Chain — правила автоматизации, чьи действия породили это событие; по нему обрываются циклы
+	Chain []int
+}
+
+type FieldChange struct {
+	Old string
+	New string
+}
@@ -0,0 +1,38 @@
+package model
+
+// TaskFilter — условия выборки задач проекта. Пустые поля не ограничивают выборку.
+type TaskFilter struct {
+	// LabelsAny — задача помечена хотя бы одной из меток; LabelsAll — всеми сразу (по имени, без учёта регистра)
+	LabelsAny []string
+	LabelsAll []string
+
+	CustomFields []CustomFieldCondition
+	Sort         *TaskSort
+
+	// Query — выражение языка запросов, например "status != done AND due < now+7d";
+	// Viewer — пользователь, которого в выражении означает me
+	Query  string
+	Viewer int
+}
+
+// CustomFieldCondition — условие на значение пользовательского поля.
+// Field, Op и Raw приходят из запроса, FieldID, Type и Value заполняет сервис после проверки.
+type CustomFieldCondition struct {
+	Field string
+	Op    string // eq, ne, gt, gte, lt, lte, contains
+	Raw   string
+
+	FieldID int
+	Type    string
+	Value   interface{}
+}
+
+// TaskSort — сортировка выборки: по встроенной колонке или по пользовательскому полю
+type TaskSort struct {
+	Field string
+	Desc  bool
+
+	Custom  bool
+	FieldID int
+	Type    string
+}
@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+const (
+	GitLinkCommit      = "commit"
+	GitLinkPullRequest = "pull_request"
+)
+
+// GitIntegration — подключение репозитория GitHub, GitLab или Gitea к проекту. Провайдер шлёт push
+// и pull request на /integrations/git/{id}, запрос проверяется по Secret.
+type GitIntegration struct {
+	ID        int
+	ProjectID int
+	Provider  string
+	Secret    string `json:"-"`
+	// CloseStatus — статус задачи после «fixes #123» в основной ветке или слияния такого pull request
+	CloseStatus string
+	// ReviewStatus — статус задачи, когда на неё открыт pull request; пусто — не менять
+	ReviewStatus string
+	Active       bool
+	CreatedBy    int
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// GitLink — коммит или pull request, в котором упомянута задача. У коммита Ref — хеш, State — pushed;
+// у pull request Ref — номер, State — opened, closed или merged.
+type GitLink struct {
+	ID            int
+	TaskID        int
+	IntegrationID int
+	Kind          string
+	Ref           string
+	Title         string
+	URL           string
+	Author        string
+	State         string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
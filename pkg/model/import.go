@@ -0,0 +1,77 @@
+package model
+
+import "time"
+
+// Состояния задания импорта. Задание в running после перезапуска сервиса продолжается с Processed.
+const (
+	ImportQueued  = "queued"
+	ImportRunning = "running"
+	ImportDone    = "done"
+	ImportFailed  = "failed"
+)
+
+// ImportOptions — сопоставление выгрузки с приложением
+type ImportOptions struct {
+	// StatusMap — статус или колонка источника → pending, in_progress или done;
+	// несопоставленные статусы определяются по названию
+	StatusMap map[string]string
+	// Members — ID, логин или имя участника источника → email пользователя приложения
+	Members map[string]string
+}
+
+// ImportJob — фоновое задание импорта выгрузки Trello или Jira. Без ProjectID задание создаёт
+// проект с названием доски. Payload — исходный файл, после завершения не хранится.
+type ImportJob struct {
+	ID         int
+	UserID     int
+	ProjectID  *int
+	Source     string
+	Status     string
+	Options    ImportOptions
+	Payload    []byte `json:"-"`
+	Total      int
+	Processed  int
+	Report     *ImportReport
+	Error      string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	FinishedAt *time.Time
+}
+
+// ImportReport — что будет импортировано (предпросмотр) и что уже импортировано (задание)
+type ImportReport struct {
+	Board    string
+	Tasks    int
+	Comments int
+	// Skipped — архивные карточки и строки без названия
+	Skipped int
+	// Statuses — статус источника → статус приложения
+	Statuses map[string]string
+	// Members — участник источника → ID пользователя; UnmappedMembers не найдены, их задачи
+	// остаются без исполнителя, а комментарии публикуются от автора импорта
+	Members         map[string]int
+	UnmappedMembers []string
+	// Labels — метки, которых ещё нет в проекте
+	Labels   []string
+	Warnings []string
+
+	CreatedTasks    int
+	CreatedComments int
+	// Failed — задачи, которые не удалось перенести; импорт остальных продолжается
+	Failed []ImportFailure
+}
+
+type ImportFailure struct {
+	Key   string
+	Title string
+	Error string
+}
+
+// ImportItem — задача выгрузки, уже созданная заданием: повторный проход её не дублирует,
+// Comments — сколько её комментариев перенесено
+type ImportItem struct {
+	JobID      int
+	ExternalID string
+	TaskID     int
+	Comments   int
+}
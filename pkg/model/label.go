@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+type Label struct {
+	ID        int
+	ProjectID int
+	Name      string
+	Color     string
+	CreatedAt time.Time
+}
+
+type LabelUsage struct {
+	Label
+	TaskCount int
+}
@@ -0,0 +1,7 @@
+package model
+
+// References — структурированные ссылки, найденные в Markdown-тексте
+type References struct {
+	Tasks    []int
+	Mentions []string
+}
@@ -7,6 +7,10 @@ type Notification struct {
 	UserID    int       `json:"user_id"`
 	Type      string    `json:"type"`
 	Message   string    `json:"message"`
+	TaskID    *int      `json:"task_id"`
 	IsRead    bool      `json:"is_read"`
 	CreatedAt time.Time `json:"created_at"`
+
+	// Key защищает от повторного создания того же уведомления, например при повторной доставке события
+	Key string `json:"-"`
 }
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// Темы outbox: события проекта и созданные уведомления
+const (
+	TopicProjectEvent = "project_event"
+	TopicNotification = "notification"
+)
+
+const (
+	OutboxPending   = "pending"
+	OutboxPublished = "published"
+	OutboxFailed    = "failed"
+)
+
+// OutboxMessage — сообщение, записанное в той же транзакции, что и изменение, которое оно описывает.
+// Диспетчер передаёт его всем подписчикам темы; сообщение опубликовано, когда каждый из них отработал.
+type OutboxMessage struct {
+	ID            int
+	Topic         string
+	Payload       string
+	Status        string
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	CreatedAt     time.Time
+	PublishedAt   *time.Time
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// Recurrence — серия повторяющихся задач. Шаблон (Title, Description, Priority, AssignedTo)
+// копируется в каждое новое вхождение, дата вхождения становится его DueDate.
+type Recurrence struct {
+	ID          int
+	ProjectID   int
+	Rule        string
+	Title       string
+	Description string
+	Priority    string
+	AssignedTo  int
+	StartAt     time.Time
+	// NextAt — дата следующего ещё не созданного вхождения, nil — серия завершена
+	NextAt    *time.Time
+	Generated int
+	CreatedBy int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
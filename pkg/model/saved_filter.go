@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// SavedFilter — именованное выражение языка запросов. Фильтр без ProjectID выполняется по всем
+// доступным автору проектам; Shared-фильтр проекта видят все его участники, менять может только автор.
+type SavedFilter struct {
+	ID        int
+	UserID    int
+	ProjectID *int
+	Name      string
+	Query     string
+	Shared    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -0,0 +1,27 @@
+package model
+
+const (
+	SearchTask    = "task"
+	SearchComment = "comment"
+	SearchProject = "project"
+)
+
+type SearchQuery struct {
+	Query  string
+	Types  []string
+	UserID int
+	Limit  int
+	Offset int
+}
+
+// SearchResult — найденный объект. Snippet — экранированный HTML, совпадения обёрнуты в <mark>.
+type SearchResult struct {
+	Type      string
+	ID        int
+	ProjectID int
+	// TaskID заполняется для комментариев
+	TaskID  *int
+	Title   string
+	Snippet string
+	Rank    float64
+}
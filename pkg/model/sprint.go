@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+const (
+	SprintPlanned   = "planned"
+	SprintActive    = "active"
+	SprintCompleted = "completed"
+)
+
+// Sprint — итерация проекта. StartDate и EndDate — даты без времени, обе включительно.
+type Sprint struct {
+	ID          int
+	ProjectID   int
+	Name        string
+	Goal        string
+	StartDate   time.Time
+	EndDate     time.Time
+	Status      string
+	StartedAt   *time.Time
+	CompletedAt *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// TaskEvent — изменение поля задачи, пишется триггером в БД. OldValue == nil у события создания.
+type TaskEvent struct {
+	ID        int
+	TaskID    int
+	Field     string
+	OldValue  *string
+	NewValue  *string
+	ChangedAt time.Time
+}
+
+// BurndownDay — состояние спринта на конец дня. Scope — всё, что было в спринте,
+// Done — закрытое (burnup), Remaining — остаток (burndown). Minutes считаются по исходной оценке.
+type BurndownDay struct {
+	Date             string
+	ScopeTasks       int
+	DoneTasks        int
+	RemainingTasks   int
+	ScopeMinutes     int
+	DoneMinutes      int
+	RemainingMinutes int
+	// IdealRemainingTasks — линия идеального сгорания от объёма первого дня до нуля в последний
+	IdealRemainingTasks float64
+}
+
+type Burndown struct {
+	SprintID  int
+	StartDate string
+	EndDate   string
+	Days      []*BurndownDay
+}
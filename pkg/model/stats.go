@@ -0,0 +1,52 @@
+package model
+
+// ProjectStats — сводка по проекту. Время — в часах, nil если закрытых задач ещё нет.
+// LeadTime — от создания до закрытия, CycleTime — от первого перехода в in_progress до закрытия.
+type ProjectStats struct {
+	ProjectID        int
+	Total            int
+	Open             int
+	Overdue          int
+	ByStatus         map[string]int
+	ByPriority       map[string]int
+	ByAssignee       []*AssigneeStats
+	AvgLeadTimeHours *float64
+	// AvgCycleTimeHours считается только по задачам, которые проходили через in_progress
+	AvgCycleTimeHours *float64
+	Throughput        []*WeekThroughput
+}
+
+type AssigneeStats struct {
+	UserID  int
+	Open    int
+	Done    int
+	Overdue int
+}
+
+// WeekThroughput — число задач, закрытых за неделю, начинающуюся в Week (понедельник)
+type WeekThroughput struct {
+	Week string
+	Done int
+}
+
+// ProjectSummary — строка сводки по проектам пользователя: свои проекты и проекты, где он исполнитель
+type ProjectSummary struct {
+	ProjectID    int
+	Name         string
+	Total        int
+	Open         int
+	Done         int
+	Overdue      int
+	AssignedToMe int
+	OverdueForMe int
+	OwnedByMe    bool
+}
+
+type UserSummary struct {
+	UserID       int
+	Open         int
+	Overdue      int
+	AssignedToMe int
+	OverdueForMe int
+	Projects     []*ProjectSummary
+}
@@ -10,7 +10,33 @@ type Task struct {
 	Priority    string
 	AssignedTo  int
 	ProjectID   int
+	ParentID    *int
+	SeriesID    *int
+	SprintID    *int
+	Rank        string // позиция карточки в колонке доски, см. internal/rank
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 	DueDate     *time.Time
+
+	// Оценки в минутах
+	OriginalEstimate  *int
+	RemainingEstimate *int
+
+	// Assignees включает основного исполнителя AssignedTo
+	Assignees []int
+	Watchers  []int
+	Labels    []*Label
+	// CustomFields — значения пользовательских полей проекта, ключ — имя поля
+	CustomFields map[string]interface{}
+
+	// Вычисляются из Description при выдаче, в БД не хранятся
+	DescriptionHTML string
+	References      References
+}
+
+// TaskNode — узел дерева задач. Progress — доля закрытых прямых подзадач в процентах.
+type TaskNode struct {
+	Task     *Task
+	Progress float64
+	Children []*TaskNode
 }
@@ -0,0 +1,59 @@
+package model
+
+import "time"
+
+// EventPing — тестовое событие, которое отправляется только по запросу
+const EventPing = "ping"
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook — подписка проекта на события. Тело запроса подписывается HMAC-SHA256 с ключом Secret;
+// сам ключ наружу не отдаётся.
+type Webhook struct {
+	ID        int
+	ProjectID int
+	URL       string
+	Secret    string `json:"-"`
+	Events    []string
+	Active    bool
+	CreatedBy int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// WebhookDelivery — отправка одного события в webhook. Очередь хранится в БД: отправки со статусом
+// pending забирает воркер, после неудачи следующая попытка переносится с экспоненциальной задержкой.
+type WebhookDelivery struct {
+	ID        int
+	WebhookID int
+	Event     string
+	// EventID — сообщение outbox, из которого создана отправка; 0 для ping и повторных отправок
+	EventID        int
+	Payload        string
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode *int
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+
+	// Log заполняется только при запросе одной отправки
+	Log []*WebhookAttempt
+}
+
+// WebhookAttempt — одна попытка отправки: код ответа или ошибка соединения
+type WebhookAttempt struct {
+	ID         int
+	DeliveryID int
+	Attempt    int
+	StatusCode *int
+	Error      string
+	Response   string
+	DurationMs int
+	CreatedAt  time.Time
+}
@@ -0,0 +1,54 @@
+package model
+
+import "time"
+
+// Worklog — запись о затраченном времени; длительность в минутах
+type Worklog struct {
+	ID        int
+	TaskID    int
+	UserID    int
+	Minutes   int
+	WorkDate  time.Time
+	Note      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Timer — запущенный пользователем таймер; у пользователя может быть только один активный таймер
+type Timer struct {
+	UserID    int
+	TaskID    int
+	StartedAt time.Time
+}
+
+// WorklogScope ограничивает выборку для агрегатов; нулевые поля не ограничивают
+type WorklogScope struct {
+	TaskID    int
+	ProjectID int
+	UserID    int
+	From      *time.Time
+	To        *time.Time
+}
+
+type UserTime struct {
+	UserID  int
+	Minutes int
+}
+
+type TaskTime struct {
+	TaskID  int
+	Title   string
+	Minutes int
+}
+
+type DayTime struct {
+	Date    string
+	Minutes int
+}
+
+type TimeSummary struct {
+	TotalMinutes int
+	ByUser       []*UserTime
+	ByTask       []*TaskTime
+	ByDay        []*DayTime
+}